@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+
+	"github.com/tohirov1994/clients-core/pkg/openapi"
+)
+
+func main() {
+	out := flag.String("o", "client_gen.go", "file to write")
+	packageName := flag.String("package", "client", "package of the generated file")
+	flag.Parse()
+
+	document, err := openapi.Load()
+	if err != nil {
+		log.Fatalf("can't load spec: %v", err)
+	}
+	source, err := openapi.GenerateClient(document, *packageName)
+	if err != nil {
+		log.Fatalf("can't generate client: %v", err)
+	}
+	err = ioutil.WriteFile(*out, source, 0644)
+	if err != nil {
+		log.Fatalf("can't write %s: %v", *out, err)
+	}
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+	"github.com/tohirov1994/clients-core/pkg/receipt"
+)
+
+const signInAttempts = 3
+
+const menu = `
+1. Cards
+2. Transfer
+3. Pay service
+4. ATMs
+5. Receipt
+6. Beneficiaries
+q. Exit
+`
+
+var errorAmount = errors.New("amount must be a positive number")
+var errorPAN = errors.New("pan must be a number")
+var errorBeneficiary = errors.New("beneficiary must be a number")
+
+type app struct {
+	db       *sql.DB
+	in       *bufio.Scanner
+	out      io.Writer
+	batch    bool
+	clientId int
+}
+
+func newApp(db *sql.DB, in io.Reader, out io.Writer, batch bool) *app {
+	return &app{db: db, in: bufio.NewScanner(in), out: out, batch: batch}
+}
+
+func (a *app) run() error {
+	err := a.signIn()
+	if err != nil {
+		return err
+	}
+	for {
+		if !a.batch {
+			fmt.Fprint(a.out, menu)
+		}
+		choice, err := a.ask("> ")
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch choice {
+		case "1":
+			err = a.listCards()
+		case "2":
+			err = a.transfer()
+		case "3":
+			err = a.payService()
+		case "4":
+			err = a.listATMs()
+		case "5":
+			err = a.showReceipt()
+		case "6":
+			err = a.beneficiaries()
+		case "q":
+			return nil
+		default:
+			err = fmt.Errorf("unknown menu item %q", choice)
+		}
+		if err != nil {
+			if a.batch {
+				return err
+			}
+			fmt.Fprintf(a.out, "error: %s\n", message(err))
+		}
+	}
+}
+
+func (a *app) ask(prompt string) (string, error) {
+	if !a.batch {
+		fmt.Fprint(a.out, prompt)
+	}
+	if !a.in.Scan() {
+		if a.in.Err() != nil {
+			return "", a.in.Err()
+		}
+		return "", io.EOF
+	}
+	return strings.TrimSpace(a.in.Text()), nil
+}
+
+func (a *app) askInt64(prompt string) (int64, error) {
+	answer, err := a.ask(prompt)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(answer, 10, 64)
+	if err != nil {
+		return 0, errorPAN
+	}
+	return value, nil
+}
+
+func (a *app) askAmount() (int, error) {
+	answer, err := a.ask("amount: ")
+	if err != nil {
+		return 0, err
+	}
+	amount, err := strconv.Atoi(answer)
+	if err != nil || amount <= 0 {
+		return 0, errorAmount
+	}
+	return amount, nil
+}
+
+func (a *app) signIn() error {
+	for attempt := 1; ; attempt++ {
+		login, err := a.ask("login: ")
+		if err != nil {
+			return err
+		}
+		password, err := a.ask("password: ")
+		if err != nil {
+			return err
+		}
+		clientId, ok, err := core.SignIn(login, password, a.db)
+		if err == nil && !ok {
+			err = core.ErrorPassword
+		}
+		if err == nil {
+			a.clientId = clientId
+			fmt.Fprintf(a.out, "signed in as %s\n", login)
+			return nil
+		}
+		if a.batch || attempt == signInAttempts {
+			return err
+		}
+		fmt.Fprintf(a.out, "error: %s\n", message(err))
+	}
+}
+
+func (a *app) listCards() error {
+	cards, err := core.CardsGet(a.clientId, a.db)
+	if err != nil {
+		return err
+	}
+	for _, card := range cards {
+		fmt.Fprintf(a.out, "%d %s balance %d\n", card.PAN, card.HolderName, card.Balance)
+	}
+	return nil
+}
+
+// sourceCard asks for a card only when the client has several, as
+// GetTransferCard and SelectCards intend. Zero means the client's only card.
+func (a *app) sourceCard() (int64, error) {
+	count, err := core.GetTransferCard(a.clientId, a.db)
+	if err != nil {
+		return 0, err
+	}
+	if count <= 1 {
+		return 0, nil
+	}
+	if !a.batch {
+		err = a.listCards()
+		if err != nil {
+			return 0, err
+		}
+	}
+	pan, err := a.askInt64("from card: ")
+	if err != nil {
+		return 0, err
+	}
+	return core.SelectCards(a.clientId, pan, a.db)
+}
+
+func (a *app) transfer() error {
+	fromPAN, err := a.sourceCard()
+	if err != nil {
+		return err
+	}
+	toPAN, err := a.askInt64("to card: ")
+	if err != nil {
+		return err
+	}
+	confirmed, err := a.confirmReceiver(toPAN)
+	if err != nil || !confirmed {
+		return err
+	}
+	amount, err := a.askAmount()
+	if err != nil {
+		return err
+	}
+	if fromPAN == 0 {
+		_, err = core.OneCard(toPAN, a.clientId, amount, a.db)
+	} else {
+		_, err = core.MoreCard(fromPAN, toPAN, amount, a.db)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "transferred %d to %d\n", amount, toPAN)
+	return nil
+}
+
+// confirmReceiver shows who gets the money and asks once more before a
+// transfer to a card the client hasn't saved, where a mistyped PAN would go
+// unnoticed. Batch scripts aren't asked.
+func (a *app) confirmReceiver(toPAN int64) (bool, error) {
+	if a.batch {
+		return true, nil
+	}
+	receiver, err := core.LookupReceiver(a.clientId, toPAN, a.db)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(a.out, "receiver: %s (%s)\n", receiver.MaskedName, receiver.Scheme)
+	known, err := core.IsBeneficiary(a.clientId, toPAN, a.db)
+	if err != nil || known {
+		return known, err
+	}
+	answer, err := a.ask(fmt.Sprintf("%s is not among your beneficiaries, transfer anyway? (y/n): ", core.MaskPAN(toPAN)))
+	if err != nil {
+		return false, err
+	}
+	if answer != "y" {
+		fmt.Fprintln(a.out, "transfer cancelled")
+		return false, nil
+	}
+	return true, nil
+}
+
+func (a *app) beneficiaries() error {
+	if !a.batch {
+		beneficiaries, err := core.BeneficiariesGet(a.clientId, a.db)
+		if err != nil {
+			return err
+		}
+		for _, beneficiary := range beneficiaries {
+			fmt.Fprintf(a.out, "%d %s %d %s\n", beneficiary.Id, beneficiary.Nickname, beneficiary.PAN, beneficiary.HolderName)
+		}
+	}
+	action, err := a.ask("a. Add, r. Remove, t. Transfer, enter to go back: ")
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "a":
+		return a.addBeneficiary()
+	case "r":
+		return a.removeBeneficiary()
+	case "t":
+		return a.transferToBeneficiary()
+	case "":
+		return nil
+	}
+	return fmt.Errorf("unknown beneficiaries action %q", action)
+}
+
+func (a *app) addBeneficiary() error {
+	nickname, err := a.ask("nickname: ")
+	if err != nil {
+		return err
+	}
+	pan, err := a.askInt64("card: ")
+	if err != nil {
+		return err
+	}
+	holderName, err := a.ask("name on the card: ")
+	if err != nil {
+		return err
+	}
+	beneficiary, err := core.AddBeneficiary(a.clientId, nickname, pan, holderName, a.db)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "saved %s as beneficiary %d\n", beneficiary.Nickname, beneficiary.Id)
+	return nil
+}
+
+func (a *app) askBeneficiary() (int64, error) {
+	answer, err := a.ask("beneficiary: ")
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(answer, 10, 64)
+	if err != nil {
+		return 0, errorBeneficiary
+	}
+	return id, nil
+}
+
+func (a *app) removeBeneficiary() error {
+	id, err := a.askBeneficiary()
+	if err != nil {
+		return err
+	}
+	err = core.RemoveBeneficiary(a.clientId, id, a.db)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "removed beneficiary %d\n", id)
+	return nil
+}
+
+func (a *app) transferToBeneficiary() error {
+	id, err := a.askBeneficiary()
+	if err != nil {
+		return err
+	}
+	fromPAN, err := a.sourceCard()
+	if err != nil {
+		return err
+	}
+	amount, err := a.askAmount()
+	if err != nil {
+		return err
+	}
+	err = core.TransferToBeneficiary(a.clientId, id, fromPAN, amount, a.db)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "transferred %d to beneficiary %d\n", amount, id)
+	return nil
+}
+
+func (a *app) payService() error {
+	if !a.batch {
+		providers, err := core.ProvidersGet(a.db)
+		if err != nil {
+			return err
+		}
+		for _, provider := range providers {
+			if provider.Active {
+				fmt.Fprintf(a.out, "%d %s (%s)\n", provider.Id, provider.Service, provider.Category)
+			}
+		}
+	}
+	name, err := a.ask("service: ")
+	if err != nil {
+		return err
+	}
+	provider, err := core.ProviderGet(name, a.db)
+	if err != nil {
+		return err
+	}
+	reference, err := a.ask(provider.ReferenceLabel + ": ")
+	if err != nil {
+		return err
+	}
+	fromPAN, err := a.sourceCard()
+	if err != nil {
+		return err
+	}
+	amount, err := a.askAmount()
+	if err != nil {
+		return err
+	}
+	var receipt core.Receipt
+	if fromPAN == 0 {
+		receipt, err = core.ServicesPayOneCard(name, reference, a.clientId, amount, a.db)
+	} else {
+		receipt, err = core.ServicesPayMoreCard(name, reference, fromPAN, amount, a.db)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "paid %d for %s, receipt %s\n", amount, name, receipt.Number)
+	return nil
+}
+
+func (a *app) listATMs() error {
+	atms, err := core.ATMsGet(a.db)
+	if err != nil {
+		return err
+	}
+	for _, atm := range atms {
+		fmt.Fprintf(a.out, "%d %s, %s, %s\n", atm.Id, atm.City, atm.District, atm.Street)
+	}
+	return nil
+}
+
+func (a *app) showReceipt() error {
+	number, err := a.ask("receipt number: ")
+	if err != nil {
+		return err
+	}
+	stored, err := core.ReceiptGet(number, a.clientId, a.db)
+	if err != nil {
+		return err
+	}
+	return receipt.WriteText(a.out, stored)
+}
+
+func message(err error) string {
+	switch {
+	case errors.Is(err, core.ErrorPassword):
+		return "wrong login or password"
+	case errors.Is(err, core.ErrInsufficientFunds):
+		return "not enough money on the card"
+	case errors.Is(err, core.ErrCardBlocked):
+		return "the card is blocked"
+	case errors.Is(err, core.ErrCardNotFound):
+		return "no such card"
+	case errors.Is(err, core.ErrServiceNotFound):
+		return "no such service"
+	case errors.Is(err, core.ErrInvalidReference):
+		return "the reference doesn't fit the service"
+	case errors.Is(err, core.ErrAmountOutOfRange):
+		return "the service doesn't take this amount"
+	case errors.Is(err, core.ErrServiceInactive):
+		return "the service is not available"
+	case errors.Is(err, core.ErrPaymentRejected):
+		return "the provider refused the payment"
+	case errors.Is(err, core.ErrPaymentPending):
+		return "the provider hasn't confirmed the payment yet, the money is held until it does"
+	case errors.Is(err, core.ErrReceiptNotFound):
+		return "no such receipt"
+	case errors.Is(err, core.ErrTooManyLookups):
+		return "too many cards looked up, try again later"
+	case errors.Is(err, core.ErrBeneficiaryNotFound):
+		return "no such beneficiary"
+	case errors.Is(err, core.ErrBeneficiaryExists):
+		return "the nickname or the card is already saved"
+	case errors.Is(err, core.ErrHolderMismatch):
+		return "no such card with this name"
+	case errors.Is(err, core.ErrInvalidBeneficiary):
+		return "the nickname is required"
+	}
+	return err.Error()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+func openDb(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	err = core.Init(db)
+	if err != nil {
+		t.Fatalf("can't init db: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients VALUES (2, 'Jack', 'Jackson', 'jack', 'secret');
+INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 500, 'JACK JACKSON', 111, 0525, 2);
+INSERT INTO clients_cards VALUES (3, 2021600000000002, 2222, 900, 'JACK JACKSON', 222, 0626, 2);`)
+	if err != nil {
+		t.Fatalf("can't insert client: %v", err)
+	}
+	return db
+}
+
+func runScript(t *testing.T, db *sql.DB, batch bool, lines ...string) (string, error) {
+	var out bytes.Buffer
+	err := newApp(db, strings.NewReader(strings.Join(lines, "\n")+"\n"), &out, batch).run()
+	return out.String(), err
+}
+
+func TestApp_BatchSession(t *testing.T) {
+	db := openDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	out, err := runScript(t, db, true,
+		"jack", "secret",
+		"1",
+		"2", "2021600000000002", "2021600000000000", "100",
+		"3", "internet", "100200", "2021600000000001", "50",
+		"4",
+		"q",
+	)
+	if err != nil {
+		t.Fatalf("can't run script: %v", err)
+	}
+	var number string
+	err = db.QueryRow(`SELECT number FROM receipts`).Scan(&number)
+	if err != nil {
+		t.Fatalf("can't get receipt: %v", err)
+	}
+	want := `signed in as jack
+2021600000000001 JACK JACKSON balance 500
+2021600000000002 JACK JACKSON balance 900
+transferred 100 to 2021600000000000
+paid 50 for internet, receipt ` + number + `
+1 Dushanbe, Somoni, Foteh51
+`
+	if out != want {
+		t.Errorf("output just be\n%s\ngot\n%s", want, out)
+	}
+	balance, _, _ := core.GetCurrentBalanceClientPAN(2021600000000002, db)
+	if balance != 800 {
+		t.Errorf("balance just be 800: %d", balance)
+	}
+}
+
+func TestApp_BatchStopsAtFirstError(t *testing.T) {
+	db := openDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := runScript(t, db, true, "jack", "wrong")
+	if !errors.Is(err, core.ErrorPassword) {
+		t.Errorf("wrong password just be ErrorPassword: %v", err)
+	}
+	_, err = runScript(t, db, true, "jack", "secret", "2", "2021600000000001", "2021600000000000", "100000", "4")
+	if !errors.Is(err, core.ErrInsufficientFunds) {
+		t.Errorf("overdraft just be ErrInsufficientFunds: %v", err)
+	}
+	_, err = runScript(t, db, true, "jack", "secret", "2", "2021600000000000")
+	if !errors.Is(err, core.ErrCardNotFound) {
+		t.Errorf("someone else's card just be ErrCardNotFound: %v", err)
+	}
+}
+
+func TestApp_InteractiveKeepsGoingAfterErrors(t *testing.T) {
+	db := openDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	out, err := runScript(t, db, false,
+		"jack", "wrong",
+		"jack", "secret",
+		"3", "water",
+		"9",
+		"q",
+	)
+	if err != nil {
+		t.Fatalf("can't run session: %v", err)
+	}
+	for _, want := range []string{"error: wrong login or password", "signed in as jack", "4. ATMs", "error: no such service", `unknown menu item "9"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output just contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestApp_ShowsReceipt(t *testing.T) {
+	db := openDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	paid, err := core.ServicesPayMoreCard("internet", "100200", 2021600000000001, 50, db)
+	if err != nil {
+		t.Fatalf("can't pay service: %v", err)
+	}
+	out, err := runScript(t, db, false,
+		"jack", "secret",
+		"5", paid.Number,
+		"5", "00000000-00000000",
+		"q",
+	)
+	if err != nil {
+		t.Fatalf("can't run session: %v", err)
+	}
+	for _, want := range []string{"Receipt:      " + paid.Number, "Card:         202160******0001", "Total:        50", "error: no such receipt"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output just contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestApp_Beneficiaries(t *testing.T) {
+	db := openDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	out, err := runScript(t, db, false,
+		"jack", "secret",
+		"2", "2021600000000001", "2021600000000000", "n",
+		"6", "a", "admin", "2021600000000000", "JACK JACKSON",
+		"6", "a", "admin", "2021600000000000", "admin client",
+		"2", "2021600000000001", "2021600000000000", "100",
+		"6", "t", "1", "2021600000000002", "50",
+		"6", "r", "1",
+		"q",
+	)
+	if err != nil {
+		t.Fatalf("can't run session: %v", err)
+	}
+	for _, want := range []string{
+		"receiver: A*** C*** (local)", "202160******0000 is not among your beneficiaries", "transfer cancelled",
+		"error: no such card with this name", "saved admin as beneficiary 1",
+		"transferred 100 to 2021600000000000", "1 admin 2021600000000000 A*** C***",
+		"transferred 50 to beneficiary 1", "removed beneficiary 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output just contain %q:\n%s", want, out)
+		}
+	}
+	balance, _, _ := core.GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000150 {
+		t.Errorf("balance just be 1000150: %d", balance)
+	}
+}
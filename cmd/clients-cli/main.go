@@ -0,0 +1,46 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tohirov1994/clients-core/pkg/biller"
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+func main() {
+	dsn := flag.String("db", "db.sqlite?_busy_timeout=5000", "sqlite database")
+	batch := flag.Bool("batch", false, "read answers from stdin without prompts and stop at the first error")
+	billers := flag.String("billers", "", "HTTP billers of the service catalog as name=url,name=url")
+	fraud := flag.Bool("fraud", true, "screen transfers with the default fraud rules")
+	flag.Parse()
+
+	err := biller.RegisterHTTP(*billers)
+	if err != nil {
+		log.Fatalf("can't register billers: %v", err)
+	}
+	if *fraud {
+		core.FraudRules = core.DefaultFraudRules()
+	}
+
+	db, err := sql.Open("sqlite3", *dsn)
+	if err != nil {
+		log.Fatalf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("can't close db: %v", err)
+		}
+	}()
+	err = core.Init(db)
+	if err != nil {
+		log.Fatalf("can't init db: %v", err)
+	}
+	err = newApp(db, os.Stdin, os.Stdout, *batch).run()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"log"
+	"net"
+	"os"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tohirov1994/clients-core/pkg/biller"
+	"github.com/tohirov1994/clients-core/pkg/core"
+	"github.com/tohirov1994/clients-core/pkg/rpc"
+)
+
+func main() {
+	addr := flag.String("addr", ":9998", "address to listen on")
+	dsn := flag.String("db", "db.sqlite?_busy_timeout=5000", "sqlite database")
+	billers := flag.String("billers", "", "HTTP billers of the service catalog as name=url,name=url")
+	fraud := flag.Bool("fraud", true, "screen transfers with the default fraud rules")
+	flag.Parse()
+
+	keys := rpc.ParseServiceKeys(os.Getenv("CLIENTS_SERVICE_KEYS"))
+	if len(keys) == 0 {
+		log.Fatal("CLIENTS_SERVICE_KEYS must be set")
+	}
+	err := biller.RegisterHTTP(*billers)
+	if err != nil {
+		log.Fatalf("can't register billers: %v", err)
+	}
+	if *fraud {
+		core.FraudRules = core.DefaultFraudRules()
+	}
+	db, err := sql.Open("sqlite3", *dsn)
+	if err != nil {
+		log.Fatalf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("can't close db: %v", err)
+		}
+	}()
+	err = core.Init(db)
+	if err != nil {
+		log.Fatalf("can't init db: %v", err)
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("can't listen on %s: %v", *addr, err)
+	}
+	log.Printf("listening on %s", *addr)
+	err = rpc.NewGRPCServer(db, keys).Serve(listener)
+	if err != nil {
+		log.Fatalf("can't serve: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tohirov1994/clients-core/pkg/biller"
+	"github.com/tohirov1994/clients-core/pkg/core"
+	"github.com/tohirov1994/clients-core/pkg/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":9999", "address to listen on")
+	dsn := flag.String("db", "db.sqlite?_busy_timeout=5000", "sqlite database")
+	schedule := flag.Duration("schedule", time.Minute, "how often to make scheduled payments and transfers, 0 to disable")
+	resolve := flag.Duration("resolve", time.Minute, "how often to ask billers about pending payments, 0 to disable")
+	billers := flag.String("billers", "", "HTTP billers of the service catalog as name=url,name=url")
+	fraud := flag.Bool("fraud", true, "screen transfers with the default fraud rules")
+	flag.Parse()
+
+	secret := os.Getenv("CLIENTS_SECRET")
+	if secret == "" {
+		log.Fatal("CLIENTS_SECRET must be set")
+	}
+	err := biller.RegisterHTTP(*billers)
+	if err != nil {
+		log.Fatalf("can't register billers: %v", err)
+	}
+	if *fraud {
+		core.FraudRules = core.DefaultFraudRules()
+	}
+	db, err := sql.Open("sqlite3", *dsn)
+	if err != nil {
+		log.Fatalf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("can't close db: %v", err)
+		}
+	}()
+	err = core.Init(db)
+	if err != nil {
+		log.Fatalf("can't init db: %v", err)
+	}
+	if *schedule > 0 {
+		scheduler := core.NewScheduler(db)
+		scheduler.OnError = func(err error) {
+			log.Printf("can't make scheduled payments: %v", err)
+		}
+		go func() {
+			_ = scheduler.Run(context.Background(), *schedule)
+		}()
+		host, _ := os.Hostname()
+		worker := core.NewOrderWorker(db, fmt.Sprintf("%s:%d", host, os.Getpid()))
+		worker.OnError = func(err error) {
+			log.Printf("can't execute transfer orders: %v", err)
+		}
+		go func() {
+			_ = worker.Run(context.Background(), *schedule)
+		}()
+	}
+	if *resolve > 0 {
+		if *resolve >= core.BillerHoldTTL {
+			log.Printf("pending payments are resolved every %v, after their holds of %v run out", *resolve, core.BillerHoldTTL)
+		}
+		go resolvePayments(db, *resolve)
+	}
+	log.Printf("listening on %s", *addr)
+	err = http.ListenAndServe(*addr, server.NewServer(db, []byte(secret)))
+	if err != nil {
+		log.Fatalf("can't serve: %v", err)
+	}
+}
+
+// resolvePayments settles the payments billers left unanswered.
+func resolvePayments(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		_, err := core.ResolvePendingPayments(db)
+		if err != nil {
+			log.Printf("can't resolve pending payments: %v", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tohirov1994/clients-core/pkg/core"
+	"github.com/tohirov1994/clients-core/pkg/settlement"
+)
+
+func main() {
+	dsn := flag.String("db", "db.sqlite?_busy_timeout=5000", "sqlite database")
+	dir := flag.String("dir", "settlements", "directory for the settlement files")
+	cutoff := flag.String("cutoff", "", "settle payments made before this RFC 3339 time, now when empty")
+	regenerate := flag.Int64("regenerate", 0, "write the files of this batch again instead of settling")
+	void := flag.Int64("void", 0, "void this batch instead of settling")
+	manager := flag.Int("manager", 0, "id of the manager voiding the batch")
+	flag.Parse()
+
+	db, err := sql.Open("sqlite3", *dsn)
+	if err != nil {
+		log.Fatalf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("can't close db: %v", err)
+		}
+	}()
+	err = core.Init(db)
+	if err != nil {
+		log.Fatalf("can't init db: %v", err)
+	}
+
+	if *void != 0 {
+		err = core.VoidSettlement(*void, *manager, db)
+		if err != nil {
+			log.Fatalf("can't void settlement: %v", err)
+		}
+		fmt.Printf("voided settlement %d\n", *void)
+		return
+	}
+	var batch core.Settlement
+	if *regenerate != 0 {
+		batch, err = core.SettlementGet(*regenerate, db)
+	} else {
+		until := time.Now()
+		if *cutoff != "" {
+			until, err = time.Parse(time.RFC3339, *cutoff)
+			if err != nil {
+				log.Fatalf("can't parse cutoff: %v", err)
+			}
+		}
+		batch, err = core.Settle(until, db)
+	}
+	if err != nil {
+		log.Fatalf("can't settle: %v", err)
+	}
+	paths, err := settlement.WriteFiles(*dir, batch)
+	if err != nil {
+		log.Fatalf("can't write settlement files: %v", err)
+	}
+	for _, provider := range batch.Providers {
+		fmt.Printf("settlement %d: %s %d in %d entries\n", batch.Id, provider.Service, provider.Total, len(provider.Entries))
+	}
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+}
@@ -3,6 +3,9 @@ module github.com/tohirov1994/clients-core
 go 1.13
 
 require (
+	github.com/golang/protobuf v1.3.5
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/mattn/go-sqlite3 v2.0.3+incompatible
 	github.com/tohirov1994/database v0.0.0-20200213191104-6f418f4ab7c9
+	google.golang.org/grpc v1.27.1
 )
@@ -0,0 +1,88 @@
+package biller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+var errUnreachable = errors.New("fake biller is unreachable")
+
+// Fake is an in-memory biller for tests. It keeps the payments it posted,
+// so PaymentStatus answers for them the way a provider would.
+type Fake struct {
+	mu sync.Mutex
+	// Valid accepts references; every reference is valid when it is nil.
+	Valid func(reference string) bool
+	// Reject refuses every payment.
+	Reject bool
+	// Lose posts payments but fails as if the answer was lost.
+	Lose bool
+	// Unreachable fails every call before it reaches the provider.
+	Unreachable bool
+	posted      map[string]core.BillerPayment
+}
+
+func NewFake() *Fake {
+	return &Fake{posted: map[string]core.BillerPayment{}}
+}
+
+func (f *Fake) ValidateReference(ctx context.Context, reference string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Unreachable {
+		return errUnreachable
+	}
+	if f.Valid != nil && !f.Valid(reference) {
+		return fmt.Errorf("reference %q: %w", reference, core.ErrInvalidReference)
+	}
+	return nil
+}
+
+func (f *Fake) PostPayment(ctx context.Context, payment core.BillerPayment) (confirmation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Unreachable {
+		return "", errUnreachable
+	}
+	if f.Reject {
+		return "", fmt.Errorf("payment %s: %w", payment.Id, core.ErrPaymentRejected)
+	}
+	f.posted[payment.Id] = payment
+	if f.Lose {
+		return "", context.DeadlineExceeded
+	}
+	return confirmationOf(payment.Id), nil
+}
+
+// PaymentStatus confirms the payments Fake posted and rejects the rest,
+// which it never saw.
+func (f *Fake) PaymentStatus(ctx context.Context, paymentId string) (status, confirmation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Unreachable {
+		return "", "", errUnreachable
+	}
+	if _, ok := f.posted[paymentId]; !ok {
+		return core.PaymentRejected, "", nil
+	}
+	return core.PaymentConfirmed, confirmationOf(paymentId), nil
+}
+
+// Posted returns the payments the provider has received.
+func (f *Fake) Posted() []core.BillerPayment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	payments := make([]core.BillerPayment, 0, len(f.posted))
+	for _, payment := range f.posted {
+		payments = append(payments, payment)
+	}
+	return payments
+}
+
+func confirmationOf(paymentId string) string {
+	return "fake-" + paymentId
+}
@@ -0,0 +1,257 @@
+package biller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+const pan = 2021600000000000
+
+// openDb opens a bank whose internet service confirms payments with b.
+func openDb(t *testing.T, b core.Biller) (*sql.DB, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	err = core.Init(db)
+	if err != nil {
+		t.Fatalf("can't init db: %v", err)
+	}
+	core.Billers["test"] = b
+	err = core.SetProvider(core.ServiceProvider{Service: "internet", Category: "internet", ReferenceLabel: "contract id",
+		ReferenceFormat: "digits", MinAmount: 1, Fee: 2, Active: true, Biller: "test"}, 1, db)
+	if err != nil {
+		t.Fatalf("can't set provider: %v", err)
+	}
+	return db, func() {
+		delete(core.Billers, "test")
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}
+}
+
+func balances(t *testing.T, db *sql.DB) (card, service int) {
+	card, _, err := core.GetCurrentBalanceClientPAN(pan, db)
+	if err != nil {
+		t.Fatalf("can't get card balance: %v", err)
+	}
+	err = db.QueryRow(`SELECT balance FROM services WHERE service = 'internet'`).Scan(&service)
+	if err != nil {
+		t.Fatalf("can't get service balance: %v", err)
+	}
+	return card, service
+}
+
+func TestFake_Confirmed(t *testing.T) {
+	fake := NewFake()
+	db, closeDb := openDb(t, fake)
+	defer closeDb()
+	receipt, err := core.ServicesPayMoreCard("internet", "100200", pan, 200, db)
+	if err != nil {
+		t.Fatalf("can't pay service: %v", err)
+	}
+	if receipt.Number == "" || receipt.Total() != 202 {
+		t.Errorf("receipt just be of 200 and fee 2: %+v", receipt)
+	}
+	posted := fake.Posted()
+	if len(posted) != 1 || posted[0].Id != "payment-1" || posted[0].Reference != "100200" || posted[0].Amount != 200 {
+		t.Errorf("biller just get the payment: %+v", posted)
+	}
+	card, service := balances(t, db)
+	if card != 1000000-202 || service != 1500+200 {
+		t.Errorf("balances just be %d and %d: %d %d", 1000000-202, 1500+200, card, service)
+	}
+}
+
+func TestFake_ConfirmedWithoutAudit(t *testing.T) {
+	fake := NewFake()
+	db, closeDb := openDb(t, fake)
+	defer closeDb()
+	defer func(onError func(error)) { core.OnAuditError = onError }(core.OnAuditError)
+	var auditErr error
+	core.OnAuditError = func(err error) { auditErr = err }
+	_, err := db.Exec(`ALTER TABLE audit_log RENAME TO audit_log_away;`)
+	if err != nil {
+		t.Fatalf("can't break the audit log: %v", err)
+	}
+	receipt, err := core.ServicesPayMoreCard("internet", "100200", pan, 200, db)
+	if err != nil || receipt.Number == "" {
+		t.Errorf("charged payment just come back with its receipt alone: %+v %v", receipt, err)
+	}
+	if auditErr == nil {
+		t.Errorf("audit error just be reported")
+	}
+	card, _ := balances(t, db)
+	if card != 1000000-202 {
+		t.Errorf("payment just be charged: %d", card)
+	}
+}
+
+func TestFake_Rejected(t *testing.T) {
+	fake := NewFake()
+	fake.Reject = true
+	db, closeDb := openDb(t, fake)
+	defer closeDb()
+	_, err := core.ServicesPayMoreCard("internet", "100200", pan, 200, db)
+	if !errors.Is(err, core.ErrPaymentRejected) {
+		t.Errorf("refused payment just be ErrPaymentRejected: %v", err)
+	}
+	card, service := balances(t, db)
+	if card != 1000000 || service != 1500 {
+		t.Errorf("refused payment just go back to the card: %d %d", card, service)
+	}
+	var status string
+	err = db.QueryRow(`SELECT status FROM biller_payments`).Scan(&status)
+	if err != nil || status != core.PaymentRejected {
+		t.Errorf("payment just be rejected: %s %v", status, err)
+	}
+}
+
+func TestFake_InvalidReference(t *testing.T) {
+	fake := NewFake()
+	fake.Valid = func(reference string) bool { return reference == "100200" }
+	db, closeDb := openDb(t, fake)
+	defer closeDb()
+	_, err := core.ServicesPayMoreCard("internet", "999999", pan, 200, db)
+	if !errors.Is(err, core.ErrInvalidReference) {
+		t.Errorf("unknown account just be ErrInvalidReference: %v", err)
+	}
+	var reserved int
+	err = db.QueryRow(`SELECT count(*) FROM biller_payments`).Scan(&reserved)
+	if err != nil || reserved != 0 {
+		t.Errorf("nothing just be reserved: %d %v", reserved, err)
+	}
+}
+
+func TestFake_LostAnswer(t *testing.T) {
+	fake := NewFake()
+	fake.Lose = true
+	db, closeDb := openDb(t, fake)
+	defer closeDb()
+	receipt, err := core.ServicesPayMoreCard("internet", "100200", pan, 200, db)
+	if err != nil {
+		t.Fatalf("payment the biller has just be confirmed by its status: %v", err)
+	}
+	if receipt.Amount != 200 {
+		t.Errorf("receipt just be of 200: %+v", receipt)
+	}
+}
+
+// silent posts payments but answers nothing until it is told to.
+type silent struct {
+	*Fake
+	answer bool
+}
+
+func (s *silent) PostPayment(ctx context.Context, payment core.BillerPayment) (string, error) {
+	_, _ = s.Fake.PostPayment(ctx, payment)
+	return "", context.DeadlineExceeded
+}
+
+func (s *silent) PaymentStatus(ctx context.Context, paymentId string) (string, string, error) {
+	if !s.answer {
+		return "", "", context.DeadlineExceeded
+	}
+	return s.Fake.PaymentStatus(ctx, paymentId)
+}
+
+func TestFake_Pending(t *testing.T) {
+	biller := &silent{Fake: NewFake()}
+	db, closeDb := openDb(t, biller)
+	defer closeDb()
+	timeout := core.BillerTimeout
+	core.BillerTimeout = 0
+	defer func() { core.BillerTimeout = timeout }()
+
+	_, err := core.ServicesPayMoreCard("internet", "100200", pan, 200, db)
+	if !errors.Is(err, core.ErrPaymentPending) {
+		t.Errorf("unanswered payment just be ErrPaymentPending: %v", err)
+	}
+	card, available, err := core.GetCurrentBalanceClientPAN(pan, db)
+	if err != nil || card != 1000000 || available != 1000000-202 {
+		t.Errorf("pending payment just be held on the card: %d %d %v", card, available, err)
+	}
+	resolved, err := core.ResolvePendingPayments(db)
+	if err != nil || resolved != 0 {
+		t.Errorf("silent biller just resolve nothing: %d %v", resolved, err)
+	}
+	biller.answer = true
+	resolved, err = core.ResolvePendingPayments(db)
+	if err != nil || resolved != 1 {
+		t.Errorf("answering biller just resolve the payment: %d %v", resolved, err)
+	}
+	card, service := balances(t, db)
+	if card != 1000000-202 || service != 1500+200 {
+		t.Errorf("confirmed payment just reach the service: %d %d", card, service)
+	}
+	var receipts int
+	err = db.QueryRow(`SELECT count(*) FROM receipts`).Scan(&receipts)
+	if err != nil || receipts != 1 {
+		t.Errorf("confirmed payment just have a receipt: %d %v", receipts, err)
+	}
+}
+
+func TestFake_PendingHoldRunsOut(t *testing.T) {
+	biller := &silent{Fake: NewFake()}
+	db, closeDb := openDb(t, biller)
+	defer closeDb()
+	timeout, ttl := core.BillerTimeout, core.BillerHoldTTL
+	core.BillerTimeout, core.BillerHoldTTL = 0, 0
+	defer func() { core.BillerTimeout, core.BillerHoldTTL = timeout, ttl }()
+
+	_, err := core.ServicesPayMoreCard("internet", "100200", pan, 200, db)
+	if !errors.Is(err, core.ErrPaymentPending) {
+		t.Errorf("unanswered payment just be ErrPaymentPending: %v", err)
+	}
+	card, available, err := core.GetCurrentBalanceClientPAN(pan, db)
+	if err != nil || card != 1000000 || available != 1000000 {
+		t.Errorf("money of a run out hold just be free again: %d %d %v", card, available, err)
+	}
+	biller.answer = true
+	resolved, err := core.ResolvePendingPayments(db)
+	if err != nil || resolved != 1 {
+		t.Errorf("late answer just resolve the payment: %d %v", resolved, err)
+	}
+	card, service := balances(t, db)
+	if card != 1000000-202 || service != 1500+200 {
+		t.Errorf("late confirmed payment just reach the service: %d %d", card, service)
+	}
+}
+
+func TestFake_Scheduler(t *testing.T) {
+	fake := NewFake()
+	db, closeDb := openDb(t, fake)
+	defer closeDb()
+	id, err := core.CreateSchedule(1, pan, "internet", "100200", "@daily", 100, db)
+	if err != nil {
+		t.Fatalf("can't create schedule: %v", err)
+	}
+	scheduler := core.NewScheduler(db)
+	scheduler.Now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	fake.Reject = true
+	paid, err := scheduler.RunDue()
+	if err != nil || paid != 0 {
+		t.Errorf("refused payment just not be paid: %d %v", paid, err)
+	}
+	fake.Reject = false
+	scheduler.Now = func() time.Time { return time.Now().Add(26 * time.Hour) }
+	paid, err = scheduler.RunDue()
+	if err != nil || paid != 1 {
+		t.Errorf("retry just pay: %d %v", paid, err)
+	}
+	runs, err := core.ScheduleRunsGet(id, 1, db)
+	if err != nil {
+		t.Fatalf("can't get runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Error == "" || runs[1].TransactionId == 0 || runs[1].Attempt != 2 {
+		t.Errorf("runs just be a refusal and a payment: %+v", runs)
+	}
+}
@@ -0,0 +1,142 @@
+package biller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+// HTTP talks to a provider over its JSON API:
+//
+//	GET  {base}/references/{reference}  200 when the account exists, 404 when not
+//	POST {base}/payments                {"id", "reference", "amount"} -> {"confirmation"}
+//	GET  {base}/payments/{id}           {"status", "confirmation"}, 404 when never posted
+//
+// A 4xx answer to a post refuses the payment; anything else unanswered
+// leaves it unknown.
+type HTTP struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewHTTP(baseURL string) *HTTP {
+	return &HTTP{BaseURL: strings.TrimSuffix(baseURL, "/"), Client: http.DefaultClient}
+}
+
+type paymentRequest struct {
+	Id        string `json:"id"`
+	Reference string `json:"reference"`
+	Amount    int    `json:"amount"`
+}
+
+type paymentResponse struct {
+	Status       string `json:"status"`
+	Confirmation string `json:"confirmation"`
+}
+
+func (h *HTTP) ValidateReference(ctx context.Context, reference string) error {
+	response, err := h.do(ctx, http.MethodGet, "/references/"+url.PathEscape(reference), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(response)
+	switch {
+	case response.StatusCode == http.StatusOK:
+		return nil
+	case response.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("reference %q: %w", reference, core.ErrInvalidReference)
+	}
+	return fmt.Errorf("can't validate reference %q: biller answered %d", reference, response.StatusCode)
+}
+
+func (h *HTTP) PostPayment(ctx context.Context, payment core.BillerPayment) (confirmation string, err error) {
+	body, err := json.Marshal(paymentRequest{Id: payment.Id, Reference: payment.Reference, Amount: payment.Amount})
+	if err != nil {
+		return "", fmt.Errorf("can't encode payment %s: %w", payment.Id, err)
+	}
+	response, err := h.do(ctx, http.MethodPost, "/payments", body)
+	if err != nil {
+		return "", err
+	}
+	defer closeBody(response)
+	if response.StatusCode >= 400 && response.StatusCode < 500 {
+		return "", fmt.Errorf("payment %s: biller answered %d: %w", payment.Id, response.StatusCode, core.ErrPaymentRejected)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("can't post payment %s: biller answered %d", payment.Id, response.StatusCode)
+	}
+	var answer paymentResponse
+	err = json.NewDecoder(response.Body).Decode(&answer)
+	if err != nil {
+		return "", fmt.Errorf("can't decode payment %s: %w", payment.Id, err)
+	}
+	return answer.Confirmation, nil
+}
+
+func (h *HTTP) PaymentStatus(ctx context.Context, paymentId string) (status, confirmation string, err error) {
+	response, err := h.do(ctx, http.MethodGet, "/payments/"+url.PathEscape(paymentId), nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer closeBody(response)
+	if response.StatusCode == http.StatusNotFound {
+		return core.PaymentRejected, "", nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("can't get payment %s: biller answered %d", paymentId, response.StatusCode)
+	}
+	var answer paymentResponse
+	err = json.NewDecoder(response.Body).Decode(&answer)
+	if err != nil {
+		return "", "", fmt.Errorf("can't decode payment %s: %w", paymentId, err)
+	}
+	return answer.Status, answer.Confirmation, nil
+}
+
+func (h *HTTP) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, h.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't create biller request: %w", err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	response, err := h.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("can't reach biller: %w", err)
+	}
+	return response, nil
+}
+
+// RegisterHTTP adds an HTTP biller to core.Billers for every name=url pair
+// of the comma-separated spec.
+func RegisterHTTP(spec string) error {
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("biller %q is not name=url", pair)
+		}
+		core.Billers[strings.TrimSpace(parts[0])] = NewHTTP(strings.TrimSpace(parts[1]))
+	}
+	return nil
+}
+
+func closeBody(response *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	_ = response.Body.Close()
+}
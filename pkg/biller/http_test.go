@@ -0,0 +1,111 @@
+package biller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+// provider answers the way TestHTTP expects: account 100200 exists,
+// payments of 13 are refused and payments of 500 break the provider.
+func provider(t *testing.T) *httptest.Server {
+	posted := map[string]bool{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/references/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/references/") != "100200" {
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("/payments", func(w http.ResponseWriter, r *http.Request) {
+		var payment paymentRequest
+		if err := json.NewDecoder(r.Body).Decode(&payment); err != nil {
+			t.Errorf("can't decode payment: %v", err)
+		}
+		switch payment.Amount {
+		case 13:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		case 500:
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		posted[payment.Id] = true
+		_ = json.NewEncoder(w).Encode(paymentResponse{Status: core.PaymentConfirmed, Confirmation: "c-" + payment.Id})
+	})
+	mux.HandleFunc("/payments/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/payments/")
+		if !posted[id] {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(paymentResponse{Status: core.PaymentConfirmed, Confirmation: "c-" + id})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestHTTP(t *testing.T) {
+	server := provider(t)
+	defer server.Close()
+	biller := NewHTTP(server.URL + "/")
+	ctx := context.Background()
+
+	if err := biller.ValidateReference(ctx, "100200"); err != nil {
+		t.Errorf("known account just be valid: %v", err)
+	}
+	if err := biller.ValidateReference(ctx, "999"); !errors.Is(err, core.ErrInvalidReference) {
+		t.Errorf("unknown account just be ErrInvalidReference: %v", err)
+	}
+	confirmation, err := biller.PostPayment(ctx, core.BillerPayment{Id: "payment-1", Reference: "100200", Amount: 100})
+	if err != nil || confirmation != "c-payment-1" {
+		t.Errorf("payment just be confirmed: %q %v", confirmation, err)
+	}
+	_, err = biller.PostPayment(ctx, core.BillerPayment{Id: "payment-2", Reference: "100200", Amount: 13})
+	if !errors.Is(err, core.ErrPaymentRejected) {
+		t.Errorf("4xx just be ErrPaymentRejected: %v", err)
+	}
+	_, err = biller.PostPayment(ctx, core.BillerPayment{Id: "payment-3", Reference: "100200", Amount: 500})
+	if err == nil || errors.Is(err, core.ErrPaymentRejected) {
+		t.Errorf("5xx just leave the payment unknown: %v", err)
+	}
+	status, confirmation, err := biller.PaymentStatus(ctx, "payment-1")
+	if err != nil || status != core.PaymentConfirmed || confirmation != "c-payment-1" {
+		t.Errorf("posted payment just be confirmed: %s %q %v", status, confirmation, err)
+	}
+	status, _, err = biller.PaymentStatus(ctx, "payment-3")
+	if err != nil || status != core.PaymentRejected {
+		t.Errorf("payment the provider never took just be rejected: %s %v", status, err)
+	}
+
+	server.Close()
+	_, err = biller.PostPayment(ctx, core.BillerPayment{Id: "payment-4", Reference: "100200", Amount: 100})
+	if err == nil || errors.Is(err, core.ErrPaymentRejected) {
+		t.Errorf("unreachable provider just leave the payment unknown: %v", err)
+	}
+}
+
+func TestRegisterHTTP(t *testing.T) {
+	defer func() {
+		delete(core.Billers, "water")
+		delete(core.Billers, "gas")
+	}()
+	err := RegisterHTTP("water=http://water.example/api, gas=http://gas.example")
+	if err != nil {
+		t.Fatalf("can't register billers: %v", err)
+	}
+	water, ok := core.Billers["water"].(*HTTP)
+	if !ok || water.BaseURL != "http://water.example/api" {
+		t.Errorf("water just be an HTTP biller: %#v", core.Billers["water"])
+	}
+	if _, ok := core.Billers["gas"]; !ok {
+		t.Errorf("gas just be registered")
+	}
+	if err := RegisterHTTP("water"); err == nil {
+		t.Errorf("name without url just be an error")
+	}
+}
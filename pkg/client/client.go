@@ -0,0 +1,77 @@
+//go:generate go run ../../cmd/client-gen -o client_gen.go -package client
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIError is any response outside 2xx, decoded from the Error schema.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("clients-core: %d: %s", e.StatusCode, e.Message)
+}
+
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var payload bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&payload).Encode(body)
+		if err != nil {
+			return fmt.Errorf("can't encode request: %w", err)
+		}
+	}
+	request, err := http.NewRequestWithContext(ctx, method, target, &payload)
+	if err != nil {
+		return fmt.Errorf("can't create request: %w", err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("can't call %s %s: %w", method, path, err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: response.StatusCode}
+		var errorBody Error
+		if json.NewDecoder(response.Body).Decode(&errorBody) == nil {
+			apiErr.Message = errorBody.Error
+		}
+		return apiErr
+	}
+	err = json.NewDecoder(response.Body).Decode(result)
+	if err != nil {
+		return fmt.Errorf("can't decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+// Code generated by client-gen from the openapi package. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const APIVersion = "1.8.2"
+
+type Error struct {
+	Error string `json:"error"`
+}
+
+type SignInRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type SignInResponse struct {
+	Token     string    `json:"token"`
+	ClientId  int       `json:"clientId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type Card struct {
+	Id         int    `json:"id"`
+	Pan        int64  `json:"pan"`
+	Balance    int    `json:"balance"`
+	HolderName string `json:"holderName"`
+	Validity   int    `json:"validity"`
+}
+
+type Balance struct {
+	Pan       int64 `json:"pan"`
+	Balance   int   `json:"balance"`
+	Available int   `json:"available"`
+}
+
+type TransferRequest struct {
+	FromPan int64 `json:"fromPan,omitempty"`
+	ToPan   int64 `json:"toPan"`
+	Amount  int   `json:"amount"`
+}
+
+type Receiver struct {
+	Card       string `json:"card"`
+	MaskedName string `json:"maskedName"`
+	Scheme     string `json:"scheme"`
+}
+
+type BeneficiaryRequest struct {
+	Nickname   string `json:"nickname"`
+	Pan        int64  `json:"pan"`
+	HolderName string `json:"holderName"`
+}
+
+type Beneficiary struct {
+	Id         int64     `json:"id"`
+	Nickname   string    `json:"nickname"`
+	Pan        int64     `json:"pan"`
+	HolderName string    `json:"holderName"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type BeneficiaryTransferRequest struct {
+	BeneficiaryId int64 `json:"beneficiaryId"`
+	FromPan       int64 `json:"fromPan,omitempty"`
+	Amount        int   `json:"amount"`
+}
+
+type PaymentRequest struct {
+	Service   string `json:"service"`
+	Reference string `json:"reference"`
+	FromPan   int64  `json:"fromPan,omitempty"`
+	Amount    int    `json:"amount"`
+}
+
+type Status struct {
+	Status string `json:"status"`
+}
+
+type Receipt struct {
+	Number        string    `json:"number"`
+	TransactionId int64     `json:"transactionId"`
+	Time          time.Time `json:"time"`
+	Card          string    `json:"card"`
+	Service       string    `json:"service"`
+	Reference     string    `json:"reference"`
+	Amount        int       `json:"amount"`
+	Fee           int       `json:"fee"`
+	Total         int       `json:"total"`
+}
+
+type ServicesStruct struct {
+	Id              int    `json:"id"`
+	Service         string `json:"service"`
+	Category        string `json:"category"`
+	ReferenceLabel  string `json:"referenceLabel"`
+	ReferenceFormat string `json:"referenceFormat"`
+	MinAmount       int    `json:"minAmount"`
+	MaxAmount       int    `json:"maxAmount"`
+	Fee             int    `json:"fee"`
+	Active          bool   `json:"active"`
+}
+
+type Atm struct {
+	Id           int64    `json:"id"`
+	City         string   `json:"city"`
+	District     string   `json:"district"`
+	Street       string   `json:"street"`
+	Latitude     float64  `json:"latitude,omitempty"`
+	Longitude    float64  `json:"longitude,omitempty"`
+	Status       string   `json:"status"`
+	Timezone     string   `json:"timezone"`
+	Hours        []Hours  `json:"hours,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Currencies   []string `json:"currencies,omitempty"`
+}
+
+type Hours struct {
+	Weekday int    `json:"weekday"`
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
+}
+
+type AtmPage struct {
+	Atms          []Atm  `json:"atms"`
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+type NearbyAtm struct {
+	Id           int64    `json:"id"`
+	City         string   `json:"city"`
+	District     string   `json:"district"`
+	Street       string   `json:"street"`
+	Latitude     float64  `json:"latitude"`
+	Longitude    float64  `json:"longitude"`
+	Status       string   `json:"status"`
+	Timezone     string   `json:"timezone"`
+	Hours        []Hours  `json:"hours,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Currencies   []string `json:"currencies,omitempty"`
+	Distance     float64  `json:"distance"`
+}
+
+// ListAtms calls GET /api/atms. ATM locations. openNow keeps online ATMs open at the moment; capability is cash-in, cash-out or contactless; currency is an ISO 4217 code.
+func (c *Client) ListAtms(ctx context.Context, openNow bool, capability string, currency string) ([]Atm, error) {
+	query := url.Values{}
+	query.Set("openNow", fmt.Sprint(openNow))
+	query.Set("capability", capability)
+	query.Set("currency", currency)
+	var result []Atm
+	err := c.do(ctx, http.MethodGet, "/api/atms", query, nil, &result)
+	return result, err
+}
+
+// ListNearestAtms calls GET /api/atms/nearest. ATMs nearest to a point, within radius meters. Zero limit and radius take 10 ATMs and 5000 meters.
+func (c *Client) ListNearestAtms(ctx context.Context, lat float64, lon float64, limit int, radius float64, openNow bool, capability string, currency string) ([]NearbyAtm, error) {
+	query := url.Values{}
+	query.Set("lat", fmt.Sprint(lat))
+	query.Set("lon", fmt.Sprint(lon))
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("radius", fmt.Sprint(radius))
+	query.Set("openNow", fmt.Sprint(openNow))
+	query.Set("capability", capability)
+	query.Set("currency", currency)
+	var result []NearbyAtm
+	err := c.do(ctx, http.MethodGet, "/api/atms/nearest", query, nil, &result)
+	return result, err
+}
+
+// SearchAtms calls GET /api/atms/search. One page of the ATMs in a city and district whose street contains the text, sorted by id, city, district or street. Empty filters match every ATM.
+func (c *Client) SearchAtms(ctx context.Context, city string, district string, street string, sort string, limit int, pageToken string, openNow bool, capability string, currency string) (*AtmPage, error) {
+	query := url.Values{}
+	query.Set("city", city)
+	query.Set("district", district)
+	query.Set("street", street)
+	query.Set("sort", sort)
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("pageToken", pageToken)
+	query.Set("openNow", fmt.Sprint(openNow))
+	query.Set("capability", capability)
+	query.Set("currency", currency)
+	result := &AtmPage{}
+	err := c.do(ctx, http.MethodGet, "/api/atms/search", query, nil, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetBalance calls GET /api/balance. Current balance of one of the client's cards.
+func (c *Client) GetBalance(ctx context.Context, pan int64) (*Balance, error) {
+	query := url.Values{}
+	query.Set("pan", strconv.FormatInt(pan, 10))
+	result := &Balance{}
+	err := c.do(ctx, http.MethodGet, "/api/balance", query, nil, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RemoveBeneficiary calls DELETE /api/beneficiaries. Forget a saved receiver.
+func (c *Client) RemoveBeneficiary(ctx context.Context, id int64) (*Status, error) {
+	query := url.Values{}
+	query.Set("id", strconv.FormatInt(id, 10))
+	result := &Status{}
+	err := c.do(ctx, http.MethodDelete, "/api/beneficiaries", query, nil, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListBeneficiaries calls GET /api/beneficiaries. The client's saved receivers by nickname.
+func (c *Client) ListBeneficiaries(ctx context.Context) ([]Beneficiary, error) {
+	var result []Beneficiary
+	err := c.do(ctx, http.MethodGet, "/api/beneficiaries", nil, nil, &result)
+	return result, err
+}
+
+// AddBeneficiary calls POST /api/beneficiaries. Save a receiver card under a nickname. holderName must match the name on the card; an unknown card fails the same way. Counts as a receiver lookup.
+func (c *Client) AddBeneficiary(ctx context.Context, body BeneficiaryRequest) (*Beneficiary, error) {
+	result := &Beneficiary{}
+	err := c.do(ctx, http.MethodPost, "/api/beneficiaries", nil, body, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// TransferToBeneficiary calls POST /api/beneficiaries/transfers. Transfer money to a saved receiver. fromPan is required when the client has several cards.
+func (c *Client) TransferToBeneficiary(ctx context.Context, body BeneficiaryTransferRequest) (*Status, error) {
+	result := &Status{}
+	err := c.do(ctx, http.MethodPost, "/api/beneficiaries/transfers", nil, body, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListCards calls GET /api/cards. List the cards of the signed in client.
+func (c *Client) ListCards(ctx context.Context) ([]Card, error) {
+	var result []Card
+	err := c.do(ctx, http.MethodGet, "/api/cards", nil, nil, &result)
+	return result, err
+}
+
+// PayService calls POST /api/payments. Pay a service from a card. fromPan is required when the client has several cards.
+func (c *Client) PayService(ctx context.Context, body PaymentRequest) (*Receipt, error) {
+	result := &Receipt{}
+	err := c.do(ctx, http.MethodPost, "/api/payments", nil, body, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetReceipt calls GET /api/receipts. A receipt of one of the client's service payments.
+func (c *Client) GetReceipt(ctx context.Context, number string) (*Receipt, error) {
+	query := url.Values{}
+	query.Set("number", number)
+	result := &Receipt{}
+	err := c.do(ctx, http.MethodGet, "/api/receipts", query, nil, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LookupReceiver calls GET /api/receivers. The masked holder name and scheme of a card, to confirm before a transfer. Each client may look up 10 different cards an hour; over the limit the answer is 429 with Retry-After.
+func (c *Client) LookupReceiver(ctx context.Context, pan int64) (*Receiver, error) {
+	query := url.Values{}
+	query.Set("pan", strconv.FormatInt(pan, 10))
+	result := &Receiver{}
+	err := c.do(ctx, http.MethodGet, "/api/receivers", query, nil, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListServices calls GET /api/services. Service catalog.
+func (c *Client) ListServices(ctx context.Context) ([]ServicesStruct, error) {
+	var result []ServicesStruct
+	err := c.do(ctx, http.MethodGet, "/api/services", nil, nil, &result)
+	return result, err
+}
+
+// SignIn calls POST /api/signin. Exchange login and password for a bearer token.
+func (c *Client) SignIn(ctx context.Context, body SignInRequest) (*SignInResponse, error) {
+	result := &SignInResponse{}
+	err := c.do(ctx, http.MethodPost, "/api/signin", nil, body, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CreateTransfer calls POST /api/transfers. Transfer money to another card. fromPan is required when the client has several cards.
+func (c *Client) CreateTransfer(ctx context.Context, body TransferRequest) (*Status, error) {
+	result := &Status{}
+	err := c.do(ctx, http.MethodPost, "/api/transfers", nil, body, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
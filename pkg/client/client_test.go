@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tohirov1994/clients-core/pkg/core"
+	"github.com/tohirov1994/clients-core/pkg/openapi"
+	"github.com/tohirov1994/clients-core/pkg/server"
+)
+
+func TestClient_GeneratedIsUpToDate(t *testing.T) {
+	document, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("can't load spec: %v", err)
+	}
+	want, err := openapi.GenerateClient(document, "client")
+	if err != nil {
+		t.Fatalf("can't generate client: %v", err)
+	}
+	got, err := ioutil.ReadFile("client_gen.go")
+	if err != nil {
+		t.Fatalf("can't read client_gen.go: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("client_gen.go is stale, run go generate ./pkg/client")
+	}
+}
+
+func TestClient_AgainstServer(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	db.SetMaxOpenConns(1)
+	err = core.Init(db)
+	if err != nil {
+		t.Fatalf("can't init db: %v", err)
+	}
+	httpServer := httptest.NewServer(server.NewServer(db, []byte("test-secret")))
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	c := NewClient(httpServer.URL)
+	_, err = c.ListCards(ctx)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("cards without token just be 401: %v", err)
+	}
+	signedIn, err := c.SignIn(ctx, SignInRequest{Login: "adminC", Password: "adminC"})
+	if err != nil {
+		t.Fatalf("can't sign in: %v", err)
+	}
+	c.Token = signedIn.Token
+	cards, err := c.ListCards(ctx)
+	if err != nil || len(cards) != 1 {
+		t.Fatalf("just be one card: %v %v", cards, err)
+	}
+	paid, err := c.PayService(ctx, PaymentRequest{Service: "internet", Reference: "100200", Amount: 100})
+	if err != nil {
+		t.Fatalf("can't pay service: %v", err)
+	}
+	receipt, err := c.GetReceipt(ctx, paid.Number)
+	if err != nil || receipt.Total != 100 || receipt.Reference != "100200" {
+		t.Errorf("receipt just be the payment: %v %v", receipt, err)
+	}
+	balance, err := c.GetBalance(ctx, cards[0].Pan)
+	if err != nil || balance.Balance != cards[0].Balance-100 {
+		t.Errorf("balance just be %d: %v %v", cards[0].Balance-100, balance, err)
+	}
+	atms, err := c.ListAtms(ctx, false, "", "")
+	if err != nil || len(atms) != 1 {
+		t.Errorf("just be one atm: %v %v", atms, err)
+	}
+}
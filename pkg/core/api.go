@@ -1,19 +1,26 @@
 package core
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	DSN "github.com/tohirov1994/database"
 )
 
-var ErrorPassword = errors.New("password is not valid")
-
 type Atm struct {
 	Id       int64
 	City     string
 	District string
 	Street   string
+	// Location is nil for ATMs nobody has placed on the map yet.
+	Location *Location
+	// Status is one of ATMOnline, ATMOffline and ATMOutOfService.
+	Status string
+	// Timezone is the IANA zone Hours are in.
+	Timezone     string
+	Hours        []Hours
+	Capabilities []string
+	Currencies   []string
 }
 
 type Card struct {
@@ -33,17 +40,44 @@ type ServicesStruct struct {
 
 func Init(db *sql.DB) (err error) {
 	initDDLsDMLs := []string{DSN.ManagersDDL, DSN.ClientsDDL, DSN.ClientsCardsDDL, DSN.AtmsDDL, DSN.ServicesDDL,
-		DSN.ManagersDML, DSN.ClientsDML, DSN.ClientsCardsDML, DSN.AtmsDML, DSN.ServicesDML}
+		blockedCardsDDL, transactionsDDL, reversalsDDL, schedulesDDL, transferOrdersDDL, providersDDL, receiptsDDL,
+		settlementsDDL, holdsDDL, billerPaymentsDDL, cassettesDDL, atmLocationsDDL, atmsSearchDDL, atmProfilesDDL,
+		deactivatedAtmsDDL, deactivatedServicesDDL, catalogChangesDDL, auditDDL, screeningsDDL, beneficiariesDDL, receiverLookupsDDL,
+		DSN.ManagersDML, DSN.ClientsDML, DSN.ClientsCardsDML, DSN.AtmsDML, DSN.ServicesDML, providersDML}
 	for _, init := range initDDLsDMLs {
 		_, err = db.Exec(init)
 		if err != nil {
-			return err
+			return fmt.Errorf("can't init db: %w", err)
 		}
 	}
 	return nil
 }
 
+// SignIn checks the password of the client and audits the attempt. A
+// sign-in changes nothing, so an attempt that can't be audited still goes
+// through, and the audit error goes to OnAuditError.
 func SignIn(loginUsr, passwordUsr string, db *sql.DB) (int, bool, error) {
+	record := auditRecord{actor: "login " + loginUsr, action: AuditSignIn,
+		params: map[string]string{"login": loginUsr}}
+	id, ok, signInErr := signIn(loginUsr, passwordUsr, db)
+	result := AuditOK
+	switch {
+	case signInErr != nil:
+		result = signInErr.Error()
+	case !ok:
+		result = "unknown login"
+	}
+	err := audit(db, record, result)
+	if err != nil {
+		OnAuditError(fmt.Errorf("sign-in of %s: %w", loginUsr, err))
+	}
+	if signInErr != nil {
+		return 0, false, signInErr
+	}
+	return id, ok, nil
+}
+
+func signIn(loginUsr, passwordUsr string, db *sql.DB) (int, bool, error) {
 	var dbLogin, dbPassword string
 	var ClientId int
 	err := db.QueryRow(DSN.GetLoginPassIdClient, loginUsr).Scan(&dbLogin, &dbPassword, &ClientId)
@@ -51,7 +85,7 @@ func SignIn(loginUsr, passwordUsr string, db *sql.DB) (int, bool, error) {
 		if err == sql.ErrNoRows {
 			return 0, false, nil
 		}
-		return 0, false, err
+		return 0, false, fmt.Errorf("can't sign in %s: %w", loginUsr, err)
 	}
 	if dbPassword != passwordUsr {
 		return 0, false, ErrorPassword
@@ -64,9 +98,9 @@ func GetCurrentBalanceClientId(clientCardId int, db *sql.DB) (balance int, err e
 	err = db.QueryRow(DSN.GetBalanceClientId, clientCardId).Scan(&idClient, &balance)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, err
+			return 0, fmt.Errorf("client %d: %w", clientCardId, ErrClientNotFound)
 		}
-		return 0, err
+		return 0, fmt.Errorf("can't get balance of client %d: %w", clientCardId, err)
 	}
 	return balance, nil
 }
@@ -76,29 +110,33 @@ func CheckPan(panClient int64, db *sql.DB) (result int64, err error) {
 	err = db.QueryRow(DSN.CheckPAN, panClient).Scan(&checker)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, err
+			return 0, fmt.Errorf("card %d: %w", panClient, ErrCardNotFound)
 		}
-		return 0, err
+		return 0, fmt.Errorf("can't check card %d: %w", panClient, err)
 	}
 	return checker, nil
 }
 
-func GetCurrentBalanceClientPAN(clientPAN int64, db *sql.DB) (balance int, err error) {
-	err = db.QueryRow(DSN.GetBalanceClientPAN, clientPAN).Scan(&balance)
+// GetCurrentBalanceClientPAN reports the ledger balance of the card and the
+// part of it that holds leave available.
+func GetCurrentBalanceClientPAN(clientPAN int64, db *sql.DB) (balance, available int, err error) {
+	err = db.QueryRow(getCardBalances,
+		sql.Named("now", now().Unix()),
+		sql.Named("pan", clientPAN),
+	).Scan(&balance, &available)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, err
+			return 0, 0, fmt.Errorf("card %d: %w", clientPAN, ErrCardNotFound)
 		}
-		return 0, err
+		return 0, 0, fmt.Errorf("can't get balance of card %d: %w", clientPAN, err)
 	}
-	return balance, nil
+	return balance, available, nil
 }
 
 func GetTransferCard(id int, db *sql.DB) (count int, err error) {
 	err = db.QueryRow(DSN.GetTransferCard, id).Scan(&count)
 	if err != nil {
-		err := fmt.Errorf("can't find last Account Number %e", err)
-		return 0, err
+		return 0, fmt.Errorf("can't count cards of client %d: %w", id, err)
 	}
 	return count, nil
 }
@@ -106,34 +144,25 @@ func GetTransferCard(id int, db *sql.DB) (count int, err error) {
 func SelectCards(id int, panCheck int64, db *sql.DB) (panAccept int64, err error) {
 	err = db.QueryRow(DSN.SelectCardWhoHaveManyCards, id, panCheck).Scan(&panAccept)
 	if err != nil {
-		err := fmt.Errorf("can't select your card %e", err)
-		return 0, err
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("card %d of client %d: %w", panCheck, id, ErrCardNotFound)
+		}
+		return 0, fmt.Errorf("can't select your card: %w", err)
 	}
 	return panAccept, nil
 }
 
 func OneCard(panReceiver int64, idSender, amount int, db *sql.DB) (status bool, err error) {
-	tx, err := db.Begin()
-	if err != nil {
-		return false, err
-	}
-	defer func() {
+	record := auditRecord{actor: fmt.Sprintf("client %d", idSender), action: AuditTransfer,
+		params: map[string]string{"to": MaskPAN(panReceiver), "amount": fmt.Sprint(amount)}}
+	err = transfer(db, record, amount, func(ctx context.Context, q queryer) (from, to int64, err error) {
+		from, err = cardIdByClient(ctx, q, idSender)
 		if err != nil {
-			_ = tx.Rollback()
-			return
+			return 0, 0, err
 		}
-		err = tx.Commit()
-	}()
-	_, err = tx.Exec(
-		DSN.OutOneAmmount,
-		sql.Named("amount", amount),
-		sql.Named("idClient", idSender),
-	)
-	_, err = tx.Exec(
-		DSN.InAmmount,
-		sql.Named("amount", amount),
-		sql.Named("PANInner", panReceiver),
-	)
+		to, err = cardIdByPAN(ctx, q, panReceiver)
+		return from, to, err
+	})
 	if err != nil {
 		return false, err
 	}
@@ -141,27 +170,16 @@ func OneCard(panReceiver int64, idSender, amount int, db *sql.DB) (status bool,
 }
 
 func MoreCard(panSender, panReceiver int64, amount int, db *sql.DB) (status bool, err error) {
-	tx, err := db.Begin()
-	if err != nil {
-		return false, err
-	}
-	defer func() {
+	record := auditRecord{actor: "card " + MaskPAN(panSender), action: AuditTransfer,
+		params: map[string]string{"to": MaskPAN(panReceiver), "amount": fmt.Sprint(amount)}}
+	err = transfer(db, record, amount, func(ctx context.Context, q queryer) (from, to int64, err error) {
+		from, err = cardIdByPAN(ctx, q, panSender)
 		if err != nil {
-			_ = tx.Rollback()
-			return
+			return 0, 0, err
 		}
-		err = tx.Commit()
-	}()
-	_, err = tx.Exec(
-		DSN.OutMoreOneAmmount,
-		sql.Named("amount", amount),
-		sql.Named("panClient", panSender),
-	)
-	_, err = tx.Exec(
-		DSN.InAmmount,
-		sql.Named("amount", amount),
-		sql.Named("PANInner", panReceiver),
-	)
+		to, err = cardIdByPAN(ctx, q, panReceiver)
+		return from, to, err
+	})
 	if err != nil {
 		return false, err
 	}
@@ -173,99 +191,88 @@ func CheckServiceName(Name string, db *sql.DB) (result string, err error) {
 	err = db.QueryRow(DSN.CheckServiceName, Name).Scan(&checker)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", err
+			return "", fmt.Errorf("service %s: %w", Name, ErrServiceNotFound)
 		}
-		return "", err
+		return "", fmt.Errorf("can't check service %s: %w", Name, err)
 	}
 	return checker, nil
 }
 
-func ServicesPayOneCard(nameService string, payerId, amount int, db *sql.DB) (result bool, err error) {
-	tx, err := db.Begin()
-	if err != nil {
-		return false, err
-	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-			return
-		}
-		err = tx.Commit()
-	}()
-	_, err = tx.Exec(
-		DSN.OutOneAmmount,
-		sql.Named("amount", amount),
-		sql.Named("idClient", payerId),
-	)
-	_, err = tx.Exec(
-		DSN.PayService,
-		sql.Named("amount", amount),
-		sql.Named("serviceName", nameService),
-	)
-	if err != nil {
-		return false, err
-	}
-	return true, nil
+func ServicesPayOneCard(nameService, reference string, payerId, amount int, db *sql.DB) (receipt Receipt, err error) {
+	actor := fmt.Sprintf("client %d", payerId)
+	return servicesPay(actor, nameService, reference, amount, db, func(ctx context.Context, q queryer) (int64, error) {
+		return cardIdByClient(ctx, q, payerId)
+	})
 }
 
-func ServicesPayMoreCard(nameService string, cardPAN int64, amount int, db *sql.DB) (result bool, err error) {
-	tx, err := db.Begin()
-	if err != nil {
-		return false, err
-	}
-	defer func() {
+func ServicesPayMoreCard(nameService, reference string, cardPAN int64, amount int, db *sql.DB) (receipt Receipt, err error) {
+	return servicesPay("card "+MaskPAN(cardPAN), nameService, reference, amount, db, func(ctx context.Context, q queryer) (int64, error) {
+		return cardIdByPAN(ctx, q, cardPAN)
+	})
+}
+
+// servicesPay pays in one transaction unless the service has a biller to
+// confirm the payment with. A payment through a biller is audited once it
+// is done; if that fails, the payment stands, the receipt comes back
+// without an error and the audit error goes to OnAuditError, as for a
+// sign-in.
+func servicesPay(actor, nameService, reference string, amount int, db *sql.DB,
+	cardOf func(ctx context.Context, q queryer) (int64, error)) (receipt Receipt, err error) {
+	record := auditRecord{actor: actor, action: AuditServicePayment,
+		params: map[string]string{"service": nameService, "reference": reference, "amount": fmt.Sprint(amount)}}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		cardId, err := cardOf(ctx, q)
+		if err != nil {
+			return err
+		}
+		receipt, err = payService(ctx, q, cardId, nameService, reference, amount)
 		if err != nil {
-			_ = tx.Rollback()
-			return
+			return err
 		}
-		err = tx.Commit()
-	}()
-	_, err = tx.Exec(
-		DSN.OutMoreOneAmmount,
-		sql.Named("amount", amount),
-		sql.Named("panClient", cardPAN),
-	)
-	_, err = tx.Exec(
-		DSN.PayService,
-		sql.Named("amount", amount),
-		sql.Named("serviceName", nameService),
-	)
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err == errNeedsBiller {
+		receipt, err = payThroughBiller(nameService, reference, amount, db, cardOf)
+		if err != nil {
+			return Receipt{}, auditFailure(db, record, err)
+		}
+		err = audit(db, record, AuditOK)
+		if err != nil {
+			OnAuditError(fmt.Errorf("payment to %s: %w", nameService, err))
+		}
+		return receipt, nil
+	}
 	if err != nil {
-		return false, err
+		return Receipt{}, auditFailure(db, record, err)
 	}
-	return true, nil
+	return receipt, nil
 }
 
+// ATMsGet returns every ATM, page by page of ATMsSearch.
 func ATMsGet(db *sql.DB) (atms []Atm, err error) {
-	rows, err := db.Query(DSN.GetATMData)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if innerErr := rows.Close(); innerErr != nil {
-			atms = nil
-		}
-	}()
+	return ATMsFiltered(ATMFilter{}, db)
+}
 
-	for rows.Next() {
-		atm := Atm{}
-		err = rows.Scan(&atm.Id, &atm.City, &atm.District, &atm.Street)
+// ATMsFiltered returns every ATM that matches the filter.
+func ATMsFiltered(filter ATMFilter, db *sql.DB) (atms []Atm, err error) {
+	query := ATMQuery{ATMFilter: filter, Limit: MaxATMsPage}
+	for {
+		page, err := ATMsSearch(query, db)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("can't get atms: %w", err)
 		}
-		atms = append(atms, atm)
-	}
-	if rows.Err() != nil {
-		return nil, rows.Err()
+		atms = append(atms, page.ATMs...)
+		if page.NextPageToken == "" {
+			return atms, nil
+		}
+		query.PageToken = page.NextPageToken
 	}
-
-	return atms, nil
 }
 
 func CardsGet(id int, db *sql.DB) (cards []Card, err error) {
 	rows, err := db.Query(DSN.GetCards, id)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("can't get cards of client %d: %w", id, err)
 	}
 	defer func() {
 		if innerErr := rows.Close(); innerErr != nil {
@@ -276,20 +283,21 @@ func CardsGet(id int, db *sql.DB) (cards []Card, err error) {
 		card := Card{}
 		err = rows.Scan(&card.Id, &card.PAN, &card.PIN, &card.Balance, &card.HolderName, &card.CVV, &card.Validity)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("can't scan card: %w", err)
 		}
 		cards = append(cards, card)
 	}
 	if rows.Err() != nil {
-		return nil, rows.Err()
+		return nil, fmt.Errorf("can't read cards: %w", rows.Err())
 	}
 	return cards, nil
 }
 
+// GetAllService returns the services that are not deactivated.
 func GetAllService(db *sql.DB) (services []ServicesStruct, err error) {
-	rows, err := db.Query(DSN.GetServices)
+	rows, err := db.Query(getActiveServices)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("can't get services: %w", err)
 	}
 	defer func() {
 		if innerErr := rows.Close(); innerErr != nil {
@@ -300,12 +308,12 @@ func GetAllService(db *sql.DB) (services []ServicesStruct, err error) {
 		service := ServicesStruct{}
 		err = rows.Scan(&service.Id, &service.Service)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("can't scan service: %w", err)
 		}
 		services = append(services, service)
 	}
 	if rows.Err() != nil {
-		return nil, rows.Err()
+		return nil, fmt.Errorf("can't read services: %w", rows.Err())
 	}
 
 	return services, nil
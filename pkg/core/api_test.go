@@ -86,7 +86,7 @@ func TestSignIn_NoValidData(t *testing.T) {
 	CREATE TABLE clients (
     Id INTEGER PRIMARY KEY AUTOINCREMENT,
 	login TEXT NOT NULL UNIQUE,
-	password TEXT NOT NULL)`)
+	password TEXT NOT NULL);` + auditDDL)
 	if err != nil {
 		t.Errorf("can't execute query to base: %v", err)
 	}
@@ -123,7 +123,7 @@ func TestSignIn_LoginNotOkForInvalidPassword(t *testing.T) {
 	CREATE TABLE clients (
     Id INTEGER PRIMARY KEY AUTOINCREMENT,
 	login TEXT NOT NULL UNIQUE,
-	password TEXT NOT NULL)`)
+	password TEXT NOT NULL);` + auditDDL)
 	if err != nil {
 		t.Errorf("can't execute query to base: %v", err)
 	}
@@ -153,7 +153,7 @@ func TestSignIn_OK(t *testing.T) {
 	CREATE TABLE clients (
     Id INTEGER PRIMARY KEY AUTOINCREMENT,
 	login TEXT NOT NULL UNIQUE,
-	password TEXT NOT NULL)`)
+	password TEXT NOT NULL);` + auditDDL)
 	if err != nil {
 		t.Errorf("can't execute query to base: %v", err)
 	}
@@ -343,7 +343,7 @@ func TestGetCurrentBalanceClientPAN_NoTable(t *testing.T)  {
 			t.Errorf("can't close db: %v", err)
 		}
 	}()
-	result, err := GetCurrentBalanceClientPAN(992, db)
+	result, _, err := GetCurrentBalanceClientPAN(992, db)
 	if err == nil {
 		t.Errorf("can't execute query from emty base: %v", err)
 	}
@@ -374,7 +374,7 @@ func TestGetCurrentBalanceClientPAN_ErrorRows(t *testing.T)  {
 	if err != nil {
 		t.Errorf("can't execute query to base: %v", err)
 	}
-	result, err := GetCurrentBalanceClientPAN(159, db)
+	result, _, err := GetCurrentBalanceClientPAN(159, db)
 	if err == nil {
 		t.Errorf("can't execute query from emty base: %v", err)
 	}
@@ -395,22 +395,23 @@ func TestGetCurrentBalanceClientPAN_OK(t *testing.T)  {
 	}()
 	_, err = db.Exec(`
 	CREATE TABLE clients_cards (
+	id INTEGER PRIMARY KEY,
 	pan integer NOT NULL,
-	balance INTEGER);`)
+	balance INTEGER);` + holdsDDL)
 	if err != nil {
 		t.Errorf("can't execute query to base: %v", err)
 	}
 	_, err = db.Exec(`
-	INSERT INTO clients_cards VALUES (333, 100000);`)
+	INSERT INTO clients_cards VALUES (1, 333, 100000);`)
 	if err != nil {
 		t.Errorf("can't execute query to base: %v", err)
 	}
-	balance, err := GetCurrentBalanceClientPAN(333, db)
+	balance, available, err := GetCurrentBalanceClientPAN(333, db)
 	if err != nil {
 		t.Errorf("can't query GetBalanceFromClientPAN: %v", err)
 	}
-	if balance != 100000 {
-		t.Errorf("balance just be 100000: %d", balance)
+	if balance != 100000 || available != 100000 {
+		t.Errorf("balance just be 100000: %d %d", balance, available)
 	}
 }
 
@@ -595,10 +596,15 @@ VALUES (1, 1111, 1000000, 1),
 (2, 2222, 2000000, 1),
 (3, 3333, 3000000, 3),
 (4, 4444, 352, 4),
-(5, 5555, 5000000, 5);`)
+(5, 5555, 5000000, 5),
+(6, 6666, 2000000, 2222);`)
 	if err != nil {
 		t.Errorf("can't execute insert card to DB: %v", err)
 	}
+	_, err = db.Exec(ledgerTables)
+	if err != nil {
+		t.Errorf("can't execute query to base: %v", err)
+	}
 	result, err := OneCard(4444, 2222, 2000000, db)
 	if err != nil {
 		t.Errorf("can't execute transefer money: %v", err)
@@ -677,6 +683,10 @@ VALUES (1, 1111, 1000000, 1),
 	if err != nil {
 		t.Errorf("can't execute insert card to DB: %v", err)
 	}
+	_, err = db.Exec(ledgerTables)
+	if err != nil {
+		t.Errorf("can't execute query to base: %v", err)
+	}
 	result, err := MoreCard(5555, 4444, 200000, db)
 	if err != nil {
 		t.Errorf("can't execute transefer money: %v", err)
@@ -770,12 +780,12 @@ func TestServicesPayOneCard_DbClose(t *testing.T) {
 		}
 	}()
 	_ = db.Close()
-	result, err := ServicesPayOneCard("phone", 2222, 2000000, db)
+	result, err := ServicesPayOneCard("phone", "992900000000", 2222, 2000000, db)
 	if err == nil {
 		t.Errorf("pay of service just have error: %v", err)
 	}
-	if result == true {
-		t.Errorf("pay just be false: %v", result)
+	if result.Number != "" {
+		t.Errorf("pay just have no receipt: %v", result)
 	}
 }
 
@@ -789,12 +799,12 @@ func TestServicesPayOneCard_CanNotBegin(t *testing.T) {
 			t.Errorf("can't close db: %v", err)
 		}
 	}()
-	result, err := ServicesPayOneCard("internet", 2222, 2000000, db)
+	result, err := ServicesPayOneCard("internet", "992900000000", 2222, 2000000, db)
 	if err == nil {
 		t.Errorf("pay of service just have error: %v", err)
 	}
-	if result == true {
-		t.Errorf("pay just be false: %v", result)
+	if result.Number != "" {
+		t.Errorf("pay just have no receipt: %v", result)
 	}
 }
 
@@ -810,23 +820,32 @@ func TestServicesPayOneCard_OK(t *testing.T) {
 	}()
 	_, err = db.Exec(`
 CREATE TABLE IF NOT EXISTS services
-(service TEXT    NOT NULL,
+(id INTEGER PRIMARY KEY AUTOINCREMENT,
+service TEXT    NOT NULL,
 balance integer    NOT NULL);`)
 	if err != nil {
 		t.Errorf("can't execute query to base: %v", err)
 	}
 	_, err = db.Exec(`
-INSERT INTO services
+INSERT INTO services(service, balance)
 VALUES ('phone', 5000);`)
 	if err != nil {
 		t.Errorf("can't execute insert card to DB: %v", err)
 	}
-	result, err := ServicesPayOneCard("water", 4444, 200000, db)
+	_, err = db.Exec(cardsTable + ledgerTables)
+	if err != nil {
+		t.Errorf("can't execute query to base: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients_cards(Id, pan, balance, client_id) VALUES (4, 4444, 352, 4);`)
+	if err != nil {
+		t.Errorf("can't execute insert card to DB: %v", err)
+	}
+	result, err := ServicesPayOneCard("phone", "992900000000", 4, 200, db)
 	if err != nil {
 		t.Errorf("can't execute pay service: %v", err)
 	}
-	if result != true {
-		t.Errorf("trasfer just be true: %v", result)
+	if result.Number == "" || result.Amount != 200 {
+		t.Errorf("pay just have a receipt for 200: %v", result)
 	}
 }
 
@@ -841,12 +860,12 @@ func TestServicesPayMoreCard_DbClose(t *testing.T) {
 		}
 	}()
 	_ = db.Close()
-	result, err := ServicesPayMoreCard("phone", 2222, 2000000, db)
+	result, err := ServicesPayMoreCard("phone", "992900000000", 2222, 2000000, db)
 	if err == nil {
 		t.Errorf("pay of service just have error: %v", err)
 	}
-	if result == true {
-		t.Errorf("pay just be false: %v", result)
+	if result.Number != "" {
+		t.Errorf("pay just have no receipt: %v", result)
 	}
 }
 
@@ -860,12 +879,12 @@ func TestServicesPayMoreCard_CanNotBegin(t *testing.T) {
 			t.Errorf("can't close db: %v", err)
 		}
 	}()
-	result, err := ServicesPayMoreCard("internet", 2222, 2000000, db)
+	result, err := ServicesPayMoreCard("internet", "992900000000", 2222, 2000000, db)
 	if err == nil {
 		t.Errorf("pay of service just have error: %v", err)
 	}
-	if result == true {
-		t.Errorf("pay just be false: %v", result)
+	if result.Number != "" {
+		t.Errorf("pay just have no receipt: %v", result)
 	}
 }
 
@@ -881,23 +900,32 @@ func TestServicesPayMoreCard_OK(t *testing.T) {
 	}()
 	_, err = db.Exec(`
 CREATE TABLE IF NOT EXISTS services
-(service TEXT    NOT NULL,
+(id INTEGER PRIMARY KEY AUTOINCREMENT,
+service TEXT    NOT NULL,
 balance integer    NOT NULL);`)
 	if err != nil {
 		t.Errorf("can't execute query to base: %v", err)
 	}
 	_, err = db.Exec(`
-INSERT INTO services
+INSERT INTO services(service, balance)
 VALUES ('phone', 5000);`)
 	if err != nil {
 		t.Errorf("can't execute insert card to DB: %v", err)
 	}
-	result, err := ServicesPayMoreCard("water", 4444, 200000, db)
+	_, err = db.Exec(cardsTable + ledgerTables)
+	if err != nil {
+		t.Errorf("can't execute query to base: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients_cards(Id, pan, balance, client_id) VALUES (4, 4444, 352, 4);`)
+	if err != nil {
+		t.Errorf("can't execute insert card to DB: %v", err)
+	}
+	result, err := ServicesPayMoreCard("phone", "992900000000", 4444, 200, db)
 	if err != nil {
 		t.Errorf("can't execute pay service: %v", err)
 	}
-	if result != true {
-		t.Errorf("trasfer just be true: %v", result)
+	if result.Number == "" || result.Amount != 200 {
+		t.Errorf("pay just have a receipt for 200: %v", result)
 	}
 }
 
@@ -953,14 +981,14 @@ CREATE TABLE IF NOT EXISTS atms
     city     TEXT NOT NULL,
     district TEXT NOT NULL,
     street   TEXT NOT NULL
-);`)
+);` + atmLocationsDDL + atmProfilesDDL + deactivatedAtmsDDL)
 	_, _ = db.Exec(`
 INSERT INTO atms
 VALUES (1, 'Dushanbe', 'Somoni', 'Foteh51')
 ON CONFLICT DO NOTHING;`)
 	result, _ := ATMsGet(db)
 	fmt.Println(result)
-	//Output: [{1 Dushanbe Somoni Foteh51}]
+	//Output: [{1 Dushanbe Somoni Foteh51 <nil> online UTC [] [] []}]
 }
 
 func ExampleCardsGet_WithoutData() {
@@ -1017,7 +1045,7 @@ CREATE TABLE services
 (
     id      INTEGER PRIMARY KEY AUTOINCREMENT,
     service TEXT    NOT NULL
-);`)
+);` + deactivatedServicesDDL)
 	_, _ = db.Exec(`
 INSERT INTO services
 VALUES (1, 'internet');`)
@@ -0,0 +1,398 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const KindWithdrawal = "withdrawal"
+const KindDeposit = "deposit"
+
+var ErrATMNotFound = errors.New("atm not found")
+var ErrWrongPIN = errors.New("pin is not valid")
+var ErrATMLimit = errors.New("atm limit is exceeded")
+var ErrCannotDispense = errors.New("atm can't dispense the amount")
+var ErrATMUnavailable = errors.New("atm is not available")
+
+// ATM limits. A withdrawal is bounded by ATMWithdrawalLimit and, together
+// with the card's other withdrawals since midnight, by ATMDailyLimit.
+var ATMWithdrawalLimit = 200000
+var ATMDailyLimit = 500000
+var ATMDepositLimit = 1000000
+
+// ATMLowNotes is the note count under which a cassette needs cash.
+var ATMLowNotes = 100
+
+// Cassette holds notes of one denomination in an ATM.
+type Cassette struct {
+	Denomination int
+	Notes        int
+}
+
+// Withdrawal is a withdrawal as the ATM pays it out: Notes are the notes
+// to dispense, largest first.
+type Withdrawal struct {
+	TransactionId int64
+	Notes         []Cassette
+}
+
+// CashAlert reports a cassette with fewer than ATMLowNotes notes.
+type CashAlert struct {
+	AtmId        int64
+	Denomination int
+	Notes        int
+}
+
+// Withdraw gives out amount of cash at the ATM and takes it from the card.
+// The ATM has to be online and capable of cash-out.
+// A withdrawal the cassettes can't make up is refused with
+// ErrCannotDispense.
+func Withdraw(atmId int64, pan int64, pin int, amount int, db *sql.DB) (withdrawal Withdrawal, err error) {
+	if amount <= 0 {
+		return Withdrawal{}, ErrInvalidAmount
+	}
+	if amount > ATMWithdrawalLimit {
+		return Withdrawal{}, fmt.Errorf("withdrawal of %d over %d: %w", amount, ATMWithdrawalLimit, ErrATMLimit)
+	}
+	record := auditRecord{actor: "card " + MaskPAN(pan), action: AuditWithdrawal,
+		params: map[string]string{"atm": fmt.Sprint(atmId), "amount": fmt.Sprint(amount)}}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkATMServes(ctx, q, atmId, CapabilityCashOut)
+		if err != nil {
+			return err
+		}
+		cardId, err := cardIdByPIN(ctx, q, pan, pin)
+		if err != nil {
+			return err
+		}
+		err = lockActiveCards(ctx, q, cardId)
+		if err != nil {
+			return err
+		}
+		err = checkDailyLimit(ctx, q, cardId, amount)
+		if err != nil {
+			return err
+		}
+		err = debitCard(ctx, q, cardId, amount)
+		if err != nil {
+			return err
+		}
+		withdrawal.Notes, err = takeCash(ctx, q, atmId, amount)
+		if err != nil {
+			return err
+		}
+		withdrawal.TransactionId, err = recordTransaction(ctx, q, ledgerEntry{kind: KindWithdrawal, fromCardId: cardId, amount: amount, atmId: atmId})
+		if err != nil {
+			return err
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return Withdrawal{}, auditFailure(db, record, err)
+	}
+	return withdrawal, nil
+}
+
+// Deposit takes amount of cash in at the ATM and adds it to the card. The
+// ATM has to be online and capable of cash-in.
+func Deposit(atmId int64, pan int64, amount int, db *sql.DB) (transactionId int64, err error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if amount > ATMDepositLimit {
+		return 0, fmt.Errorf("deposit of %d over %d: %w", amount, ATMDepositLimit, ErrATMLimit)
+	}
+	record := auditRecord{actor: "card " + MaskPAN(pan), action: AuditDeposit,
+		params: map[string]string{"atm": fmt.Sprint(atmId), "amount": fmt.Sprint(amount)}}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkATMServes(ctx, q, atmId, CapabilityCashIn)
+		if err != nil {
+			return err
+		}
+		cardId, err := cardIdByPAN(ctx, q, pan)
+		if err != nil {
+			return err
+		}
+		err = lockActiveCards(ctx, q, cardId)
+		if err != nil {
+			return err
+		}
+		err = addToCard(ctx, q, cardId, amount)
+		if err != nil {
+			return err
+		}
+		transactionId, err = recordTransaction(ctx, q, ledgerEntry{kind: KindDeposit, toCardId: cardId, amount: amount, atmId: atmId})
+		if err != nil {
+			return err
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return 0, auditFailure(db, record, err)
+	}
+	return transactionId, nil
+}
+
+func checkATM(ctx context.Context, q queryer, atmId int64) error {
+	var id int64
+	err := q.QueryRowContext(ctx, checkAtm, atmId).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("atm %d: %w", atmId, ErrATMNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("can't find atm %d: %w", atmId, err)
+	}
+	return nil
+}
+
+// checkATMServes refuses ATMs that aren't online or lack the capability
+// the operation needs. Like an ATM without a status, one without any
+// capabilities set is taken to do everything.
+func checkATMServes(ctx context.Context, q queryer, atmId int64, capability string) error {
+	var status string
+	var capable bool
+	err := q.QueryRowContext(ctx, getAtmService, sql.Named("atmId", atmId), sql.Named("capability", capability)).Scan(&status, &capable)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("atm %d: %w", atmId, ErrATMNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("can't find atm %d: %w", atmId, err)
+	}
+	if status != ATMOnline {
+		return fmt.Errorf("atm %d is %s: %w", atmId, status, ErrATMUnavailable)
+	}
+	if !capable {
+		return fmt.Errorf("atm %d has no %s: %w", atmId, capability, ErrATMUnavailable)
+	}
+	return nil
+}
+
+func cardIdByPIN(ctx context.Context, q queryer, pan int64, pin int) (id int64, err error) {
+	var cardPIN int
+	err = q.QueryRowContext(ctx, getCardPIN, pan).Scan(&id, &cardPIN)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("card %d: %w", pan, ErrCardNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("can't find card %d: %w", pan, err)
+	}
+	if cardPIN != pin {
+		return 0, fmt.Errorf("card %d: %w", pan, ErrWrongPIN)
+	}
+	return id, nil
+}
+
+func checkDailyLimit(ctx context.Context, q queryer, cardId int64, amount int) error {
+	t := now()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	var withdrawn int
+	err := q.QueryRowContext(ctx, sumWithdrawalsSince,
+		sql.Named("idCard", cardId),
+		sql.Named("since", midnight.Unix()),
+	).Scan(&withdrawn)
+	if err != nil {
+		return fmt.Errorf("can't get withdrawals of card %d: %w", cardId, err)
+	}
+	if withdrawn+amount > ATMDailyLimit {
+		return fmt.Errorf("card %d has withdrawn %d today, limit %d: %w", cardId, withdrawn, ATMDailyLimit, ErrATMLimit)
+	}
+	return nil
+}
+
+// Replenish loads the cassettes of the ATM on behalf of the manager. Each
+// cassette given replaces the one of its denomination; what was left in it
+// is logged as removed.
+func Replenish(atmId int64, cassettes []Cassette, managerId int, db *sql.DB) error {
+	for _, cassette := range cassettes {
+		if cassette.Denomination <= 0 || cassette.Notes < 0 {
+			return fmt.Errorf("cassette of %d notes of %d: %w", cassette.Notes, cassette.Denomination, ErrInvalidAmount)
+		}
+	}
+	loading := make([]string, len(cassettes))
+	for i, cassette := range cassettes {
+		loading[i] = fmt.Sprintf("%dx%d", cassette.Notes, cassette.Denomination)
+	}
+	record := auditRecord{actor: fmt.Sprintf("manager %d", managerId), action: AuditReplenishment,
+		params: map[string]string{"atm": fmt.Sprint(atmId), "cassettes": strings.Join(loading, " ")}}
+	err := runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManagedATM(ctx, q, atmId, managerId)
+		if err != nil {
+			return err
+		}
+		loaded, err := cassettesOf(ctx, q, atmId)
+		if err != nil {
+			return err
+		}
+		left := map[int]int{}
+		for _, cassette := range loaded {
+			left[cassette.Denomination] = cassette.Notes
+		}
+		at := now().Unix()
+		for _, cassette := range cassettes {
+			_, err = q.ExecContext(ctx, loadCassette,
+				sql.Named("atmId", atmId),
+				sql.Named("denomination", cassette.Denomination),
+				sql.Named("notes", cassette.Notes),
+			)
+			if err != nil {
+				return fmt.Errorf("can't load cassette of %d in atm %d: %w", cassette.Denomination, atmId, err)
+			}
+			_, err = q.ExecContext(ctx, insertReplenishment,
+				sql.Named("atmId", atmId),
+				sql.Named("denomination", cassette.Denomination),
+				sql.Named("removed", left[cassette.Denomination]),
+				sql.Named("loaded", cassette.Notes),
+				sql.Named("createdAt", at),
+			)
+			if err != nil {
+				return fmt.Errorf("can't log replenishment of atm %d: %w", atmId, err)
+			}
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return auditFailure(db, record, err)
+	}
+	return nil
+}
+
+// CassettesGet returns the cassettes of the ATM, largest denomination first.
+func CassettesGet(atmId int64, db *sql.DB) (cassettes []Cassette, err error) {
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkATM(ctx, q, atmId)
+		if err != nil {
+			return err
+		}
+		cassettes, err = cassettesOf(ctx, q, atmId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cassettes, nil
+}
+
+// LowCash returns the cassettes of every ATM that are running out of notes.
+func LowCash(db *sql.DB) (alerts []CashAlert, err error) {
+	rows, err := db.Query(getLowCassettes, sql.Named("lowNotes", ATMLowNotes))
+	if err != nil {
+		return nil, fmt.Errorf("can't get low cassettes: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close low cassettes: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var alert CashAlert
+		err = rows.Scan(&alert.AtmId, &alert.Denomination, &alert.Notes)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan low cassette: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get low cassettes: %w", err)
+	}
+	return alerts, nil
+}
+
+func cassettesOf(ctx context.Context, q queryer, atmId int64) (cassettes []Cassette, err error) {
+	rows, err := q.QueryContext(ctx, getCassettes, atmId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get cassettes of atm %d: %w", atmId, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close cassettes of atm %d: %w", atmId, cerr)
+		}
+	}()
+	for rows.Next() {
+		var cassette Cassette
+		err = rows.Scan(&cassette.Denomination, &cassette.Notes)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan cassette of atm %d: %w", atmId, err)
+		}
+		cassettes = append(cassettes, cassette)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get cassettes of atm %d: %w", atmId, err)
+	}
+	return cassettes, nil
+}
+
+// takeCash takes the notes for amount out of the ATM's cassettes.
+func takeCash(ctx context.Context, q queryer, atmId int64, amount int) (notes []Cassette, err error) {
+	cassettes, err := cassettesOf(ctx, q, atmId)
+	if err != nil {
+		return nil, err
+	}
+	notes, ok := dispense(cassettes, amount)
+	if !ok {
+		return nil, fmt.Errorf("atm %d can't make up %d: %w", atmId, amount, ErrCannotDispense)
+	}
+	for _, note := range notes {
+		result, err := q.ExecContext(ctx, takeNotes,
+			sql.Named("atmId", atmId),
+			sql.Named("denomination", note.Denomination),
+			sql.Named("notes", note.Notes),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("can't take notes of %d from atm %d: %w", note.Denomination, atmId, err)
+		}
+		taken, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("can't take notes of %d from atm %d: %w", note.Denomination, atmId, err)
+		}
+		if taken == 0 {
+			return nil, fmt.Errorf("atm %d ran out of notes of %d: %w", atmId, note.Denomination, ErrCannotDispense)
+		}
+	}
+	return notes, nil
+}
+
+// dispense makes up amount from the cassettes. It is greedy, taking as many
+// of the largest notes as fit, and backs off to fewer of them when the
+// smaller notes can't make up the rest: with 50s and 20s, 60 is three 20s.
+func dispense(cassettes []Cassette, amount int) (notes []Cassette, ok bool) {
+	ordered := append([]Cassette(nil), cassettes...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Denomination > ordered[j].Denomination })
+	counts := make([]int, len(ordered))
+	failed := map[[2]int]bool{}
+	var fill func(i, rest int) bool
+	fill = func(i, rest int) bool {
+		if rest == 0 {
+			return true
+		}
+		if i == len(ordered) || failed[[2]int{i, rest}] {
+			return false
+		}
+		most := rest / ordered[i].Denomination
+		if most > ordered[i].Notes {
+			most = ordered[i].Notes
+		}
+		for n := most; n >= 0; n-- {
+			counts[i] = n
+			if fill(i+1, rest-n*ordered[i].Denomination) {
+				return true
+			}
+		}
+		counts[i] = 0
+		failed[[2]int{i, rest}] = true
+		return false
+	}
+	if !fill(0, amount) {
+		return nil, false
+	}
+	for i, n := range counts {
+		if n > 0 {
+			notes = append(notes, Cassette{Denomination: ordered[i].Denomination, Notes: n})
+		}
+	}
+	return notes, true
+}
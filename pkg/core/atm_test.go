@@ -0,0 +1,261 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// loadAtm fills ATM 1 with enough notes for every test withdrawal.
+func loadAtm(t *testing.T, db *sql.DB) {
+	err := Replenish(1, []Cassette{{Denomination: 5000, Notes: 1000}, {Denomination: 1000, Notes: 1000}}, 1, db)
+	if err != nil {
+		t.Fatalf("can't replenish atm: %v", err)
+	}
+}
+
+func TestWithdraw(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(2, 10))()
+	loadAtm(t, db)
+	_, err := Withdraw(1, 2021600000000000, 1111, 1000, db)
+	if !errors.Is(err, ErrWrongPIN) {
+		t.Errorf("wrong pin just be ErrWrongPIN: %v", err)
+	}
+	_, err = Withdraw(404, 2021600000000000, 1994, 1000, db)
+	if !errors.Is(err, ErrATMNotFound) {
+		t.Errorf("unknown atm just be ErrATMNotFound: %v", err)
+	}
+	_, err = Withdraw(1, 2021600000000000, 1994, ATMWithdrawalLimit+1, db)
+	if !errors.Is(err, ErrATMLimit) {
+		t.Errorf("withdrawal over the limit just be ErrATMLimit: %v", err)
+	}
+	withdrawal, err := Withdraw(1, 2021600000000000, 1994, 150000, db)
+	if err != nil {
+		t.Fatalf("can't withdraw: %v", err)
+	}
+	if !reflect.DeepEqual(withdrawal.Notes, []Cassette{{Denomination: 5000, Notes: 30}}) {
+		t.Errorf("withdrawal just be 30 notes of 5000: %+v", withdrawal.Notes)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000000-150000 {
+		t.Errorf("balance just be %d: %d", 1000000-150000, balance)
+	}
+	var kind string
+	var atmId int64
+	err = db.QueryRow(`SELECT kind, atm_id FROM transactions WHERE id = ?`, withdrawal.TransactionId).Scan(&kind, &atmId)
+	if err != nil || kind != KindWithdrawal || atmId != 1 {
+		t.Errorf("withdrawal just be logged with its atm: %s %d %v", kind, atmId, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = Withdraw(1, 2021600000000000, 1994, 150000, db)
+		if err != nil {
+			t.Fatalf("can't withdraw: %v", err)
+		}
+	}
+	_, err = Withdraw(1, 2021600000000000, 1994, 100000, db)
+	if !errors.Is(err, ErrATMLimit) {
+		t.Errorf("withdrawal over the daily limit just be ErrATMLimit: %v", err)
+	}
+	setNow(at(3, 10))
+	_, err = Withdraw(1, 2021600000000000, 1994, 100000, db)
+	if err != nil {
+		t.Errorf("next day just allow withdrawals again: %v", err)
+	}
+}
+
+func TestWithdraw_InsufficientFunds(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	loadAtm(t, db)
+	_, err := Authorize(2021600000000000, 900000, db)
+	if err != nil {
+		t.Fatalf("can't authorize: %v", err)
+	}
+	_, err = Withdraw(1, 2021600000000000, 1994, 150000, db)
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("held money just not be withdrawn: %v", err)
+	}
+	err = BlockCard(2021600000000000, "stolen", db)
+	if err != nil {
+		t.Fatalf("can't block card: %v", err)
+	}
+	_, err = Withdraw(1, 2021600000000000, 1994, 1000, db)
+	if !errors.Is(err, ErrCardBlocked) {
+		t.Errorf("blocked card just be ErrCardBlocked: %v", err)
+	}
+}
+
+func TestDeposit(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(2, 10))()
+	_, err := Deposit(1, 2021600000000001, 1000, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("unknown card just be ErrCardNotFound: %v", err)
+	}
+	_, err = Deposit(1, 2021600000000000, ATMDepositLimit+1, db)
+	if !errors.Is(err, ErrATMLimit) {
+		t.Errorf("deposit over the limit just be ErrATMLimit: %v", err)
+	}
+	_, err = Deposit(1, 2021600000000000, 50000, db)
+	if err != nil {
+		t.Fatalf("can't deposit: %v", err)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000000+50000 {
+		t.Errorf("balance just be %d: %d", 1000000+50000, balance)
+	}
+	statement, err := GetStatement(2021600000000000, at(2, 0), at(3, 0), db)
+	if err != nil {
+		t.Fatalf("can't get statement: %v", err)
+	}
+	if len(statement.Entries) != 1 || statement.Entries[0].Kind != KindDeposit || statement.Entries[0].Amount != 50000 {
+		t.Errorf("statement just show the deposit: %+v", statement.Entries)
+	}
+}
+
+func TestWithdraw_ATMWithoutProfile(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(2, 10))()
+	err := Replenish(1, []Cassette{{Denomination: 1000, Notes: 10}}, 1, db)
+	if err != nil {
+		t.Fatalf("can't replenish atm: %v", err)
+	}
+	_, err = Withdraw(1, 2021600000000000, 1994, 1000, db)
+	if err != nil {
+		t.Errorf("seeded atm without profile just give out cash: %v", err)
+	}
+	_, err = Deposit(1, 2021600000000000, 1000, db)
+	if err != nil {
+		t.Errorf("seeded atm without profile just take cash in: %v", err)
+	}
+}
+
+func TestWithdraw_UnavailableATM(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(2, 10))()
+	loadAtm(t, db)
+	err := SetATMCapabilities(1, []string{CapabilityCashIn}, nil, 1, db)
+	if err != nil {
+		t.Fatalf("can't set capabilities: %v", err)
+	}
+	_, err = Withdraw(1, 2021600000000000, 1994, 1000, db)
+	if !errors.Is(err, ErrATMUnavailable) {
+		t.Errorf("withdrawal without cash-out just be ErrATMUnavailable: %v", err)
+	}
+	_, err = Deposit(1, 2021600000000000, 1000, db)
+	if err != nil {
+		t.Errorf("can't deposit at cash-in atm: %v", err)
+	}
+	err = SetATMCapabilities(1, []string{CapabilityCashOut}, nil, 1, db)
+	if err != nil {
+		t.Fatalf("can't set capabilities: %v", err)
+	}
+	_, err = Deposit(1, 2021600000000000, 1000, db)
+	if !errors.Is(err, ErrATMUnavailable) {
+		t.Errorf("deposit without cash-in just be ErrATMUnavailable: %v", err)
+	}
+	for _, status := range []string{ATMOffline, ATMOutOfService} {
+		err = SetATMStatus(1, status, 1, db)
+		if err != nil {
+			t.Fatalf("can't set status: %v", err)
+		}
+		_, err = Withdraw(1, 2021600000000000, 1994, 1000, db)
+		if !errors.Is(err, ErrATMUnavailable) {
+			t.Errorf("withdrawal at %s atm just be ErrATMUnavailable: %v", status, err)
+		}
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000000+1000 {
+		t.Errorf("only the deposit just move money: %d", balance)
+	}
+}
+
+func TestWithdraw_Cassettes(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := Withdraw(1, 2021600000000000, 1994, 1000, db)
+	if !errors.Is(err, ErrCannotDispense) {
+		t.Errorf("empty atm just be ErrCannotDispense: %v", err)
+	}
+	err = Replenish(1, []Cassette{{Denomination: 5000, Notes: 2}, {Denomination: 2000, Notes: 150}}, 1, db)
+	if err != nil {
+		t.Fatalf("can't replenish atm: %v", err)
+	}
+	withdrawal, err := Withdraw(1, 2021600000000000, 1994, 6000, db)
+	if err != nil {
+		t.Fatalf("can't withdraw: %v", err)
+	}
+	if !reflect.DeepEqual(withdrawal.Notes, []Cassette{{Denomination: 2000, Notes: 3}}) {
+		t.Errorf("6000 just be three notes of 2000: %+v", withdrawal.Notes)
+	}
+	_, err = Withdraw(1, 2021600000000000, 1994, 3000, db)
+	if !errors.Is(err, ErrCannotDispense) {
+		t.Errorf("amount the notes can't make up just be ErrCannotDispense: %v", err)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000000-6000 {
+		t.Errorf("refused withdrawal just not touch the card: %d", balance)
+	}
+	withdrawal, err = Withdraw(1, 2021600000000000, 1994, 14000, db)
+	if err != nil {
+		t.Fatalf("can't withdraw: %v", err)
+	}
+	if !reflect.DeepEqual(withdrawal.Notes, []Cassette{{Denomination: 5000, Notes: 2}, {Denomination: 2000, Notes: 2}}) {
+		t.Errorf("14000 just be two notes of 5000 and two of 2000: %+v", withdrawal.Notes)
+	}
+	cassettes, err := CassettesGet(1, db)
+	if err != nil {
+		t.Fatalf("can't get cassettes: %v", err)
+	}
+	if !reflect.DeepEqual(cassettes, []Cassette{{Denomination: 5000, Notes: 0}, {Denomination: 2000, Notes: 145}}) {
+		t.Errorf("cassettes just be empty of 5000 and have 145 of 2000: %+v", cassettes)
+	}
+	alerts, err := LowCash(db)
+	if err != nil {
+		t.Fatalf("can't get low cash: %v", err)
+	}
+	if !reflect.DeepEqual(alerts, []CashAlert{{AtmId: 1, Denomination: 5000, Notes: 0}}) {
+		t.Errorf("empty cassette just be reported: %+v", alerts)
+	}
+	err = Replenish(1, []Cassette{{Denomination: 5000, Notes: 500}}, 1, db)
+	if err != nil {
+		t.Fatalf("can't replenish atm: %v", err)
+	}
+	alerts, err = LowCash(db)
+	if err != nil || len(alerts) != 0 {
+		t.Errorf("replenished atm just not be reported: %+v %v", alerts, err)
+	}
+	if err = Replenish(404, nil, 1, db); !errors.Is(err, ErrATMNotFound) {
+		t.Errorf("unknown atm just be ErrATMNotFound: %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var ErrAuditTampered = errors.New("audit log is tampered with")
+
+// Audited actions.
+const AuditSignIn = "sign-in"
+const AuditTransfer = "transfer"
+const AuditServicePayment = "service-payment"
+const AuditTransferReview = "transfer-review"
+const AuditWithdrawal = "withdrawal"
+const AuditDeposit = "deposit"
+const AuditReplenishment = "replenishment"
+const AuditReversal = "reversal"
+const AuditHoldAuthorize = "hold-authorize"
+const AuditHoldCapture = "hold-capture"
+const AuditHoldVoid = "hold-void"
+const AuditSettlementVoid = "settlement-void"
+const AuditBeneficiaryAdd = "beneficiary-add"
+
+// AuditOK is the result of an action that succeeded; failed actions keep
+// their error.
+const AuditOK = "ok"
+
+// DefaultAuditPage is the number of entries AuditLog returns when the
+// filter sets no limit.
+const DefaultAuditPage = 100
+
+// auditRedacted replaces the values of sensitiveParams. Card numbers are
+// masked by the callers before they get here.
+const auditRedacted = "[redacted]"
+
+var sensitiveParams = map[string]bool{"password": true, "pin": true, "cvv": true}
+
+// OnAuditError receives the audit errors of actions that go through
+// without their audit entry. It logs them unless replaced.
+var OnAuditError = func(err error) {
+	log.Printf("can't audit: %v", err)
+}
+
+// AuditEntry is one action in the audit log. Hash covers every other field
+// and PrevHash, the Hash of the entry before it, so changing, dropping or
+// reordering an entry breaks the chain from there on.
+type AuditEntry struct {
+	Id       int64
+	Actor    string
+	Action   string
+	Params   map[string]string
+	Result   string
+	At       time.Time
+	PrevHash string
+	Hash     string
+}
+
+// AuditFilter narrows AuditLog. Zero fields match every entry.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Since  time.Time
+	Until  time.Time
+	// AfterId skips the entries up to it, to read the log page by page.
+	AfterId int64
+	Limit   int
+}
+
+// AuditChainError names the first entry whose link is broken.
+type AuditChainError struct {
+	EntryId int64
+	Reason  string
+}
+
+func (e *AuditChainError) Error() string {
+	return fmt.Sprintf("audit entry %d: %v: %s", e.EntryId, ErrAuditTampered, e.Reason)
+}
+
+func (e *AuditChainError) Is(target error) bool {
+	return target == ErrAuditTampered
+}
+
+// auditRecord is what the caller of an action knows before it runs.
+type auditRecord struct {
+	actor  string
+	action string
+	params map[string]string
+}
+
+// auditFailure audits the action that failed and returns its error.
+func auditFailure(db *sql.DB, record auditRecord, failure error) error {
+	err := audit(db, record, failure.Error())
+	if err != nil {
+		return fmt.Errorf("%w (can't audit: %v)", failure, err)
+	}
+	return failure
+}
+
+// audit appends the record in a transaction of its own. Actions that
+// change anything append theirs in their own transaction instead, so
+// nothing changes without being audited.
+func audit(db *sql.DB, record auditRecord, result string) error {
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		return appendAudit(ctx, q, record, result)
+	})
+}
+
+func appendAudit(ctx context.Context, q queryer, record auditRecord, result string) error {
+	params := map[string]string{}
+	for name, value := range record.params {
+		if sensitiveParams[name] {
+			value = auditRedacted
+		}
+		params[name] = value
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("can't encode audit params: %w", err)
+	}
+	entry := AuditEntry{Actor: record.actor, Action: record.action, Params: params, Result: result, At: now()}
+	err = q.QueryRowContext(ctx, getLastAudit).Scan(&entry.Id, &entry.PrevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("can't get last audit entry: %w", err)
+	}
+	entry.Id++
+	entry.Hash = auditHash(entry, string(encoded))
+	_, err = q.ExecContext(ctx, insertAudit,
+		sql.Named("id", entry.Id),
+		sql.Named("actor", entry.Actor),
+		sql.Named("action", entry.Action),
+		sql.Named("params", string(encoded)),
+		sql.Named("result", entry.Result),
+		sql.Named("createdAt", entry.At.Unix()),
+		sql.Named("prevHash", entry.PrevHash),
+		sql.Named("hash", entry.Hash),
+	)
+	if err != nil {
+		return fmt.Errorf("can't audit %s of %s: %w", entry.Action, entry.Actor, err)
+	}
+	_, err = q.ExecContext(ctx, moveAuditHead, sql.Named("lastId", entry.Id), sql.Named("hash", entry.Hash))
+	if err != nil {
+		return fmt.Errorf("can't move audit head to %d: %w", entry.Id, err)
+	}
+	return nil
+}
+
+// auditHash hashes the entry as stored, params being their JSON.
+func auditHash(entry AuditEntry, params string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%d\n%q\n%q\n%s\n%q\n%d",
+		entry.PrevHash, entry.Id, entry.Actor, entry.Action, params, entry.Result, entry.At.Unix())))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditLog returns the entries that match the filter, oldest first.
+func AuditLog(filter AuditFilter, db *sql.DB) (entries []AuditEntry, err error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditPage
+	}
+	var since, until int64
+	if !filter.Since.IsZero() {
+		since = filter.Since.Unix()
+	}
+	if !filter.Until.IsZero() {
+		until = filter.Until.Unix()
+	}
+	rows, err := db.Query(getAuditLog,
+		sql.Named("actor", filter.Actor),
+		sql.Named("action", filter.Action),
+		sql.Named("since", since),
+		sql.Named("until", until),
+		sql.Named("afterId", filter.AfterId),
+		sql.Named("limit", filter.Limit),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't get audit log: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close audit log: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		entry, _, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// VerifyAudit walks the chain from the first entry and returns an
+// *AuditChainError for the first one that doesn't hold. The chain has to
+// end at the head appendAudit keeps, so entries dropped from the end of
+// the log are caught as well.
+func VerifyAudit(db *sql.DB) (err error) {
+	var head AuditEntry
+	err = db.QueryRow(getAuditHead).Scan(&head.Id, &head.Hash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("can't get audit head: %w", err)
+	}
+	rows, err := db.Query(getAuditChain)
+	if err != nil {
+		return fmt.Errorf("can't get audit log: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close audit log: %w", cerr)
+		}
+	}()
+	var prev AuditEntry
+	for rows.Next() {
+		entry, params, err := scanAudit(rows)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.Id != prev.Id+1:
+			return &AuditChainError{EntryId: entry.Id, Reason: fmt.Sprintf("follows entry %d", prev.Id)}
+		case entry.PrevHash != prev.Hash:
+			return &AuditChainError{EntryId: entry.Id, Reason: "doesn't link to the entry before"}
+		case entry.Hash != auditHash(entry, params):
+			return &AuditChainError{EntryId: entry.Id, Reason: "doesn't match its hash"}
+		}
+		prev = entry
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("can't get audit log: %w", err)
+	}
+	switch {
+	case prev.Id < head.Id:
+		return &AuditChainError{EntryId: head.Id, Reason: fmt.Sprintf("is missing after entry %d", prev.Id)}
+	case prev.Id > head.Id || prev.Hash != head.Hash:
+		return &AuditChainError{EntryId: prev.Id, Reason: "doesn't match the head"}
+	}
+	return nil
+}
+
+// scanAudit reads an entry and its params as stored.
+func scanAudit(rows *sql.Rows) (entry AuditEntry, params string, err error) {
+	var createdAt int64
+	err = rows.Scan(&entry.Id, &entry.Actor, &entry.Action, &params, &entry.Result, &createdAt, &entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return AuditEntry{}, "", fmt.Errorf("can't scan audit entry: %w", err)
+	}
+	entry.At = time.Unix(createdAt, 0).UTC()
+	// params that aren't JSON any more are left for the hash to catch
+	_ = json.Unmarshal([]byte(params), &entry.Params)
+	return entry, params, nil
+}
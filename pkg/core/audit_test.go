@@ -0,0 +1,230 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// auditSome signs in twice on the 2nd, makes a payment and fails a
+// transfer on the 3rd.
+func auditSome(t *testing.T, db *sql.DB) {
+	restore := setNow(at(2, 10))
+	defer restore()
+	_, _, err := SignIn("adminC", "adminC", db)
+	if err != nil {
+		t.Fatalf("can't sign in: %v", err)
+	}
+	_, _, err = SignIn("adminC", "wrong", db)
+	if !errors.Is(err, ErrorPassword) {
+		t.Fatalf("wrong password just be ErrorPassword: %v", err)
+	}
+	setNow(at(3, 10))
+	_, err = ServicesPayMoreCard("internet", "100200", 2021600000000000, 1000, db)
+	if err != nil {
+		t.Fatalf("can't pay: %v", err)
+	}
+	_, err = MoreCard(2021600000000000, 404, 10, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Fatalf("transfer to unknown card just be ErrCardNotFound: %v", err)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	auditSome(t, db)
+	entries, err := AuditLog(AuditFilter{}, db)
+	if err != nil || len(entries) != 4 {
+		t.Fatalf("log just have 4 entries: %+v %v", entries, err)
+	}
+	signIn := entries[0]
+	if signIn.Actor != "login adminC" || signIn.Action != AuditSignIn || signIn.Result != AuditOK || !signIn.At.Equal(at(2, 10)) {
+		t.Errorf("first entry just be the sign in: %+v", signIn)
+	}
+	if !reflect.DeepEqual(signIn.Params, map[string]string{"login": "adminC"}) {
+		t.Errorf("password just be left out: %v", signIn.Params)
+	}
+	if entries[1].Result != ErrorPassword.Error() {
+		t.Errorf("wrong password just be the result: %+v", entries[1])
+	}
+	payment := entries[2]
+	if payment.Actor != "card 202160******0000" || payment.Action != AuditServicePayment || payment.Result != AuditOK ||
+		payment.Params["service"] != "internet" || payment.Params["amount"] != "1000" {
+		t.Errorf("third entry just be the payment: %+v", payment)
+	}
+	transfer := entries[3]
+	if transfer.Action != AuditTransfer || transfer.Params["to"] != "404" || transfer.Result == AuditOK {
+		t.Errorf("last entry just be the failed transfer: %+v", transfer)
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].PrevHash != entries[i-1].Hash {
+			t.Errorf("entry %d just link to the one before", entries[i].Id)
+		}
+	}
+
+	filtered, err := AuditLog(AuditFilter{Actor: "login adminC"}, db)
+	if err != nil || len(filtered) != 2 {
+		t.Errorf("adminC just have 2 entries: %+v %v", filtered, err)
+	}
+	filtered, err = AuditLog(AuditFilter{Action: AuditTransfer}, db)
+	if err != nil || len(filtered) != 1 || filtered[0].Id != transfer.Id {
+		t.Errorf("transfers just be the last entry: %+v %v", filtered, err)
+	}
+	filtered, err = AuditLog(AuditFilter{Since: at(3, 0), Until: at(4, 0)}, db)
+	if err != nil || len(filtered) != 2 || filtered[0].Id != payment.Id {
+		t.Errorf("the 3rd just have the payment and the transfer: %+v %v", filtered, err)
+	}
+	filtered, err = AuditLog(AuditFilter{AfterId: signIn.Id, Limit: 1}, db)
+	if err != nil || len(filtered) != 1 || filtered[0].Id != entries[1].Id {
+		t.Errorf("page after the first entry just be the second: %+v %v", filtered, err)
+	}
+}
+
+func TestSignIn_AuditFails(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer func(onError func(error)) { OnAuditError = onError }(OnAuditError)
+	var auditErr error
+	OnAuditError = func(err error) { auditErr = err }
+	_, err := db.Exec(`ALTER TABLE audit_log RENAME TO audit_log_away;`)
+	if err != nil {
+		t.Fatalf("can't break the audit log: %v", err)
+	}
+	id, ok, err := SignIn("adminC", "adminC", db)
+	if err != nil || !ok || id != 1 {
+		t.Errorf("sign-in just go through without the audit log: %d %v %v", id, ok, err)
+	}
+	if auditErr == nil {
+		t.Errorf("audit error just be reported")
+	}
+	_, _, err = SignIn("adminC", "wrong", db)
+	if !errors.Is(err, ErrorPassword) || strings.Contains(err.Error(), "audit") {
+		t.Errorf("wrong password just be ErrorPassword alone: %v", err)
+	}
+}
+
+func TestAuditLog_MoneyAndManagerActions(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(2, 10))()
+	if err := Replenish(1, []Cassette{{Denomination: 1000, Notes: 10}}, 1, db); err != nil {
+		t.Fatalf("can't replenish atm: %v", err)
+	}
+	if _, err := Deposit(1, 2021600000000000, 5000, db); err != nil {
+		t.Fatalf("can't deposit: %v", err)
+	}
+	withdrawal, err := Withdraw(1, 2021600000000000, 1994, 2000, db)
+	if err != nil {
+		t.Fatalf("can't withdraw: %v", err)
+	}
+	if _, err = Withdraw(1, 2021600000000000, 1111, 2000, db); !errors.Is(err, ErrWrongPIN) {
+		t.Fatalf("wrong pin just be ErrWrongPIN: %v", err)
+	}
+	holdId, err := Authorize(2021600000000000, 3000, db)
+	if err != nil {
+		t.Fatalf("can't authorize: %v", err)
+	}
+	if _, err = Capture(holdId, 1000, db); err != nil {
+		t.Fatalf("can't capture: %v", err)
+	}
+	if _, err = Reverse(withdrawal.TransactionId, 0, "atm jam", 404, db); !errors.Is(err, ErrManagerNotFound) {
+		t.Fatalf("reversal by unknown manager just be ErrManagerNotFound: %v", err)
+	}
+
+	entries, err := AuditLog(AuditFilter{}, db)
+	if err != nil {
+		t.Fatalf("can't get audit log: %v", err)
+	}
+	want := []struct{ actor, action string }{
+		{"manager 1", AuditReplenishment},
+		{"card 202160******0000", AuditDeposit},
+		{"card 202160******0000", AuditWithdrawal},
+		{"card 202160******0000", AuditWithdrawal},
+		{"card 202160******0000", AuditHoldAuthorize},
+		{fmt.Sprintf("hold %d", holdId), AuditHoldCapture},
+		{"manager 404", AuditReversal},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("log just have %d entries: %+v", len(want), entries)
+	}
+	for i, entry := range entries {
+		if entry.Actor != want[i].actor || entry.Action != want[i].action {
+			t.Errorf("entry %d just be %s by %s: %+v", i, want[i].action, want[i].actor, entry)
+		}
+	}
+	if entries[3].Result == AuditOK || entries[6].Result == AuditOK {
+		t.Errorf("failed actions just be audited with their errors: %+v %+v", entries[3], entries[6])
+	}
+	if _, ok := entries[2].Params["pin"]; ok {
+		t.Errorf("pin just be left out: %v", entries[2].Params)
+	}
+}
+
+func TestAuditLog_AppendOnly(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	auditSome(t, db)
+	_, err := db.Exec(`UPDATE audit_log SET result = 'ok' WHERE id = 2;`)
+	if err == nil {
+		t.Errorf("audit entry just not be updated")
+	}
+	_, err = db.Exec(`DELETE FROM audit_log WHERE id = 2;`)
+	if err == nil {
+		t.Errorf("audit entry just not be deleted")
+	}
+	if err = VerifyAudit(db); err != nil {
+		t.Errorf("untouched log just verify: %v", err)
+	}
+}
+
+func TestVerifyAudit(t *testing.T) {
+	cases := []struct {
+		name   string
+		tamper string
+		broken int64
+	}{
+		{"changed result", `UPDATE audit_log SET result = 'ok' WHERE id = 2;`, 2},
+		{"changed params", `UPDATE audit_log SET params = '{"amount":"1"}' WHERE id = 3;`, 3},
+		{"rehashed entry", `UPDATE audit_log SET result = 'ok', hash = 'x' WHERE id = 2;`, 2},
+		{"dropped entry", `DELETE FROM audit_log WHERE id = 2;`, 3},
+		{"dropped last entry", `DELETE FROM audit_log WHERE id = 4;`, 4},
+		{"relinked entry", `DELETE FROM audit_log WHERE id = 2; UPDATE audit_log SET id = 2 WHERE id = 3;`, 2},
+	}
+	for _, c := range cases {
+		db := openBankDb(t)
+		auditSome(t, db)
+		// whoever tampers with the log gets around the triggers first
+		_, err := db.Exec(`DROP TRIGGER audit_log_no_update; DROP TRIGGER audit_log_no_delete;` + c.tamper)
+		if err != nil {
+			t.Fatalf("can't tamper with the log: %v", err)
+		}
+		err = VerifyAudit(db)
+		var chainErr *AuditChainError
+		if !errors.Is(err, ErrAuditTampered) || !errors.As(err, &chainErr) || chainErr.EntryId != c.broken {
+			t.Errorf("%s just break the chain at %d: %v", c.name, c.broken, err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxNicknameLength bounds beneficiary nicknames, in characters.
+const MaxNicknameLength = 64
+
+var ErrBeneficiaryNotFound = errors.New("beneficiary not found")
+var ErrBeneficiaryExists = errors.New("beneficiary already exists")
+var ErrInvalidBeneficiary = errors.New("beneficiary is not valid")
+var ErrHolderMismatch = errors.New("no card with the holder name")
+
+// Beneficiary is a receiver the client has saved. HolderName is the name
+// on the card, which the client confirmed when saving it, masked as
+// MaskName does; the full name is only kept in the database.
+type Beneficiary struct {
+	Id         int64
+	Nickname   string
+	PAN        int64
+	HolderName string
+	CreatedAt  time.Time
+}
+
+// AddBeneficiary saves the card under the nickname once the client has
+// typed the name on it. Names match ignoring case and extra spaces.
+// Nicknames and cards are unique per client. The card counts as a
+// receiver lookup, and an unknown card fails as a wrong name does, so
+// neither tells whether the card exists.
+func AddBeneficiary(clientId int, nickname string, pan int64, holderName string, db *sql.DB) (beneficiary Beneficiary, err error) {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" || utf8.RuneCountInString(nickname) > MaxNicknameLength {
+		return Beneficiary{}, fmt.Errorf("nickname of 1-%d characters is required: %w", MaxNicknameLength, ErrInvalidBeneficiary)
+	}
+	record := auditRecord{actor: fmt.Sprintf("client %d", clientId), action: AuditBeneficiaryAdd,
+		params: map[string]string{"nickname": nickname, "pan": MaskPAN(pan)}}
+	beneficiary = Beneficiary{Nickname: nickname, PAN: pan, CreatedAt: now()}
+	matched := true
+	var holder string
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		err := countLookup(ctx, q, clientId, pan)
+		if err != nil {
+			return err
+		}
+		err = q.QueryRowContext(ctx, getCardHolder, pan).Scan(&holder)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("can't get holder of card %d: %w", pan, err)
+		}
+		if err == sql.ErrNoRows || !sameHolder(holder, holderName) {
+			// the lookup still counts, so the transaction has to commit
+			matched = false
+			return nil
+		}
+		var taken int
+		err = q.QueryRowContext(ctx, countBeneficiaries,
+			sql.Named("clientId", clientId),
+			sql.Named("nickname", nickname),
+			sql.Named("pan", pan),
+		).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("can't check beneficiaries of client %d: %w", clientId, err)
+		}
+		if taken > 0 {
+			return fmt.Errorf("%s or card %s: %w", nickname, MaskPAN(pan), ErrBeneficiaryExists)
+		}
+		result, err := q.ExecContext(ctx, insertBeneficiary,
+			sql.Named("clientId", clientId),
+			sql.Named("nickname", nickname),
+			sql.Named("pan", pan),
+			sql.Named("holderName", holder),
+			sql.Named("createdAt", beneficiary.CreatedAt.Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("can't add beneficiary %s: %w", nickname, err)
+		}
+		beneficiary.Id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("can't get id of beneficiary %s: %w", nickname, err)
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err == nil && !matched {
+		err = fmt.Errorf("card %s: %w", MaskPAN(pan), ErrHolderMismatch)
+	}
+	if err != nil {
+		return Beneficiary{}, auditFailure(db, record, err)
+	}
+	beneficiary.HolderName = MaskName(holder)
+	return beneficiary, nil
+}
+
+func sameHolder(onCard, typed string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(onCard), " "), strings.Join(strings.Fields(typed), " "))
+}
+
+// BeneficiariesGet returns the beneficiaries of the client by nickname.
+func BeneficiariesGet(clientId int, db *sql.DB) (beneficiaries []Beneficiary, err error) {
+	rows, err := db.Query(getBeneficiaries, clientId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get beneficiaries of client %d: %w", clientId, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close beneficiaries: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var beneficiary Beneficiary
+		var createdAt int64
+		err = rows.Scan(&beneficiary.Id, &beneficiary.Nickname, &beneficiary.PAN, &beneficiary.HolderName, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan beneficiary: %w", err)
+		}
+		beneficiary.HolderName = MaskName(beneficiary.HolderName)
+		beneficiary.CreatedAt = time.Unix(createdAt, 0)
+		beneficiaries = append(beneficiaries, beneficiary)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get beneficiaries of client %d: %w", clientId, err)
+	}
+	return beneficiaries, nil
+}
+
+// RemoveBeneficiary forgets a beneficiary of the client.
+func RemoveBeneficiary(clientId int, beneficiaryId int64, db *sql.DB) error {
+	result, err := db.Exec(deleteBeneficiary, sql.Named("id", beneficiaryId), sql.Named("clientId", clientId))
+	if err != nil {
+		return fmt.Errorf("can't remove beneficiary %d: %w", beneficiaryId, err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("can't remove beneficiary %d: %w", beneficiaryId, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("beneficiary %d of client %d: %w", beneficiaryId, clientId, ErrBeneficiaryNotFound)
+	}
+	return nil
+}
+
+// IsBeneficiary tells whether the client has saved the card, so transfers
+// to cards the client hasn't can be confirmed once more.
+func IsBeneficiary(clientId int, pan int64, db *sql.DB) (bool, error) {
+	var count int
+	err := db.QueryRow(countBeneficiaries,
+		sql.Named("clientId", clientId),
+		sql.Named("nickname", ""),
+		sql.Named("pan", pan),
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("can't check beneficiaries of client %d: %w", clientId, err)
+	}
+	return count > 0, nil
+}
+
+// TransferToBeneficiary transfers from the client's card fromPAN, or from
+// their first card when it is zero, to the beneficiary.
+func TransferToBeneficiary(clientId int, beneficiaryId int64, fromPAN int64, amount int, db *sql.DB) error {
+	record := auditRecord{actor: fmt.Sprintf("client %d", clientId), action: AuditTransfer,
+		params: map[string]string{"beneficiary": fmt.Sprint(beneficiaryId), "amount": fmt.Sprint(amount)}}
+	return transfer(db, record, amount, func(ctx context.Context, q queryer) (from, to int64, err error) {
+		var pan int64
+		err = q.QueryRowContext(ctx, getBeneficiaryPAN, sql.Named("id", beneficiaryId), sql.Named("clientId", clientId)).Scan(&pan)
+		if err == sql.ErrNoRows {
+			return 0, 0, fmt.Errorf("beneficiary %d of client %d: %w", beneficiaryId, clientId, ErrBeneficiaryNotFound)
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("can't get beneficiary %d: %w", beneficiaryId, err)
+		}
+		if fromPAN == 0 {
+			from, err = cardIdByClient(ctx, q, clientId)
+		} else {
+			from, err = clientCardId(ctx, q, clientId, fromPAN)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		to, err = cardIdByPAN(ctx, q, pan)
+		return from, to, err
+	})
+}
+
+// clientCardId finds the card of the client, as SelectCards does.
+func clientCardId(ctx context.Context, q queryer, clientId int, pan int64) (id int64, err error) {
+	err = q.QueryRowContext(ctx, getClientCardId, sql.Named("clientId", clientId), sql.Named("pan", pan)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("card %d of client %d: %w", pan, clientId, ErrCardNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("can't find card %d of client %d: %w", pan, clientId, err)
+	}
+	return id, nil
+}
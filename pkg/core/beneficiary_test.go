@@ -0,0 +1,154 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAddBeneficiary(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	restore := setNow(at(2, 12))
+	defer restore()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 0, 'JACK  JACKSON', 111, 0525, 1);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	cases := []struct {
+		name                 string
+		nickname, holderName string
+		pan                  int64
+		err                  error
+	}{
+		{"empty nickname", " ", "JACK JACKSON", 2021600000000001, ErrInvalidBeneficiary},
+		{"unknown card", "jack", "JACK JACKSON", 404, ErrHolderMismatch},
+		{"wrong holder", "jack", "JACK JONES", 2021600000000001, ErrHolderMismatch},
+		{"holder ignoring case and spaces", " jack ", " jack jackson", 2021600000000001, nil},
+		{"same nickname", "JACK", "ADMIN CLIENT", 2021600000000000, ErrBeneficiaryExists},
+		{"same card", "jj", "JACK JACKSON", 2021600000000001, ErrBeneficiaryExists},
+		{"another card", "me", "ADMIN CLIENT", 2021600000000000, nil},
+	}
+	for _, c := range cases {
+		beneficiary, err := AddBeneficiary(1, c.nickname, c.pan, c.holderName, db)
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s just be %v: %v", c.name, c.err, err)
+		}
+		if err == nil && !strings.EqualFold(beneficiary.HolderName, MaskName(c.holderName)) {
+			t.Errorf("%s just return the masked holder: %+v", c.name, beneficiary)
+		}
+	}
+	beneficiaries, err := BeneficiariesGet(1, db)
+	if err != nil || len(beneficiaries) != 2 {
+		t.Fatalf("client just have 2 beneficiaries: %+v %v", beneficiaries, err)
+	}
+	jack := beneficiaries[0]
+	if jack.Nickname != "jack" || jack.PAN != 2021600000000001 || jack.HolderName != "J*** J***" || !jack.CreatedAt.Equal(at(2, 12)) {
+		t.Errorf("first beneficiary just be jack as on the card: %+v", jack)
+	}
+	var stored string
+	err = db.QueryRow(`SELECT holder_name FROM beneficiaries WHERE id = ?`, jack.Id).Scan(&stored)
+	if err != nil || stored != "JACK  JACKSON" {
+		t.Errorf("full holder name just be stored: %q %v", stored, err)
+	}
+	others, err := BeneficiariesGet(2, db)
+	if err != nil || len(others) != 0 {
+		t.Errorf("another client just have no beneficiaries: %+v %v", others, err)
+	}
+	known, err := IsBeneficiary(1, 2021600000000001, db)
+	if err != nil || !known {
+		t.Errorf("saved card just be a beneficiary: %v", err)
+	}
+	known, err = IsBeneficiary(2, 2021600000000001, db)
+	if err != nil || known {
+		t.Errorf("card saved by another client just not be a beneficiary: %v", err)
+	}
+
+	err = RemoveBeneficiary(2, jack.Id, db)
+	if !errors.Is(err, ErrBeneficiaryNotFound) {
+		t.Errorf("another client just not remove the beneficiary: %v", err)
+	}
+	err = RemoveBeneficiary(1, jack.Id, db)
+	if err != nil {
+		t.Fatalf("can't remove beneficiary: %v", err)
+	}
+	known, err = IsBeneficiary(1, 2021600000000001, db)
+	if err != nil || known {
+		t.Errorf("removed card just not be a beneficiary: %v", err)
+	}
+	_, err = AddBeneficiary(1, "jack", 2021600000000001, "JACK JACKSON", db)
+	if err != nil {
+		t.Errorf("removed beneficiary just be added again: %v", err)
+	}
+}
+
+func TestAddBeneficiary_CountsLookups(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	restore := setNow(at(2, 12))
+	defer restore()
+	for pan := int64(404); pan < 404+ReceiverLookupLimit; pan++ {
+		_, err := AddBeneficiary(1, "guess", pan, "ADMIN CLIENT", db)
+		if !errors.Is(err, ErrHolderMismatch) {
+			t.Fatalf("guess %d just be ErrHolderMismatch: %v", pan, err)
+		}
+	}
+	_, err := AddBeneficiary(1, "me", 2021600000000000, "ADMIN CLIENT", db)
+	if !errors.Is(err, ErrTooManyLookups) {
+		t.Errorf("guesses just use up the lookups: %v", err)
+	}
+	_, err = LookupReceiver(1, 2021600000000000, db)
+	if !errors.Is(err, ErrTooManyLookups) {
+		t.Errorf("lookups after guesses just be ErrTooManyLookups: %v", err)
+	}
+}
+
+func TestTransferToBeneficiary(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := db.Exec(`INSERT INTO clients VALUES (2, 'Jack', 'Jackson', 'jack', 'secret');
+INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 0, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	jack, err := AddBeneficiary(1, "jack", 2021600000000001, "JACK JACKSON", db)
+	if err != nil {
+		t.Fatalf("can't add beneficiary: %v", err)
+	}
+	err = TransferToBeneficiary(1, jack.Id, 0, 300, db)
+	if err != nil {
+		t.Fatalf("can't transfer to beneficiary: %v", err)
+	}
+	err = TransferToBeneficiary(1, jack.Id, 2021600000000000, 200, db)
+	if err != nil {
+		t.Fatalf("can't transfer from the chosen card: %v", err)
+	}
+	err = TransferToBeneficiary(1, jack.Id, 2021600000000001, 100, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("card of another client just be ErrCardNotFound: %v", err)
+	}
+	err = TransferToBeneficiary(2, jack.Id, 0, 100, db)
+	if !errors.Is(err, ErrBeneficiaryNotFound) {
+		t.Errorf("beneficiary of another client just be ErrBeneficiaryNotFound: %v", err)
+	}
+	balance, _, err := GetCurrentBalanceClientPAN(2021600000000001, db)
+	if err != nil || balance != 500 {
+		t.Errorf("beneficiary just get 500: %d %v", balance, err)
+	}
+	entries, err := AuditLog(AuditFilter{Actor: "client 1", Action: AuditTransfer}, db)
+	if err != nil || len(entries) != 3 || entries[0].Result != AuditOK || entries[0].Params["beneficiary"] != "1" {
+		t.Errorf("transfers to beneficiary just be audited: %+v %v", entries, err)
+	}
+}
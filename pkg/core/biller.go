@@ -0,0 +1,359 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const PaymentPending = "pending"
+const PaymentConfirmed = "confirmed"
+const PaymentRejected = "rejected"
+
+var ErrPaymentRejected = errors.New("payment is rejected by the provider")
+var ErrPaymentPending = errors.New("payment is not confirmed by the provider yet")
+
+// errNeedsBiller rolls back a one-transaction payment to a service that
+// has a biller.
+var errNeedsBiller = errors.New("service payments go through a biller")
+
+// BillerTimeout bounds every call to a biller.
+var BillerTimeout = 30 * time.Second
+
+// BillerHoldTTL keeps the money of a pending payment reserved while the
+// biller is asked about it again. Once it runs out the money is free again
+// with nothing running for it; a biller that confirms later still gets
+// paid. It has to be longer than the interval ResolvePendingPayments runs
+// at.
+var BillerHoldTTL = 15 * time.Minute
+
+// BillerPayment is a payment as the provider sees it. Id stays the same
+// for every call about one payment, so billers can drop repeated posts.
+type BillerPayment struct {
+	Id        string
+	Service   string
+	Reference string
+	Amount    int
+}
+
+// Biller confirms service payments with the provider. PostPayment returns
+// an error wrapping ErrPaymentRejected when the provider refused the
+// payment; any other error leaves it unknown until PaymentStatus tells.
+type Biller interface {
+	ValidateReference(ctx context.Context, reference string) error
+	PostPayment(ctx context.Context, payment BillerPayment) (confirmation string, err error)
+	PaymentStatus(ctx context.Context, paymentId string) (status, confirmation string, err error)
+}
+
+// Billers are the implementations ServiceProvider.Biller names. They are
+// registered at start-up, before any payment is made.
+var Billers = map[string]Biller{}
+
+type billerPayment struct {
+	id        int64
+	cardId    int64
+	service   string
+	reference string
+	amount    int
+	fee       int
+	biller    string
+	holdId    int64
+	status    string
+}
+
+func (p billerPayment) key() string {
+	return "payment-" + strconv.FormatInt(p.id, 10)
+}
+
+// payThroughBiller pays in two phases: the money is held on the card for a
+// pending payment, the biller is asked to post it, and then the payment is either
+// captured to the service or its hold is voided. A payment the biller
+// didn't answer for stays pending until ResolvePendingPayments settles it.
+func payThroughBiller(nameService, reference string, amount int, db *sql.DB,
+	cardOf func(ctx context.Context, q queryer) (int64, error)) (Receipt, error) {
+	provider, err := ProviderGet(nameService, db)
+	if err != nil {
+		return Receipt{}, err
+	}
+	biller, err := billerOf(provider.Service, provider.Biller)
+	if err != nil {
+		return Receipt{}, err
+	}
+	err = provider.Validate(reference, amount)
+	if err != nil {
+		return Receipt{}, err
+	}
+	err = withBillerTimeout(func(ctx context.Context) error {
+		return biller.ValidateReference(ctx, reference)
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+	var payment billerPayment
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		cardId, err := cardOf(ctx, q)
+		if err != nil {
+			return err
+		}
+		payment, err = reserveBillerPayment(ctx, q, cardId, nameService, reference, amount)
+		return err
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+	var confirmation string
+	postErr := withBillerTimeout(func(ctx context.Context) (err error) {
+		confirmation, err = biller.PostPayment(ctx, BillerPayment{
+			Id:        payment.key(),
+			Service:   payment.service,
+			Reference: payment.reference,
+			Amount:    payment.amount,
+		})
+		return err
+	})
+	if postErr == nil {
+		return confirmPayment(payment.id, confirmation, db)
+	}
+	if errors.Is(postErr, ErrPaymentRejected) {
+		err = rejectPayment(payment.id, postErr, db)
+		if err != nil {
+			return Receipt{}, err
+		}
+		return Receipt{}, postErr
+	}
+	receipt, status, err := resolvePayment(payment, biller, db)
+	if err != nil {
+		return Receipt{}, err
+	}
+	switch status {
+	case PaymentConfirmed:
+		return receipt, nil
+	case PaymentRejected:
+		return Receipt{}, fmt.Errorf("payment %d to %s: %w", payment.id, nameService, ErrPaymentRejected)
+	}
+	return Receipt{}, fmt.Errorf("payment %d to %s: %v: %w", payment.id, nameService, postErr, ErrPaymentPending)
+}
+
+// ResolvePendingPayments asks the billers about payments left pending for
+// longer than BillerTimeout and confirms or returns them. Payments the
+// billers still can't answer for stay pending.
+func ResolvePendingPayments(db *sql.DB) (resolved int, err error) {
+	pending, err := pendingPayments(now().Add(-BillerTimeout), db)
+	if err != nil {
+		return 0, err
+	}
+	for _, payment := range pending {
+		biller, err := billerOf(payment.service, payment.biller)
+		if err != nil {
+			continue
+		}
+		_, status, err := resolvePayment(payment, biller, db)
+		if err != nil {
+			return resolved, err
+		}
+		if status != PaymentPending {
+			resolved++
+		}
+	}
+	return resolved, nil
+}
+
+// resolvePayment settles a payment the way the biller reports it and
+// returns the status it settled on, PaymentPending when the biller can't
+// tell yet.
+func resolvePayment(payment billerPayment, biller Biller, db *sql.DB) (receipt Receipt, status string, err error) {
+	var confirmation string
+	err = withBillerTimeout(func(ctx context.Context) (err error) {
+		status, confirmation, err = biller.PaymentStatus(ctx, payment.key())
+		return err
+	})
+	if err != nil {
+		return Receipt{}, PaymentPending, nil
+	}
+	switch status {
+	case PaymentConfirmed:
+		receipt, err = confirmPayment(payment.id, confirmation, db)
+	case PaymentRejected:
+		err = rejectPayment(payment.id, ErrPaymentRejected, db)
+	default:
+		status = PaymentPending
+	}
+	if err != nil {
+		return Receipt{}, "", err
+	}
+	return receipt, status, nil
+}
+
+func reserveBillerPayment(ctx context.Context, q queryer, cardId int64, nameService, reference string, amount int) (payment billerPayment, err error) {
+	err = lockActiveCards(ctx, q, cardId)
+	if err != nil {
+		return billerPayment{}, err
+	}
+	err = lockServiceRow(ctx, q, nameService)
+	if err != nil {
+		return billerPayment{}, err
+	}
+	provider, err := providerByName(ctx, q, nameService)
+	if err != nil {
+		return billerPayment{}, err
+	}
+	err = provider.Validate(reference, amount)
+	if err != nil {
+		return billerPayment{}, err
+	}
+	holdId, err := placeHold(ctx, q, cardId, amount+provider.Fee, BillerHoldTTL)
+	if err != nil {
+		return billerPayment{}, err
+	}
+	payment = billerPayment{
+		cardId:    cardId,
+		service:   nameService,
+		reference: reference,
+		amount:    amount,
+		fee:       provider.Fee,
+		biller:    provider.Biller,
+		holdId:    holdId,
+		status:    PaymentPending,
+	}
+	result, err := q.ExecContext(ctx, insertBillerPayment,
+		sql.Named("idCard", cardId),
+		sql.Named("service", nameService),
+		sql.Named("reference", reference),
+		sql.Named("amount", amount),
+		sql.Named("fee", provider.Fee),
+		sql.Named("biller", provider.Biller),
+		sql.Named("holdId", holdId),
+		sql.Named("createdAt", now().Unix()),
+	)
+	if err != nil {
+		return billerPayment{}, fmt.Errorf("can't reserve payment to %s: %w", nameService, err)
+	}
+	payment.id, err = result.LastInsertId()
+	if err != nil {
+		return billerPayment{}, fmt.Errorf("can't reserve payment to %s: %w", nameService, err)
+	}
+	return payment, nil
+}
+
+// confirmPayment captures the hold of a pending payment and credits it to
+// its service. Whoever settles the payment first wins; the other gets an
+// error.
+func confirmPayment(paymentId int64, confirmation string, db *sql.DB) (receipt Receipt, err error) {
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		payment, err := settleBillerPayment(ctx, q, paymentId, confirmBillerPayment,
+			sql.Named("confirmation", confirmation),
+			sql.Named("finishedAt", now().Unix()),
+			sql.Named("id", paymentId),
+		)
+		if err != nil {
+			return err
+		}
+		_, _, err = takeHold(ctx, q, payment.holdId, 0, true)
+		if err != nil {
+			return err
+		}
+		err = lockServiceRow(ctx, q, payment.service)
+		if err != nil {
+			return err
+		}
+		receipt, err = creditService(ctx, q, payment.cardId, payment.service, payment.reference, payment.amount, payment.fee)
+		if err != nil {
+			return err
+		}
+		err = linkHold(ctx, q, payment.holdId, receipt.TransactionId)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, setBillerPaymentTransaction,
+			sql.Named("transactionId", receipt.TransactionId),
+			sql.Named("id", paymentId),
+		)
+		if err != nil {
+			return fmt.Errorf("can't confirm payment %d: %w", paymentId, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+	return receipt, nil
+}
+
+// rejectPayment voids the hold of a pending payment and its fee.
+func rejectPayment(paymentId int64, reason error, db *sql.DB) error {
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		payment, err := settleBillerPayment(ctx, q, paymentId, rejectBillerPayment,
+			sql.Named("error", reason.Error()),
+			sql.Named("finishedAt", now().Unix()),
+			sql.Named("id", paymentId),
+		)
+		if err != nil {
+			return err
+		}
+		return releaseHold(ctx, q, payment.holdId)
+	})
+}
+
+// settleBillerPayment moves a pending payment to its final status with
+// query and reads it back.
+func settleBillerPayment(ctx context.Context, q queryer, paymentId int64, query string, args ...interface{}) (payment billerPayment, err error) {
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return billerPayment{}, fmt.Errorf("can't settle payment %d: %w", paymentId, err)
+	}
+	settled, err := result.RowsAffected()
+	if err != nil {
+		return billerPayment{}, fmt.Errorf("can't settle payment %d: %w", paymentId, err)
+	}
+	err = q.QueryRowContext(ctx, getBillerPayment, paymentId).Scan(&payment.id, &payment.cardId, &payment.service,
+		&payment.reference, &payment.amount, &payment.fee, &payment.biller, &payment.holdId, &payment.status)
+	if err != nil {
+		return billerPayment{}, fmt.Errorf("can't get payment %d: %w", paymentId, err)
+	}
+	if settled == 0 {
+		return billerPayment{}, fmt.Errorf("payment %d is already %s", paymentId, payment.status)
+	}
+	return payment, nil
+}
+
+func pendingPayments(before time.Time, db *sql.DB) (payments []billerPayment, err error) {
+	rows, err := db.Query(getPendingBillerPayments, before.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("can't get pending payments: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close pending payments: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var payment billerPayment
+		err = rows.Scan(&payment.id, &payment.cardId, &payment.service, &payment.reference,
+			&payment.amount, &payment.fee, &payment.biller, &payment.holdId, &payment.status)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan pending payment: %w", err)
+		}
+		payments = append(payments, payment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get pending payments: %w", err)
+	}
+	return payments, nil
+}
+
+func billerOf(service, name string) (Biller, error) {
+	biller, ok := Billers[name]
+	if !ok {
+		return nil, fmt.Errorf("biller %q of service %s is not registered: %w", name, service, ErrServiceInactive)
+	}
+	return biller, nil
+}
+
+func withBillerTimeout(call func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), BillerTimeout)
+	defer cancel()
+	return call(ctx)
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+func BlockCard(pan int64, reason string, db *sql.DB) (err error) {
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		cardId, err := cardIdByPAN(ctx, q, pan)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, blockCard,
+			sql.Named("idCard", cardId),
+			sql.Named("reason", reason),
+			sql.Named("blockedAt", time.Now().Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("can't block card %d: %w", pan, err)
+		}
+		return nil
+	})
+}
+
+func UnblockCard(pan int64, db *sql.DB) (err error) {
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		cardId, err := cardIdByPAN(ctx, q, pan)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, unblockCard, cardId)
+		if err != nil {
+			return fmt.Errorf("can't unblock card %d: %w", pan, err)
+		}
+		return nil
+	})
+}
+
+// MaskPAN keeps the first six and the last four digits.
+func MaskPAN(pan int64) string {
+	digits := []byte(fmt.Sprint(pan))
+	for i := 6; i < len(digits)-4; i++ {
+		digits[i] = '*'
+	}
+	return string(digits)
+}
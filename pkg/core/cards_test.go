@@ -0,0 +1,57 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBlockCard_RefusesOperations(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 500, 'JACK JACKSON', 111, 0525, 1);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	err = BlockCard(2021600000000001, "lost", db)
+	if err != nil {
+		t.Fatalf("can't block card: %v", err)
+	}
+	_, err = MoreCard(2021600000000000, 2021600000000001, 100, db)
+	if !errors.Is(err, ErrCardBlocked) {
+		t.Errorf("transfer to blocked card just be ErrCardBlocked: %v", err)
+	}
+	var blockedErr *CardBlockedError
+	if !errors.As(err, &blockedErr) || blockedErr.Reason != "lost" {
+		t.Errorf("reason just be lost: %v", err)
+	}
+	_, err = ServicesPayMoreCard("internet", "100200", 2021600000000001, 100, db)
+	if !errors.Is(err, ErrCardBlocked) {
+		t.Errorf("payment from blocked card just be ErrCardBlocked: %v", err)
+	}
+
+	err = UnblockCard(2021600000000001, db)
+	if err != nil {
+		t.Fatalf("can't unblock card: %v", err)
+	}
+	_, err = MoreCard(2021600000000000, 2021600000000001, 100, db)
+	if err != nil {
+		t.Errorf("can't execute transfer money: %v", err)
+	}
+}
+
+func TestBlockCard_UnknownCard(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	err := BlockCard(404, "lost", db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("just be ErrCardNotFound: %v", err)
+	}
+}
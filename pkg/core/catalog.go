@@ -0,0 +1,310 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	DSN "github.com/tohirov1994/database"
+)
+
+var ErrInvalidATM = errors.New("atm is not valid")
+var ErrInvalidService = errors.New("service is not valid")
+var ErrServiceExists = errors.New("service already exists")
+
+// Catalog items and the changes made to them.
+const CatalogATM = "atm"
+const CatalogService = "service"
+const CatalogAdd = "add"
+const CatalogUpdate = "update"
+const CatalogDeactivate = "deactivate"
+const CatalogSetStatus = "set-status"
+const CatalogSetHours = "set-hours"
+const CatalogSetCapabilities = "set-capabilities"
+
+// ATMs and services are never deleted: transactions refer to them. A
+// deactivated one drops out of every client listing and takes no more
+// operations; managers still find it in CatalogATMsGet and
+// CatalogServicesGet. Every change records the manager who made it.
+
+// CatalogChange is one change a manager made to the catalog.
+type CatalogChange struct {
+	Id        int64
+	ManagerId int
+	Kind      string
+	ItemId    int64
+	Action    string
+	At        time.Time
+}
+
+// ManagedATM is an ATM as managers see it. DeactivatedAt is zero while
+// the ATM is active.
+type ManagedATM struct {
+	Atm
+	DeactivatedAt time.Time
+}
+
+// ManagedService is a service as managers see it. DeactivatedAt is zero
+// while the service is active.
+type ManagedService struct {
+	Id            int64
+	Service       string
+	Balance       int
+	DeactivatedAt time.Time
+}
+
+// AddATM adds an ATM at the address of atm and returns its id. The rest of
+// its profile is set by SetATMLocation, SetATMStatus, SetATMHours and
+// SetATMCapabilities.
+func AddATM(atm Atm, managerId int, db *sql.DB) (atmId int64, err error) {
+	atm, err = atm.address()
+	if err != nil {
+		return 0, err
+	}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		result, err := q.ExecContext(ctx, DSN.InsertAtm,
+			sql.Named("cityName", atm.City),
+			sql.Named("districtName", atm.District),
+			sql.Named("streetName", atm.Street),
+		)
+		if err != nil {
+			return fmt.Errorf("can't add atm: %w", err)
+		}
+		atmId, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("can't get id of atm: %w", err)
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogATM, atmId, CatalogAdd)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return atmId, nil
+}
+
+// UpdateATM moves the ATM atm.Id to the address of atm.
+func UpdateATM(atm Atm, managerId int, db *sql.DB) error {
+	atm, err := atm.address()
+	if err != nil {
+		return err
+	}
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		err = checkATM(ctx, q, atm.Id)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, updateAtm,
+			sql.Named("atmId", atm.Id),
+			sql.Named("cityName", atm.City),
+			sql.Named("districtName", atm.District),
+			sql.Named("streetName", atm.Street),
+		)
+		if err != nil {
+			return fmt.Errorf("can't update atm %d: %w", atm.Id, err)
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogATM, atm.Id, CatalogUpdate)
+	})
+}
+
+// DeactivateATM takes the ATM out of the network for good.
+func DeactivateATM(atmId int64, managerId int, db *sql.DB) error {
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		err = checkATM(ctx, q, atmId)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, deactivateAtm, sql.Named("atmId", atmId), sql.Named("deactivatedAt", now().Unix()))
+		if err != nil {
+			return fmt.Errorf("can't deactivate atm %d: %w", atmId, err)
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogATM, atmId, CatalogDeactivate)
+	})
+}
+
+// address trims the address of the ATM and checks every part is given.
+func (atm Atm) address() (Atm, error) {
+	atm.City = strings.TrimSpace(atm.City)
+	atm.District = strings.TrimSpace(atm.District)
+	atm.Street = strings.TrimSpace(atm.Street)
+	if atm.City == "" || atm.District == "" || atm.Street == "" {
+		return Atm{}, fmt.Errorf("city, district and street are required: %w", ErrInvalidATM)
+	}
+	return atm, nil
+}
+
+// AddService adds a service nobody has used the name of and returns its id.
+// Names stay taken after deactivation, since transactions refer to services
+// by name; for the same reason they can't be changed. A manager updates
+// what the service takes with SetProvider.
+func AddService(name string, managerId int, db *sql.DB) (serviceId int64, err error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("name is required: %w", ErrInvalidService)
+	}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		var taken string
+		err = q.QueryRowContext(ctx, DSN.CheckServiceName, name).Scan(&taken)
+		if err == nil {
+			return fmt.Errorf("service %s: %w", name, ErrServiceExists)
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("can't check service %s: %w", name, err)
+		}
+		result, err := q.ExecContext(ctx, DSN.InsertService, sql.Named("serviceName", name), sql.Named("serviceBalance", 0))
+		if err != nil {
+			return fmt.Errorf("can't add service %s: %w", name, err)
+		}
+		serviceId, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("can't get id of service %s: %w", name, err)
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogService, serviceId, CatalogAdd)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return serviceId, nil
+}
+
+// DeactivateService stops the service from taking payments for good. Its
+// balance stays to be settled.
+func DeactivateService(name string, managerId int, db *sql.DB) error {
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		var serviceId int64
+		err = q.QueryRowContext(ctx, getServiceId, name).Scan(&serviceId)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("service %s: %w", name, ErrServiceNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("can't find service %s: %w", name, err)
+		}
+		_, err = q.ExecContext(ctx, deactivateService, sql.Named("serviceId", serviceId), sql.Named("deactivatedAt", now().Unix()))
+		if err != nil {
+			return fmt.Errorf("can't deactivate service %s: %w", name, err)
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogService, serviceId, CatalogDeactivate)
+	})
+}
+
+func recordCatalogChange(ctx context.Context, q queryer, managerId int, kind string, itemId int64, action string) error {
+	_, err := q.ExecContext(ctx, insertCatalogChange,
+		sql.Named("managerId", managerId),
+		sql.Named("kind", kind),
+		sql.Named("itemId", itemId),
+		sql.Named("action", action),
+		sql.Named("changedAt", now().Unix()),
+	)
+	if err != nil {
+		return fmt.Errorf("can't record %s of %s %d: %w", action, kind, itemId, err)
+	}
+	return nil
+}
+
+// CatalogATMsGet returns every ATM by id, the deactivated ones included.
+func CatalogATMsGet(db *sql.DB) (atms []ManagedATM, err error) {
+	rows, err := db.Query(getCatalogAtms)
+	if err != nil {
+		return nil, fmt.Errorf("can't get atm catalog: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close atm catalog: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var deactivatedAt int64
+		atm, err := scanAtm(rows, &deactivatedAt)
+		if err != nil {
+			return nil, err
+		}
+		managed := ManagedATM{Atm: atm}
+		if deactivatedAt != 0 {
+			managed.DeactivatedAt = time.Unix(deactivatedAt, 0)
+		}
+		atms = append(atms, managed)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get atm catalog: %w", err)
+	}
+	return atms, nil
+}
+
+// CatalogServicesGet returns every service by id, the deactivated ones
+// included.
+func CatalogServicesGet(db *sql.DB) (services []ManagedService, err error) {
+	rows, err := db.Query(getCatalogServices)
+	if err != nil {
+		return nil, fmt.Errorf("can't get service catalog: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close service catalog: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var service ManagedService
+		var deactivatedAt int64
+		err = rows.Scan(&service.Id, &service.Service, &service.Balance, &deactivatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan service: %w", err)
+		}
+		if deactivatedAt != 0 {
+			service.DeactivatedAt = time.Unix(deactivatedAt, 0)
+		}
+		services = append(services, service)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get service catalog: %w", err)
+	}
+	return services, nil
+}
+
+// CatalogChangesGet returns the changes made to the catalog, oldest first.
+func CatalogChangesGet(db *sql.DB) (changes []CatalogChange, err error) {
+	rows, err := db.Query(getCatalogChanges)
+	if err != nil {
+		return nil, fmt.Errorf("can't get catalog changes: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close catalog changes: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var change CatalogChange
+		var changedAt int64
+		err = rows.Scan(&change.Id, &change.ManagerId, &change.Kind, &change.ItemId, &change.Action, &changedAt)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan catalog change: %w", err)
+		}
+		change.At = time.Unix(changedAt, 0)
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get catalog changes: %w", err)
+	}
+	return changes, nil
+}
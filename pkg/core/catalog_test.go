@@ -0,0 +1,160 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestATMCatalog(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	atmId, err := AddATM(Atm{City: " Khujand ", District: "Center", Street: "Lenin 5"}, 1, db)
+	if err != nil {
+		t.Fatalf("can't add atm: %v", err)
+	}
+	err = UpdateATM(Atm{Id: atmId, City: "Khujand", District: "Panjshanbe", Street: "Lenin 7"}, 1, db)
+	if err != nil {
+		t.Fatalf("can't update atm: %v", err)
+	}
+	atms, err := ATMsGet(db)
+	if err != nil || len(atms) != 2 {
+		t.Fatalf("can't get atms: %v %v", atms, err)
+	}
+	if atms[1].Id != atmId || atms[1].City != "Khujand" || atms[1].District != "Panjshanbe" || atms[1].Street != "Lenin 7" {
+		t.Errorf("atm just be moved to Panjshanbe: %+v", atms[1])
+	}
+	_, err = AddATM(Atm{City: "Khujand", District: " ", Street: "Lenin 5"}, 1, db)
+	if !errors.Is(err, ErrInvalidATM) {
+		t.Errorf("atm without district just be ErrInvalidATM: %v", err)
+	}
+	err = UpdateATM(Atm{Id: 404, City: "Khujand", District: "Center", Street: "Lenin 5"}, 1, db)
+	if !errors.Is(err, ErrATMNotFound) {
+		t.Errorf("unknown atm just be ErrATMNotFound: %v", err)
+	}
+	_, err = AddATM(Atm{City: "Khujand", District: "Center", Street: "Lenin 9"}, 404, db)
+	if !errors.Is(err, ErrManagerNotFound) {
+		t.Errorf("atm added by unknown manager just be ErrManagerNotFound: %v", err)
+	}
+
+	loadAtm(t, db)
+	err = SetATMLocation(1, Location{Latitude: 38.5598, Longitude: 68.7870}, db)
+	if err != nil {
+		t.Fatalf("can't place atm: %v", err)
+	}
+	err = DeactivateATM(1, 1, db)
+	if err != nil {
+		t.Fatalf("can't deactivate atm: %v", err)
+	}
+	atms, err = ATMsGet(db)
+	if err != nil || !reflect.DeepEqual(atmIds(atms), []int64{atmId}) {
+		t.Errorf("deactivated atm just be gone: %v %v", atmIds(atms), err)
+	}
+	nearby, err := NearestATMs(38.5598, 68.7870, 0, 0, ATMFilter{}, db)
+	if err != nil || len(nearby) != 0 {
+		t.Errorf("deactivated atm just not be near: %+v %v", nearby, err)
+	}
+	_, err = Withdraw(1, 2021600000000000, 1994, 5000, db)
+	if !errors.Is(err, ErrATMNotFound) {
+		t.Errorf("withdrawal at deactivated atm just be ErrATMNotFound: %v", err)
+	}
+	err = DeactivateATM(1, 1, db)
+	if !errors.Is(err, ErrATMNotFound) {
+		t.Errorf("second deactivation just be ErrATMNotFound: %v", err)
+	}
+	catalog, err := CatalogATMsGet(db)
+	if err != nil || len(catalog) != 2 {
+		t.Fatalf("manager just see both atms: %+v %v", catalog, err)
+	}
+	if catalog[0].Id != 1 || catalog[0].DeactivatedAt.IsZero() || catalog[0].Location == nil {
+		t.Errorf("deactivated atm just be listed with its profile: %+v", catalog[0])
+	}
+	if catalog[1].Id != atmId || !catalog[1].DeactivatedAt.IsZero() {
+		t.Errorf("active atm just be listed as active: %+v", catalog[1])
+	}
+	changes, err := CatalogChangesGet(db)
+	if err != nil || len(changes) != 3 {
+		t.Fatalf("catalog just have 3 changes: %+v %v", changes, err)
+	}
+	for i, action := range []string{CatalogAdd, CatalogUpdate, CatalogDeactivate} {
+		if changes[i].ManagerId != 1 || changes[i].Kind != CatalogATM || changes[i].Action != action {
+			t.Errorf("change %d just be %s by manager 1: %+v", i, action, changes[i])
+		}
+	}
+}
+
+func TestServiceCatalog(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	serviceId, err := AddService(" water ", 1, db)
+	if err != nil {
+		t.Fatalf("can't add service: %v", err)
+	}
+	services, err := GetAllService(db)
+	if err != nil || len(services) != 2 || services[1] != (ServicesStruct{Id: int(serviceId), Service: "water"}) {
+		t.Fatalf("services just be internet and water: %v %v", services, err)
+	}
+	_, err = AddService("water", 1, db)
+	if !errors.Is(err, ErrServiceExists) {
+		t.Errorf("second water just be ErrServiceExists: %v", err)
+	}
+	_, err = AddService("  ", 1, db)
+	if !errors.Is(err, ErrInvalidService) {
+		t.Errorf("blank name just be ErrInvalidService: %v", err)
+	}
+
+	_, err = ServicesPayMoreCard("water", "42", 2021600000000000, 1000, db)
+	if err != nil {
+		t.Fatalf("can't pay water: %v", err)
+	}
+	err = DeactivateService("water", 1, db)
+	if err != nil {
+		t.Fatalf("can't deactivate service: %v", err)
+	}
+	services, err = GetAllService(db)
+	if err != nil || len(services) != 1 || services[0].Service != "internet" {
+		t.Errorf("deactivated service just be gone: %v %v", services, err)
+	}
+	providers, err := ProvidersGet(db)
+	if err != nil || len(providers) != 1 || providers[0].Service != "internet" {
+		t.Errorf("deactivated service just be gone from the catalog: %+v %v", providers, err)
+	}
+	_, err = ServicesPayMoreCard("water", "42", 2021600000000000, 1000, db)
+	if !errors.Is(err, ErrServiceInactive) {
+		t.Errorf("payment to deactivated service just be ErrServiceInactive: %v", err)
+	}
+	if balance := serviceBalance(t, db, "water"); balance != 1000 {
+		t.Errorf("deactivated service just keep its balance: %d", balance)
+	}
+	_, err = AddService("water", 1, db)
+	if !errors.Is(err, ErrServiceExists) {
+		t.Errorf("name of deactivated service just stay taken: %v", err)
+	}
+	err = DeactivateService("water", 1, db)
+	if !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("second deactivation just be ErrServiceNotFound: %v", err)
+	}
+	catalog, err := CatalogServicesGet(db)
+	if err != nil || len(catalog) != 2 {
+		t.Fatalf("manager just see both services: %+v %v", catalog, err)
+	}
+	water := catalog[1]
+	if water.Id != serviceId || water.Service != "water" || water.Balance != 1000 || water.DeactivatedAt.IsZero() {
+		t.Errorf("deactivated water just be listed: %+v", water)
+	}
+	if !catalog[0].DeactivatedAt.IsZero() {
+		t.Errorf("internet just be active: %+v", catalog[0])
+	}
+	changes, err := CatalogChangesGet(db)
+	if err != nil || len(changes) != 2 || changes[1].ItemId != serviceId || changes[1].Action != CatalogDeactivate {
+		t.Errorf("service changes just be recorded: %+v %v", changes, err)
+	}
+}
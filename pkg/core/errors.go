@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrorPassword = errors.New("password is not valid")
+var ErrClientNotFound = errors.New("client not found")
+var ErrCardNotFound = errors.New("card not found")
+var ErrServiceNotFound = errors.New("service not found")
+var ErrInsufficientFunds = errors.New("insufficient funds")
+var ErrCardBlocked = errors.New("card is blocked")
+var ErrTransactionNotFound = errors.New("transaction not found")
+var ErrManagerNotFound = errors.New("manager not found")
+var ErrAlreadyReversed = errors.New("transaction is already reversed")
+var ErrNotReversible = errors.New("transaction can't be reversed")
+var ErrInvalidAmount = errors.New("amount must be positive")
+
+// InsufficientFundsError carries the figures behind ErrInsufficientFunds,
+// so callers can tell the client how much is missing.
+type InsufficientFundsError struct {
+	CardId  int64
+	Balance int
+	Amount  int
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("card %d has %d, needs %d: %v", e.CardId, e.Balance, e.Amount, ErrInsufficientFunds)
+}
+
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// CardBlockedError names the card that refused the operation.
+type CardBlockedError struct {
+	CardId int64
+	Reason string
+}
+
+func (e *CardBlockedError) Error() string {
+	return fmt.Sprintf("card %d: %v: %s", e.CardId, ErrCardBlocked, e.Reason)
+}
+
+func (e *CardBlockedError) Is(target error) bool {
+	return target == ErrCardBlocked
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func openBankDb(t *testing.T) *sql.DB {
+	db, err := sql.Open(dbDriver, dbMemory)
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	err = Init(db)
+	if err != nil {
+		t.Fatalf("can't init db: %v", err)
+	}
+	return db
+}
+
+func TestErrors_NotFound(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := GetCurrentBalanceClientId(404, db)
+	if !errors.Is(err, ErrClientNotFound) {
+		t.Errorf("just be ErrClientNotFound: %v", err)
+	}
+	_, _, err = GetCurrentBalanceClientPAN(404, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("just be ErrCardNotFound: %v", err)
+	}
+	_, err = CheckPan(404, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("just be ErrCardNotFound: %v", err)
+	}
+	_, err = SelectCards(1, 404, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("just be ErrCardNotFound: %v", err)
+	}
+	_, err = CheckServiceName("water", db)
+	if !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("just be ErrServiceNotFound: %v", err)
+	}
+	_, err = OneCard(2021600000000000, 404, 10, db)
+	if !errors.Is(err, ErrClientNotFound) {
+		t.Errorf("just be ErrClientNotFound: %v", err)
+	}
+	_, err = MoreCard(2021600000000000, 404, 10, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("just be ErrCardNotFound: %v", err)
+	}
+	_, err = ServicesPayMoreCard("water", "100200", 2021600000000000, 10, db)
+	if !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("just be ErrServiceNotFound: %v", err)
+	}
+}
+
+func TestErrors_InsufficientFunds(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := ServicesPayOneCard("internet", "100200", 1, 1000001, db)
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("just be ErrInsufficientFunds: %v", err)
+	}
+	var fundsErr *InsufficientFundsError
+	if !errors.As(err, &fundsErr) {
+		t.Fatalf("just be InsufficientFundsError: %v", err)
+	}
+	if fundsErr.Balance != 1000000 || fundsErr.Amount != 1000001 {
+		t.Errorf("balance and amount just be 1000000 and 1000001: %d %d", fundsErr.Balance, fundsErr.Amount)
+	}
+}
+
+func TestErrors_WrapDriverErrors(t *testing.T) {
+	db, err := sql.Open(dbDriver, dbMemory)
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	_ = db.Close()
+	_, err = GetTransferCard(1, db)
+	if err == nil || errors.Unwrap(err) == nil {
+		t.Errorf("driver error just be wrapped: %v", err)
+	}
+}
@@ -0,0 +1,381 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fraud rule decisions, from the mildest.
+const FraudAllow = "allow"
+const FraudReview = "review"
+const FraudDeny = "deny"
+
+// Screening statuses. A transfer held for review is released or rejected
+// by ReviewTransfer; a denied one stays denied.
+const ScreeningHeld = "held"
+const ScreeningDenied = "denied"
+const ScreeningReleased = "released"
+const ScreeningRejected = "rejected"
+
+// FraudLookback is how far back FraudCheck.Recent goes.
+const FraudLookback = 24 * time.Hour
+
+var ErrTransferDenied = errors.New("transfer is denied by fraud screening")
+var ErrTransferHeld = errors.New("transfer is held for fraud review")
+var ErrScreeningNotFound = errors.New("fraud screening not found")
+var ErrScreeningClosed = errors.New("fraud screening is not held")
+
+// FraudCheck is a transfer about to be made and what the bank knows of
+// its sender.
+type FraudCheck struct {
+	FromCardId int64
+	ToCardId   int64
+	Amount     int
+	At         time.Time
+	// Recent are the sender's transfers within FraudLookback, newest first.
+	Recent []PastTransfer
+	// Transfers and AverageAmount cover every transfer the sender has made.
+	Transfers     int
+	AverageAmount int
+	// KnownReceiver tells whether the sender has transferred to the
+	// receiver before.
+	KnownReceiver bool
+}
+
+type PastTransfer struct {
+	ToCardId int64
+	Amount   int
+	At       time.Time
+}
+
+// FraudVerdict is the decision of one rule. Reason explains any decision
+// but FraudAllow.
+type FraudVerdict struct {
+	Decision string
+	Reason   string
+}
+
+// FraudRule screens transfers before money moves.
+type FraudRule interface {
+	Screen(check FraudCheck) FraudVerdict
+}
+
+// FraudRules screen every OneCard and MoreCard transfer; the strictest
+// decision wins. They are registered at start-up, before any transfer is
+// made.
+var FraudRules []FraudRule
+
+// DefaultFraudRules are the rules of the risk team.
+func DefaultFraudRules() []FraudRule {
+	return []FraudRule{
+		VelocityRule{Max: 5, Window: 10 * time.Minute, Decision: FraudDeny},
+		LargeAmountRule{Factor: 10, MinTransfers: 3, Decision: FraudReview},
+		NewReceiverRule{MinAmount: 500000, Decision: FraudReview},
+		NightRule{From: 0, To: 6, MinAmount: 200000, Decision: FraudReview},
+	}
+}
+
+var fraudAllowed = FraudVerdict{Decision: FraudAllow}
+
+// VelocityRule flags a transfer that makes more than Max transfers of the
+// sender within Window. Window is at most FraudLookback.
+type VelocityRule struct {
+	Max      int
+	Window   time.Duration
+	Decision string
+}
+
+func (r VelocityRule) Screen(check FraudCheck) FraudVerdict {
+	count := 1
+	for _, past := range check.Recent {
+		if check.At.Sub(past.At) < r.Window {
+			count++
+		}
+	}
+	if count <= r.Max {
+		return fraudAllowed
+	}
+	return FraudVerdict{Decision: r.Decision, Reason: fmt.Sprintf("%d transfers within %v", count, r.Window)}
+}
+
+// LargeAmountRule flags a transfer over Factor times the sender's average
+// once the sender has made MinTransfers transfers to average.
+type LargeAmountRule struct {
+	Factor       int
+	MinTransfers int
+	Decision     string
+}
+
+func (r LargeAmountRule) Screen(check FraudCheck) FraudVerdict {
+	if check.Transfers < r.MinTransfers || check.Amount <= r.Factor*check.AverageAmount {
+		return fraudAllowed
+	}
+	return FraudVerdict{Decision: r.Decision,
+		Reason: fmt.Sprintf("amount %d is over %d times the average %d", check.Amount, r.Factor, check.AverageAmount)}
+}
+
+// NewReceiverRule flags a first transfer of MinAmount or more to a
+// receiver.
+type NewReceiverRule struct {
+	MinAmount int
+	Decision  string
+}
+
+func (r NewReceiverRule) Screen(check FraudCheck) FraudVerdict {
+	if check.KnownReceiver || check.Amount < r.MinAmount {
+		return fraudAllowed
+	}
+	return FraudVerdict{Decision: r.Decision, Reason: fmt.Sprintf("first transfer of %d to the receiver", check.Amount)}
+}
+
+// NightRule flags a transfer of MinAmount or more made from the hour From
+// up to the hour To. From after To runs past midnight. Hours are in
+// Location, the zone of the transfer time when nil.
+type NightRule struct {
+	From      int
+	To        int
+	MinAmount int
+	Location  *time.Location
+	Decision  string
+}
+
+func (r NightRule) Screen(check FraudCheck) FraudVerdict {
+	at := check.At
+	if r.Location != nil {
+		at = at.In(r.Location)
+	}
+	hour := at.Hour()
+	night := hour >= r.From && hour < r.To
+	if r.From > r.To {
+		night = hour >= r.From || hour < r.To
+	}
+	if !night || check.Amount < r.MinAmount {
+		return fraudAllowed
+	}
+	return FraudVerdict{Decision: r.Decision, Reason: fmt.Sprintf("transfer of %d at %s", check.Amount, at.Format("15:04"))}
+}
+
+// FraudError is a transfer the rules held or denied.
+type FraudError struct {
+	ScreeningId int64
+	Decision    string
+	Reasons     []string
+	fromCardId  int64
+	toCardId    int64
+	amount      int
+}
+
+func (e *FraudError) Error() string {
+	sentinel := ErrTransferDenied
+	if e.Decision == FraudReview {
+		sentinel = ErrTransferHeld
+	}
+	return fmt.Sprintf("screening %d: %v: %s", e.ScreeningId, sentinel, strings.Join(e.Reasons, "; "))
+}
+
+func (e *FraudError) Is(target error) bool {
+	if e.Decision == FraudReview {
+		return target == ErrTransferHeld
+	}
+	return target == ErrTransferDenied
+}
+
+// Screening is a transfer the rules held or denied.
+type Screening struct {
+	Id            int64
+	FromPAN       int64
+	ToPAN         int64
+	Amount        int
+	Decision      string
+	Reasons       []string
+	Status        string
+	TransactionId int64
+	At            time.Time
+	ReviewedAt    time.Time
+	ReviewerId    int
+}
+
+// screenTransfer runs FraudRules and returns a *FraudError unless they
+// all fraudAllowed the transfer.
+func screenTransfer(ctx context.Context, q queryer, senderCardId, receiverCardId int64, amount int) error {
+	if len(FraudRules) == 0 {
+		return nil
+	}
+	check, err := fraudCheckOf(ctx, q, senderCardId, receiverCardId, amount)
+	if err != nil {
+		return err
+	}
+	fraudErr := &FraudError{Decision: FraudAllow, fromCardId: senderCardId, toCardId: receiverCardId, amount: amount}
+	for _, rule := range FraudRules {
+		verdict := rule.Screen(check)
+		if verdict.Decision == FraudAllow {
+			continue
+		}
+		fraudErr.Reasons = append(fraudErr.Reasons, verdict.Reason)
+		if verdict.Decision == FraudDeny || fraudErr.Decision == FraudAllow {
+			fraudErr.Decision = verdict.Decision
+		}
+	}
+	if fraudErr.Decision == FraudAllow {
+		return nil
+	}
+	return fraudErr
+}
+
+func fraudCheckOf(ctx context.Context, q queryer, senderCardId, receiverCardId int64, amount int) (check FraudCheck, err error) {
+	check = FraudCheck{FromCardId: senderCardId, ToCardId: receiverCardId, Amount: amount, At: now()}
+	var average float64
+	err = q.QueryRowContext(ctx, getTransferStats,
+		sql.Named("idCard", senderCardId),
+		sql.Named("toCardId", receiverCardId),
+	).Scan(&check.Transfers, &average, &check.KnownReceiver)
+	if err != nil {
+		return FraudCheck{}, fmt.Errorf("can't get transfers of card %d: %w", senderCardId, err)
+	}
+	check.AverageAmount = int(average)
+	rows, err := q.QueryContext(ctx, getRecentTransfers,
+		sql.Named("idCard", senderCardId),
+		sql.Named("since", check.At.Add(-FraudLookback).Unix()),
+	)
+	if err != nil {
+		return FraudCheck{}, fmt.Errorf("can't get recent transfers of card %d: %w", senderCardId, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close recent transfers: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var past PastTransfer
+		var at int64
+		err = rows.Scan(&past.ToCardId, &past.Amount, &at)
+		if err != nil {
+			return FraudCheck{}, fmt.Errorf("can't scan recent transfer: %w", err)
+		}
+		past.At = time.Unix(at, 0)
+		check.Recent = append(check.Recent, past)
+	}
+	if err = rows.Err(); err != nil {
+		return FraudCheck{}, fmt.Errorf("can't get recent transfers of card %d: %w", senderCardId, err)
+	}
+	return check, nil
+}
+
+// recordScreening stores the held or denied transfer and returns fraudErr
+// with its id.
+func recordScreening(db *sql.DB, fraudErr *FraudError) error {
+	status := ScreeningDenied
+	if fraudErr.Decision == FraudReview {
+		status = ScreeningHeld
+	}
+	err := runTx(db, func(ctx context.Context, q queryer) error {
+		result, err := q.ExecContext(ctx, insertScreening,
+			sql.Named("fromCardId", fraudErr.fromCardId),
+			sql.Named("toCardId", fraudErr.toCardId),
+			sql.Named("amount", fraudErr.amount),
+			sql.Named("decision", fraudErr.Decision),
+			sql.Named("reasons", strings.Join(fraudErr.Reasons, "\n")),
+			sql.Named("status", status),
+			sql.Named("createdAt", now().Unix()),
+		)
+		if err != nil {
+			return err
+		}
+		fraudErr.ScreeningId, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%w (can't record screening: %v)", fraudErr, err)
+	}
+	return fraudErr
+}
+
+// ScreeningsGet returns the screenings in the status, every one when it is
+// empty, oldest first.
+func ScreeningsGet(status string, db *sql.DB) (screenings []Screening, err error) {
+	rows, err := db.Query(getScreenings, sql.Named("status", status))
+	if err != nil {
+		return nil, fmt.Errorf("can't get screenings: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close screenings: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var screening Screening
+		var reasons string
+		var at, reviewedAt int64
+		err = rows.Scan(&screening.Id, &screening.FromPAN, &screening.ToPAN, &screening.Amount, &screening.Decision,
+			&reasons, &screening.Status, &screening.TransactionId, &at, &reviewedAt, &screening.ReviewerId)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan screening: %w", err)
+		}
+		screening.Reasons = strings.Split(reasons, "\n")
+		screening.At = time.Unix(at, 0)
+		if reviewedAt != 0 {
+			screening.ReviewedAt = time.Unix(reviewedAt, 0)
+		}
+		screenings = append(screenings, screening)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get screenings: %w", err)
+	}
+	return screenings, nil
+}
+
+// ReviewTransfer lets the manager release or reject a held transfer. A
+// released transfer is made as it was asked, without screening it again.
+// Either way the screening keeps the reviewer, and the review is audited
+// with the transfer it made.
+func ReviewTransfer(screeningId int64, release bool, managerId int, db *sql.DB) (transactionId int64, err error) {
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		var fromCardId, toCardId int64
+		var amount int
+		var status string
+		err = q.QueryRowContext(ctx, getScreening, screeningId).Scan(&fromCardId, &toCardId, &amount, &status)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("screening %d: %w", screeningId, ErrScreeningNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("can't get screening %d: %w", screeningId, err)
+		}
+		if status != ScreeningHeld {
+			return fmt.Errorf("screening %d is %s: %w", screeningId, status, ErrScreeningClosed)
+		}
+		status = ScreeningRejected
+		if release {
+			status = ScreeningReleased
+			transactionId, err = moveFunds(ctx, q, fromCardId, toCardId, amount)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = q.ExecContext(ctx, closeScreening,
+			sql.Named("id", screeningId),
+			sql.Named("status", status),
+			sql.Named("transactionId", transactionId),
+			sql.Named("reviewedAt", now().Unix()),
+			sql.Named("reviewerId", managerId),
+		)
+		if err != nil {
+			return fmt.Errorf("can't close screening %d: %w", screeningId, err)
+		}
+		record := auditRecord{actor: fmt.Sprintf("manager %d", managerId), action: AuditTransferReview,
+			params: map[string]string{"screening": fmt.Sprint(screeningId), "status": status,
+				"amount": fmt.Sprint(amount), "transaction": fmt.Sprint(transactionId)}}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return transactionId, nil
+}
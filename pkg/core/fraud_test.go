@@ -0,0 +1,170 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFraudRules_Screen(t *testing.T) {
+	dushanbe, err := time.LoadLocation("Asia/Dushanbe")
+	if err != nil {
+		t.Fatalf("can't load location: %v", err)
+	}
+	recent := []PastTransfer{{At: at(2, 12).Add(-time.Minute)}, {At: at(2, 12).Add(-5 * time.Minute)}, {At: at(2, 11)}}
+	cases := []struct {
+		name     string
+		rule     FraudRule
+		check    FraudCheck
+		decision string
+	}{
+		{"velocity under max", VelocityRule{Max: 3, Window: 10 * time.Minute, Decision: FraudDeny},
+			FraudCheck{At: at(2, 12), Recent: recent}, FraudAllow},
+		{"velocity over max", VelocityRule{Max: 2, Window: 10 * time.Minute, Decision: FraudDeny},
+			FraudCheck{At: at(2, 12), Recent: recent}, FraudDeny},
+		{"large amount", LargeAmountRule{Factor: 10, MinTransfers: 3, Decision: FraudReview},
+			FraudCheck{Amount: 10001, Transfers: 3, AverageAmount: 1000}, FraudReview},
+		{"large amount at the factor", LargeAmountRule{Factor: 10, MinTransfers: 3, Decision: FraudReview},
+			FraudCheck{Amount: 10000, Transfers: 3, AverageAmount: 1000}, FraudAllow},
+		{"large amount without history", LargeAmountRule{Factor: 10, MinTransfers: 3, Decision: FraudReview},
+			FraudCheck{Amount: 10001, Transfers: 2, AverageAmount: 1000}, FraudAllow},
+		{"new receiver", NewReceiverRule{MinAmount: 100, Decision: FraudReview},
+			FraudCheck{Amount: 100}, FraudReview},
+		{"new receiver small amount", NewReceiverRule{MinAmount: 100, Decision: FraudReview},
+			FraudCheck{Amount: 99}, FraudAllow},
+		{"known receiver", NewReceiverRule{MinAmount: 100, Decision: FraudReview},
+			FraudCheck{Amount: 100, KnownReceiver: true}, FraudAllow},
+		{"night", NightRule{From: 0, To: 6, MinAmount: 100, Decision: FraudReview},
+			FraudCheck{Amount: 100, At: at(3, 5)}, FraudReview},
+		{"morning", NightRule{From: 0, To: 6, MinAmount: 100, Decision: FraudReview},
+			FraudCheck{Amount: 100, At: at(3, 6)}, FraudAllow},
+		{"night past midnight", NightRule{From: 22, To: 6, MinAmount: 100, Decision: FraudReview},
+			FraudCheck{Amount: 100, At: at(3, 23)}, FraudReview},
+		{"night in location", NightRule{From: 0, To: 6, MinAmount: 100, Location: dushanbe, Decision: FraudReview},
+			FraudCheck{Amount: 100, At: at(2, 20)}, FraudReview},
+	}
+	for _, c := range cases {
+		verdict := c.rule.Screen(c.check)
+		if verdict.Decision != c.decision {
+			t.Errorf("%s just be %s: %+v", c.name, c.decision, verdict)
+		}
+		if verdict.Decision != FraudAllow && verdict.Reason == "" {
+			t.Errorf("%s just give a reason", c.name)
+		}
+	}
+}
+
+func TestTransfer_FraudScreening(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer func(rules []FraudRule) { FraudRules = rules }(FraudRules)
+	FraudRules = DefaultFraudRules()
+	restore := setNow(at(2, 12))
+	defer restore()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 0, 'JACK JACKSON', 111, 0525, 1);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	const from, to = 2021600000000000, 2021600000000001
+	balance := func(pan int64) int {
+		balance, _, err := GetCurrentBalanceClientPAN(pan, db)
+		if err != nil {
+			t.Fatalf("can't get balance: %v", err)
+		}
+		return balance
+	}
+
+	_, err = MoreCard(from, to, 600000, db)
+	var fraudErr *FraudError
+	if !errors.Is(err, ErrTransferHeld) || !errors.As(err, &fraudErr) || fraudErr.ScreeningId == 0 {
+		t.Fatalf("big first transfer just be held: %v", err)
+	}
+	if balance(to) != 0 {
+		t.Errorf("held transfer just not move money")
+	}
+	for i := 0; i < 5; i++ {
+		_, err = MoreCard(from, to, 1000, db)
+		if err != nil {
+			t.Fatalf("can't make transfer %d: %v", i, err)
+		}
+	}
+	_, err = MoreCard(from, to, 1000, db)
+	if !errors.Is(err, ErrTransferDenied) {
+		t.Errorf("sixth transfer in a minute just be denied: %v", err)
+	}
+	setNow(at(3, 2))
+	_, err = OneCard(to, 1, 200000, db)
+	var nightErr *FraudError
+	if !errors.Is(err, ErrTransferHeld) || !errors.As(err, &nightErr) {
+		t.Fatalf("night transfer just be held: %v", err)
+	}
+	if balance(to) != 5000 {
+		t.Errorf("only allowed transfers just move money: %d", balance(to))
+	}
+
+	screenings, err := ScreeningsGet(ScreeningHeld, db)
+	if err != nil || len(screenings) != 2 {
+		t.Fatalf("two transfers just be held: %+v %v", screenings, err)
+	}
+	first := screenings[0]
+	if first.Id != fraudErr.ScreeningId || first.FromPAN != from || first.ToPAN != to || first.Amount != 600000 ||
+		first.Decision != FraudReview || len(first.Reasons) != 1 || !strings.Contains(first.Reasons[0], "first transfer") {
+		t.Errorf("first screening just be the big transfer: %+v", first)
+	}
+	denied, err := ScreeningsGet(ScreeningDenied, db)
+	if err != nil || len(denied) != 1 || denied[0].Decision != FraudDeny {
+		t.Errorf("one transfer just be denied: %+v %v", denied, err)
+	}
+	entries, err := AuditLog(AuditFilter{Action: AuditTransfer}, db)
+	if err != nil || len(entries) != 8 || entries[0].Result != fraudErr.Error() {
+		t.Errorf("held transfer just be audited with its screening: %+v %v", entries, err)
+	}
+
+	transactionId, err := ReviewTransfer(nightErr.ScreeningId, true, 1, db)
+	if err != nil || transactionId == 0 {
+		t.Fatalf("can't release transfer: %v", err)
+	}
+	if balance(to) != 205000 {
+		t.Errorf("released transfer just move money: %d", balance(to))
+	}
+	_, err = ReviewTransfer(fraudErr.ScreeningId, false, 1, db)
+	if err != nil {
+		t.Fatalf("can't reject transfer: %v", err)
+	}
+	if balance(to) != 205000 {
+		t.Errorf("rejected transfer just not move money: %d", balance(to))
+	}
+	screenings, err = ScreeningsGet("", db)
+	if err != nil || len(screenings) != 3 || screenings[0].Status != ScreeningRejected ||
+		screenings[2].Status != ScreeningReleased || screenings[2].TransactionId != transactionId ||
+		!screenings[2].ReviewedAt.Equal(at(3, 2)) || screenings[2].ReviewerId != 1 {
+		t.Errorf("screenings just be rejected, denied and released: %+v %v", screenings, err)
+	}
+	reviews, err := AuditLog(AuditFilter{Action: AuditTransferReview}, db)
+	if err != nil || len(reviews) != 2 || reviews[0].Actor != "manager 1" || reviews[0].Params["status"] != ScreeningReleased ||
+		reviews[0].Params["transaction"] != fmt.Sprint(transactionId) || reviews[1].Params["status"] != ScreeningRejected {
+		t.Errorf("reviews just be audited with the transfer released: %+v %v", reviews, err)
+	}
+	_, err = ReviewTransfer(nightErr.ScreeningId, true, 1, db)
+	if !errors.Is(err, ErrScreeningClosed) {
+		t.Errorf("second release just be ErrScreeningClosed: %v", err)
+	}
+	_, err = ReviewTransfer(denied[0].Id, true, 1, db)
+	if !errors.Is(err, ErrScreeningClosed) {
+		t.Errorf("denied transfer just not be released: %v", err)
+	}
+	_, err = ReviewTransfer(404, true, 1, db)
+	if !errors.Is(err, ErrScreeningNotFound) {
+		t.Errorf("unknown screening just be ErrScreeningNotFound: %v", err)
+	}
+	_, err = ReviewTransfer(denied[0].Id, true, 404, db)
+	if !errors.Is(err, ErrManagerNotFound) {
+		t.Errorf("review by unknown manager just be ErrManagerNotFound: %v", err)
+	}
+}
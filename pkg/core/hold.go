@@ -0,0 +1,253 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const KindCapture = "capture"
+
+const HoldAuthorized = "authorized"
+const HoldCaptured = "captured"
+const HoldVoided = "voided"
+const HoldExpired = "expired"
+
+var ErrHoldNotFound = errors.New("hold not found")
+var ErrHoldExpired = errors.New("hold is expired")
+var ErrHoldClosed = errors.New("hold is already captured or voided")
+
+// HoldTTL is how long an authorization reserves the money.
+var HoldTTL = 7 * 24 * time.Hour
+
+// Hold reserves part of a card balance. Until it is captured, voided or
+// expires, that part can't be spent.
+type Hold struct {
+	Id            int64
+	PAN           int64
+	Amount        int
+	Status        string
+	Captured      int
+	TransactionId int64
+	ExpiresAt     time.Time
+	Time          time.Time
+	FinishedAt    time.Time
+}
+
+// Authorize places a hold of amount on the card for HoldTTL.
+func Authorize(pan int64, amount int, db *sql.DB) (holdId int64, err error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	record := auditRecord{actor: "card " + MaskPAN(pan), action: AuditHoldAuthorize,
+		params: map[string]string{"amount": fmt.Sprint(amount)}}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		cardId, err := cardIdByPAN(ctx, q, pan)
+		if err != nil {
+			return err
+		}
+		err = lockActiveCards(ctx, q, cardId)
+		if err != nil {
+			return err
+		}
+		holdId, err = placeHold(ctx, q, cardId, amount, HoldTTL)
+		if err != nil {
+			return err
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return 0, auditFailure(db, record, err)
+	}
+	return holdId, nil
+}
+
+// Capture takes amount of the hold from the card and releases the rest.
+// Zero amount captures the whole hold.
+func Capture(holdId int64, amount int, db *sql.DB) (transactionId int64, err error) {
+	if amount < 0 {
+		return 0, ErrInvalidAmount
+	}
+	record := holdRecord(holdId, AuditHoldCapture)
+	record.params["amount"] = fmt.Sprint(amount)
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		hold, cardId, err := takeHold(ctx, q, holdId, amount, false)
+		if err != nil {
+			return err
+		}
+		transactionId, err = recordTransaction(ctx, q, ledgerEntry{kind: KindCapture, fromCardId: cardId, amount: hold.Captured})
+		if err != nil {
+			return err
+		}
+		err = linkHold(ctx, q, holdId, transactionId)
+		if err != nil {
+			return err
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return 0, auditFailure(db, record, err)
+	}
+	return transactionId, nil
+}
+
+// Void releases the whole hold.
+func Void(holdId int64, db *sql.DB) error {
+	record := holdRecord(holdId, AuditHoldVoid)
+	err := runTx(db, func(ctx context.Context, q queryer) error {
+		err := releaseHold(ctx, q, holdId)
+		if err != nil {
+			return err
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return auditFailure(db, record, err)
+	}
+	return nil
+}
+
+// holdRecord audits an action on the hold, which is all its caller knows.
+func holdRecord(holdId int64, action string) auditRecord {
+	return auditRecord{actor: fmt.Sprintf("hold %d", holdId), action: action,
+		params: map[string]string{"hold": fmt.Sprint(holdId)}}
+}
+
+func HoldGet(holdId int64, db *sql.DB) (hold Hold, err error) {
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		hold, _, err = holdById(ctx, q, holdId)
+		return err
+	})
+	if err != nil {
+		return Hold{}, err
+	}
+	return hold, nil
+}
+
+// placeHold reserves amount of the available balance of a locked card.
+func placeHold(ctx context.Context, q queryer, cardId int64, amount int, ttl time.Duration) (holdId int64, err error) {
+	err = checkAvailable(ctx, q, cardId, amount)
+	if err != nil {
+		return 0, err
+	}
+	at := now()
+	result, err := q.ExecContext(ctx, insertHold,
+		sql.Named("idCard", cardId),
+		sql.Named("amount", amount),
+		sql.Named("expiresAt", at.Add(ttl).Unix()),
+		sql.Named("createdAt", at.Unix()),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("can't hold %d on card %d: %w", amount, cardId, err)
+	}
+	holdId, err = result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("can't hold %d on card %d: %w", amount, cardId, err)
+	}
+	return holdId, nil
+}
+
+// takeHold debits amount of an authorized hold, the whole hold when zero,
+// from its card. Expired holds are refused unless late is set: a payment a
+// provider confirmed has to be taken even when it is confirmed late.
+func takeHold(ctx context.Context, q queryer, holdId int64, amount int, late bool) (hold Hold, cardId int64, err error) {
+	hold, cardId, err = holdById(ctx, q, holdId)
+	if err != nil {
+		return Hold{}, 0, err
+	}
+	if hold.Status == HoldExpired && !late {
+		return Hold{}, 0, fmt.Errorf("hold %d: %w", holdId, ErrHoldExpired)
+	}
+	if hold.Status != HoldAuthorized && hold.Status != HoldExpired {
+		return Hold{}, 0, fmt.Errorf("hold %d is %s: %w", holdId, hold.Status, ErrHoldClosed)
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		return Hold{}, 0, fmt.Errorf("hold %d is of %d, not %d: %w", holdId, hold.Amount, amount, ErrInvalidAmount)
+	}
+	err = lockCards(ctx, q, cardId)
+	if err != nil {
+		return Hold{}, 0, err
+	}
+	err = closeHoldAs(ctx, q, holdId, HoldCaptured, amount)
+	if err != nil {
+		return Hold{}, 0, err
+	}
+	err = addToCard(ctx, q, cardId, -amount)
+	if err != nil {
+		return Hold{}, 0, err
+	}
+	hold.Status, hold.Captured = HoldCaptured, amount
+	return hold, cardId, nil
+}
+
+func releaseHold(ctx context.Context, q queryer, holdId int64) error {
+	hold, _, err := holdById(ctx, q, holdId)
+	if err != nil {
+		return err
+	}
+	if hold.Status != HoldAuthorized && hold.Status != HoldExpired {
+		return fmt.Errorf("hold %d is %s: %w", holdId, hold.Status, ErrHoldClosed)
+	}
+	return closeHoldAs(ctx, q, holdId, HoldVoided, 0)
+}
+
+// closeHoldAs moves an authorized hold to status. Of two closings racing
+// for the same hold, only one succeeds.
+func closeHoldAs(ctx context.Context, q queryer, holdId int64, status string, captured int) error {
+	result, err := q.ExecContext(ctx, closeHold,
+		sql.Named("status", status),
+		sql.Named("captured", captured),
+		sql.Named("finishedAt", now().Unix()),
+		sql.Named("id", holdId),
+	)
+	if err != nil {
+		return fmt.Errorf("can't close hold %d: %w", holdId, err)
+	}
+	closed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("can't close hold %d: %w", holdId, err)
+	}
+	if closed == 0 {
+		return fmt.Errorf("hold %d: %w", holdId, ErrHoldClosed)
+	}
+	return nil
+}
+
+func linkHold(ctx context.Context, q queryer, holdId, transactionId int64) error {
+	_, err := q.ExecContext(ctx, setHoldTransaction,
+		sql.Named("transactionId", transactionId),
+		sql.Named("id", holdId),
+	)
+	if err != nil {
+		return fmt.Errorf("can't link hold %d to transaction %d: %w", holdId, transactionId, err)
+	}
+	return nil
+}
+
+// holdById reads a hold. An authorized hold past its expiry reads as
+// expired; nothing needs to run for it to stop reserving money.
+func holdById(ctx context.Context, q queryer, holdId int64) (hold Hold, cardId int64, err error) {
+	var expiresAt, createdAt, finishedAt int64
+	err = q.QueryRowContext(ctx, getHold, holdId).Scan(&hold.Id, &cardId, &hold.PAN, &hold.Amount, &hold.Status,
+		&hold.Captured, &hold.TransactionId, &expiresAt, &createdAt, &finishedAt)
+	if err == sql.ErrNoRows {
+		return Hold{}, 0, fmt.Errorf("hold %d: %w", holdId, ErrHoldNotFound)
+	}
+	if err != nil {
+		return Hold{}, 0, fmt.Errorf("can't get hold %d: %w", holdId, err)
+	}
+	hold.ExpiresAt = time.Unix(expiresAt, 0)
+	hold.Time = time.Unix(createdAt, 0)
+	if finishedAt != 0 {
+		hold.FinishedAt = time.Unix(finishedAt, 0)
+	}
+	if hold.Status == HoldAuthorized && !now().Before(hold.ExpiresAt) {
+		hold.Status = HoldExpired
+	}
+	return hold, cardId, nil
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAuthorize_Capture(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(1, 10))()
+	holdId, err := Authorize(2021600000000000, 400000, db)
+	if err != nil {
+		t.Fatalf("can't authorize: %v", err)
+	}
+	balance, available, err := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if err != nil || balance != 1000000 || available != 600000 {
+		t.Errorf("hold just reduce only the available balance: %d %d %v", balance, available, err)
+	}
+	_, err = Authorize(2021600000000000, 700000, db)
+	var insufficient *InsufficientFundsError
+	if !errors.As(err, &insufficient) || insufficient.Balance != 600000 {
+		t.Errorf("held money just not be authorized again: %v", err)
+	}
+	_, err = ServicesPayMoreCard("internet", "100200", 2021600000000000, 700000, db)
+	if !errors.As(err, &insufficient) {
+		t.Errorf("held money just not be spent: %v", err)
+	}
+	_, err = Capture(holdId, 500000, db)
+	if !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("capture over the hold just be ErrInvalidAmount: %v", err)
+	}
+	transactionId, err := Capture(holdId, 250000, db)
+	if err != nil {
+		t.Fatalf("can't capture: %v", err)
+	}
+	balance, available, _ = GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 750000 || available != 750000 {
+		t.Errorf("partial capture just take 250000 and release the rest: %d %d", balance, available)
+	}
+	hold, err := HoldGet(holdId, db)
+	if err != nil {
+		t.Fatalf("can't get hold: %v", err)
+	}
+	if hold.Status != HoldCaptured || hold.Captured != 250000 || hold.TransactionId != transactionId ||
+		hold.PAN != 2021600000000000 || !hold.FinishedAt.Equal(at(1, 10)) {
+		t.Errorf("hold just be captured for 250000: %+v", hold)
+	}
+	_, err = Capture(holdId, 0, db)
+	if !errors.Is(err, ErrHoldClosed) {
+		t.Errorf("second capture just be ErrHoldClosed: %v", err)
+	}
+	statement, err := GetStatement(2021600000000000, at(1, 0), at(2, 0), db)
+	if err != nil {
+		t.Fatalf("can't get statement: %v", err)
+	}
+	if len(statement.Entries) != 1 || statement.Entries[0].Kind != KindCapture || statement.Entries[0].Amount != -250000 {
+		t.Errorf("statement just show the captured amount: %+v", statement.Entries)
+	}
+}
+
+func TestVoid(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	holdId, err := Authorize(2021600000000000, 1000000, db)
+	if err != nil {
+		t.Fatalf("can't authorize: %v", err)
+	}
+	err = Void(holdId, db)
+	if err != nil {
+		t.Fatalf("can't void: %v", err)
+	}
+	balance, available, _ := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000000 || available != 1000000 {
+		t.Errorf("voided hold just release the money: %d %d", balance, available)
+	}
+	if err = Void(holdId, db); !errors.Is(err, ErrHoldClosed) {
+		t.Errorf("second void just be ErrHoldClosed: %v", err)
+	}
+	if _, err = Capture(holdId, 0, db); !errors.Is(err, ErrHoldClosed) {
+		t.Errorf("capture of voided hold just be ErrHoldClosed: %v", err)
+	}
+	if err = Void(404, db); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("unknown hold just be ErrHoldNotFound: %v", err)
+	}
+}
+
+func TestAuthorize_Expiry(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	restore := setNow(at(1, 10))
+	defer restore()
+	holdId, err := Authorize(2021600000000000, 300000, db)
+	if err != nil {
+		t.Fatalf("can't authorize: %v", err)
+	}
+	setNow(at(1, 10).Add(HoldTTL))
+	_, available, _ := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if available != 1000000 {
+		t.Errorf("expired hold just release the money: %d", available)
+	}
+	hold, err := HoldGet(holdId, db)
+	if err != nil || hold.Status != HoldExpired {
+		t.Errorf("hold just be expired: %+v %v", hold, err)
+	}
+	if _, err = Capture(holdId, 0, db); !errors.Is(err, ErrHoldExpired) {
+		t.Errorf("capture of expired hold just be ErrHoldExpired: %v", err)
+	}
+	if _, err = Authorize(2021600000000000, 0, db); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("zero hold just be ErrInvalidAmount: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const KindTransfer = "transfer"
+const KindService = "service"
+
+var now = time.Now
+
+// ledgerEntry is one row of the transactions table. Zero card ids, an
+// empty service, an empty reference and a zero ATM id are stored as NULL;
+// a zero time is now.
+type ledgerEntry struct {
+	kind       string
+	fromCardId int64
+	toCardId   int64
+	service    string
+	reference  string
+	amount     int
+	atmId      int64
+	at         time.Time
+}
+
+func recordTransaction(ctx context.Context, q queryer, entry ledgerEntry) (id int64, err error) {
+	if entry.at.IsZero() {
+		entry.at = now()
+	}
+	result, err := q.ExecContext(ctx, insertTransaction,
+		sql.Named("kind", entry.kind),
+		sql.Named("fromCardId", entry.fromCardId),
+		sql.Named("toCardId", entry.toCardId),
+		sql.Named("service", entry.service),
+		sql.Named("reference", entry.reference),
+		sql.Named("amount", entry.amount),
+		sql.Named("atmId", entry.atmId),
+		sql.Named("createdAt", entry.at.Unix()),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("can't record %s: %w", entry.kind, err)
+	}
+	id, err = result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("can't record %s: %w", entry.kind, err)
+	}
+	return id, nil
+}
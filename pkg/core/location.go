@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var ErrInvalidLocation = errors.New("location is not valid")
+
+// earthRadius is the mean radius of the Earth in meters.
+const earthRadius = 6371000.0
+
+// NearestATMs defaults.
+const DefaultATMsLimit = 10
+const DefaultATMsRadius = 5000.0
+
+// Location is a point in degrees.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func (l Location) Validate() error {
+	if math.IsNaN(l.Latitude) || math.IsNaN(l.Longitude) ||
+		l.Latitude < -90 || l.Latitude > 90 || l.Longitude < -180 || l.Longitude > 180 {
+		return fmt.Errorf("%v, %v: %w", l.Latitude, l.Longitude, ErrInvalidLocation)
+	}
+	return nil
+}
+
+// NearbyATM is an ATM and its distance in meters.
+type NearbyATM struct {
+	Atm
+	Distance float64
+}
+
+// Distance is the haversine distance in meters between two points.
+func Distance(from, to Location) float64 {
+	lat1, lat2 := radians(from.Latitude), radians(to.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(to.Longitude - from.Longitude)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// SetATMLocation places the ATM on the map.
+func SetATMLocation(atmId int64, location Location, db *sql.DB) error {
+	err := location.Validate()
+	if err != nil {
+		return err
+	}
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkATM(ctx, q, atmId)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, setAtmLocation,
+			sql.Named("atmId", atmId),
+			sql.Named("latitude", location.Latitude),
+			sql.Named("longitude", location.Longitude),
+		)
+		if err != nil {
+			return fmt.Errorf("can't set location of atm %d: %w", atmId, err)
+		}
+		return nil
+	})
+}
+
+// NearestATMs returns at most limit ATMs that match the filter within
+// radius meters of the point, nearest first. Zero limit and radius take the
+// defaults. The database only reads the ATMs inside the bounding box of
+// the circle; the exact distance sorts them out.
+func NearestATMs(lat, lon float64, limit int, radius float64, filter ATMFilter, db *sql.DB) (atms []NearbyATM, err error) {
+	center := Location{Latitude: lat, Longitude: lon}
+	err = center.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultATMsLimit
+	}
+	if radius <= 0 {
+		radius = DefaultATMsRadius
+	}
+	minLat, maxLat, minLon, maxLon := boundingBox(center, radius)
+	rows, err := db.Query(getAtmsInBox, append(filterArgs(filter),
+		sql.Named("minLat", minLat),
+		sql.Named("maxLat", maxLat),
+		sql.Named("minLon", minLon),
+		sql.Named("maxLon", maxLon),
+	)...)
+	if err != nil {
+		return nil, fmt.Errorf("can't get atms near %v, %v: %w", lat, lon, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close atms near %v, %v: %w", lat, lon, cerr)
+		}
+	}()
+	at := now()
+	for rows.Next() {
+		atm, err := scanAtm(rows)
+		if err != nil {
+			return nil, err
+		}
+		distance := Distance(center, *atm.Location)
+		if distance <= radius && atm.matches(filter, at) {
+			atms = append(atms, NearbyATM{Atm: atm, Distance: distance})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get atms near %v, %v: %w", lat, lon, err)
+	}
+	sort.Slice(atms, func(i, j int) bool {
+		if atms[i].Distance != atms[j].Distance {
+			return atms[i].Distance < atms[j].Distance
+		}
+		return atms[i].Id < atms[j].Id
+	})
+	if len(atms) > limit {
+		atms = atms[:limit]
+	}
+	return atms, nil
+}
+
+// boundingBox returns the box around the circle of radius meters. Near
+// the poles it spans every longitude; across the antimeridian minLon is
+// greater than maxLon.
+func boundingBox(center Location, radius float64) (minLat, maxLat, minLon, maxLon float64) {
+	dLat := radius / earthRadius * 180 / math.Pi
+	minLat, maxLat = center.Latitude-dLat, center.Latitude+dLat
+	if minLat <= -90 || maxLat >= 90 {
+		return math.Max(minLat, -90), math.Min(maxLat, 90), -180, 180
+	}
+	dLon := dLat / math.Cos(radians(center.Latitude))
+	if dLon >= 180 {
+		return minLat, maxLat, -180, 180
+	}
+	minLon, maxLon = center.Longitude-dLon, center.Longitude+dLon
+	if minLon < -180 {
+		minLon += 360
+	}
+	if maxLon > 180 {
+		maxLon -= 360
+	}
+	return minLat, maxLat, minLon, maxLon
+}
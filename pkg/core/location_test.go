@@ -0,0 +1,122 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		name     string
+		from, to Location
+		meters   float64
+	}{
+		{"same point", Location{38.5598, 68.7870}, Location{38.5598, 68.7870}, 0},
+		{"paris to london", Location{48.8566, 2.3522}, Location{51.5074, -0.1278}, 343556},
+		{"dushanbe to khujand", Location{38.5598, 68.7870}, Location{40.2833, 69.6222}, 205000},
+		{"quarter of the equator", Location{0, 0}, Location{0, 90}, earthRadius * math.Pi / 2},
+		{"across the antimeridian", Location{0, 179.5}, Location{0, -179.5}, earthRadius * math.Pi / 180},
+		{"pole to pole", Location{90, 0}, Location{-90, 0}, earthRadius * math.Pi},
+	}
+	for _, c := range cases {
+		distance := Distance(c.from, c.to)
+		if math.Abs(distance-c.meters) > c.meters/200+1 {
+			t.Errorf("%s just be %.0f m: %.0f", c.name, c.meters, distance)
+		}
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	minLat, maxLat, minLon, maxLon := boundingBox(Location{0, 0}, earthRadius*math.Pi/180)
+	if math.Abs(minLat+1) > 1e-9 || math.Abs(maxLat-1) > 1e-9 || math.Abs(minLon+1) > 1e-9 || math.Abs(maxLon-1) > 1e-9 {
+		t.Errorf("one degree around the origin just be ±1: %v %v %v %v", minLat, maxLat, minLon, maxLon)
+	}
+	_, _, minLon, maxLon = boundingBox(Location{0, 179.9}, 50000)
+	if minLon < maxLon || minLon > 179.9 || maxLon > -179 {
+		t.Errorf("box across the antimeridian just wrap: %v %v", minLon, maxLon)
+	}
+	_, maxLat, minLon, maxLon = boundingBox(Location{89.99, 10}, 50000)
+	if maxLat != 90 || minLon != -180 || maxLon != 180 {
+		t.Errorf("box over the pole just span every longitude: %v %v %v", maxLat, minLon, maxLon)
+	}
+}
+
+func placeAtm(t *testing.T, db *sql.DB, id int64, lat, lon float64) {
+	_, err := db.Exec(`INSERT INTO atms VALUES (?, 'Dushanbe', 'Somoni', 'Foteh51')`, id)
+	if err != nil {
+		t.Fatalf("can't insert atm %d: %v", id, err)
+	}
+	err = SetATMLocation(id, Location{Latitude: lat, Longitude: lon}, db)
+	if err != nil {
+		t.Fatalf("can't place atm %d: %v", id, err)
+	}
+}
+
+func TestNearestATMs(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	center := Location{38.5598, 68.7870}
+	placeAtm(t, db, 2, 38.5763, 68.7795)
+	placeAtm(t, db, 3, 38.5600, 68.7880)
+	placeAtm(t, db, 4, 38.5300, 68.8000)
+	placeAtm(t, db, 5, 40.2833, 69.6222)
+	atms, err := NearestATMs(center.Latitude, center.Longitude, 0, 5000, ATMFilter{}, db)
+	if err != nil {
+		t.Fatalf("can't get nearest atms: %v", err)
+	}
+	var ids []int64
+	for _, atm := range atms {
+		ids = append(ids, atm.Id)
+		if math.Abs(atm.Distance-Distance(center, *atm.Location)) > 1e-6 {
+			t.Errorf("atm %d just carry its distance: %v", atm.Id, atm.Distance)
+		}
+	}
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 2 || ids[2] != 4 {
+		t.Errorf("atms just be 3, 2 and 4, nearest first: %v", ids)
+	}
+	atms, err = NearestATMs(center.Latitude, center.Longitude, 1, 300000, ATMFilter{}, db)
+	if err != nil || len(atms) != 1 || atms[0].Id != 3 {
+		t.Errorf("limit just keep the nearest atm: %+v %v", atms, err)
+	}
+	atms, err = NearestATMs(center.Latitude, center.Longitude, 10, 300000, ATMFilter{}, db)
+	if err != nil || len(atms) != 4 || atms[3].Id != 5 {
+		t.Errorf("wide radius just reach Khujand: %+v %v", atms, err)
+	}
+	all, err := ATMsGet(db)
+	if err != nil || len(all) != 5 || all[0].Location != nil || all[1].Location == nil {
+		t.Errorf("atms just be listed with their locations: %+v %v", all, err)
+	}
+	_, err = NearestATMs(91, 0, 0, 0, ATMFilter{}, db)
+	if !errors.Is(err, ErrInvalidLocation) {
+		t.Errorf("latitude over 90 just be ErrInvalidLocation: %v", err)
+	}
+	err = SetATMLocation(404, center, db)
+	if !errors.Is(err, ErrATMNotFound) {
+		t.Errorf("unknown atm just be ErrATMNotFound: %v", err)
+	}
+}
+
+func TestNearestATMs_Antimeridian(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	placeAtm(t, db, 2, -16.5, 179.95)
+	placeAtm(t, db, 3, -16.5, -179.95)
+	placeAtm(t, db, 4, -16.5, 0)
+	atms, err := NearestATMs(-16.5, 179.99, 5, 20000, ATMFilter{}, db)
+	if err != nil {
+		t.Fatalf("can't get nearest atms: %v", err)
+	}
+	if len(atms) != 2 || atms[0].Id != 2 || atms[1].Id != 3 {
+		t.Errorf("atms on both sides of the antimeridian just be found: %+v", atms)
+	}
+}
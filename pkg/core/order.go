@@ -0,0 +1,322 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const OrderPending = "pending"
+const OrderDone = "done"
+const OrderFailed = "failed"
+const OrderCancelled = "cancelled"
+
+const defaultOrderLease = time.Minute
+
+var ErrOrderNotFound = errors.New("transfer order not found")
+var ErrOrderNotPending = errors.New("transfer order is not pending")
+var ErrInvalidDate = errors.New("date must be in the future")
+
+// errLeaseLost aborts an execution whose order was cancelled or taken over
+// by another worker once the lease ran out.
+var errLeaseLost = errors.New("transfer order lease is lost")
+
+type TransferOrder struct {
+	Id            int64
+	FromPAN       int64
+	ToPAN         int64
+	Amount        int
+	ExecuteAt     time.Time
+	Status        string
+	TransactionId int64
+	Error         string
+	FinishedAt    time.Time
+}
+
+// ScheduleTransfer stores a one-off MoreCard transfer to be made at
+// executeAt by an OrderWorker. Until then the client may cancel it.
+func ScheduleTransfer(clientId int, fromPAN, toPAN int64, amount int, executeAt time.Time, db *sql.DB) (id int64, err error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if !executeAt.After(now()) {
+		return 0, ErrInvalidDate
+	}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		var fromCardId int64
+		err := q.QueryRowContext(ctx, getCardIdByClientPAN, fromPAN, clientId).Scan(&fromCardId)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("card %d of client %d: %w", fromPAN, clientId, ErrCardNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("can't get card %d: %w", fromPAN, err)
+		}
+		toCardId, err := cardIdByPAN(ctx, q, toPAN)
+		if err != nil {
+			return err
+		}
+		result, err := q.ExecContext(ctx, insertTransferOrder,
+			sql.Named("fromCardId", fromCardId),
+			sql.Named("toCardId", toCardId),
+			sql.Named("amount", amount),
+			sql.Named("executeAt", executeAt.Unix()),
+			sql.Named("createdAt", now().Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("can't create transfer order: %w", err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("can't create transfer order: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func TransferOrdersGet(clientId int, db *sql.DB) (orders []TransferOrder, err error) {
+	rows, err := db.Query(getTransferOrdersByClient, clientId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get transfer orders of client %d: %w", clientId, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close transfer orders: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var order TransferOrder
+		var executeAt, finishedAt int64
+		err = rows.Scan(&order.Id, &order.FromPAN, &order.ToPAN, &order.Amount, &executeAt,
+			&order.Status, &order.TransactionId, &order.Error, &finishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan transfer order: %w", err)
+		}
+		order.ExecuteAt = time.Unix(executeAt, 0)
+		if finishedAt != 0 {
+			order.FinishedAt = time.Unix(finishedAt, 0)
+		}
+		orders = append(orders, order)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get transfer orders of client %d: %w", clientId, err)
+	}
+	return orders, nil
+}
+
+// CancelTransferOrder cancels a pending order. An order being executed is
+// cancelled too if the cancellation commits first.
+func CancelTransferOrder(orderId int64, clientId int, db *sql.DB) error {
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		var status string
+		err := q.QueryRowContext(ctx, getTransferOrderStatus, orderId, clientId).Scan(&status)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("transfer order %d of client %d: %w", orderId, clientId, ErrOrderNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("can't get transfer order %d: %w", orderId, err)
+		}
+		if status != OrderPending {
+			return fmt.Errorf("transfer order %d is %s: %w", orderId, status, ErrOrderNotPending)
+		}
+		_, err = q.ExecContext(ctx, cancelTransferOrder,
+			sql.Named("finishedAt", now().Unix()),
+			sql.Named("id", orderId),
+		)
+		if err != nil {
+			return fmt.Errorf("can't cancel transfer order %d: %w", orderId, err)
+		}
+		return nil
+	})
+}
+
+// OrderWorker executes due transfer orders. Several workers may share a
+// database: each takes a lease on an order before executing it, and the
+// transfer commits only while the lease is still its own, so an order is
+// executed once even if a slow worker's lease runs out.
+type OrderWorker struct {
+	db    *sql.DB
+	owner string
+	Now   func() time.Time
+	Lease time.Duration
+	// OnError receives the errors Run can't return without stopping.
+	OnError func(error)
+}
+
+// NewOrderWorker makes a worker; owner must be unique among the workers.
+func NewOrderWorker(db *sql.DB, owner string) *OrderWorker {
+	return &OrderWorker{db: db, owner: owner, Now: time.Now, Lease: defaultOrderLease}
+}
+
+type dueOrder struct {
+	id         int64
+	fromCardId int64
+	toCardId   int64
+	amount     int
+}
+
+// Run calls RunDue every interval until ctx is done.
+func (w *OrderWorker) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := w.RunDue()
+		if err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue executes orders due by Now until none is left and returns how
+// many it finished, done or failed.
+func (w *OrderWorker) RunDue() (finished int, err error) {
+	for {
+		at := w.Now()
+		order, ok, err := w.lease(at)
+		if errors.Is(err, errLeaseLost) {
+			// another worker took this order first, others may still be due
+			continue
+		}
+		if err != nil {
+			return finished, err
+		}
+		if !ok {
+			return finished, nil
+		}
+		err = w.execute(order, at)
+		if errors.Is(err, errLeaseLost) {
+			continue
+		}
+		if err != nil {
+			return finished, err
+		}
+		finished++
+	}
+}
+
+func (w *OrderWorker) lease(at time.Time) (order dueOrder, ok bool, err error) {
+	err = runTx(w.db, func(ctx context.Context, q queryer) error {
+		err := q.QueryRowContext(ctx, getDueTransferOrder, sql.Named("now", at.Unix())).Scan(
+			&order.id, &order.fromCardId, &order.toCardId, &order.amount)
+		if err == sql.ErrNoRows {
+			ok = false
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("can't get due transfer order: %w", err)
+		}
+		result, err := q.ExecContext(ctx, leaseTransferOrder,
+			sql.Named("owner", w.owner),
+			sql.Named("until", at.Add(w.Lease).Unix()),
+			sql.Named("id", order.id),
+			sql.Named("now", at.Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("can't lease transfer order %d: %w", order.id, err)
+		}
+		leased, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("can't lease transfer order %d: %w", order.id, err)
+		}
+		if leased == 0 {
+			return fmt.Errorf("transfer order %d: %w", order.id, errLeaseLost)
+		}
+		ok = true
+		return nil
+	})
+	return order, ok, err
+}
+
+// execute screens and makes the transfer and marks the order done in one
+// transaction. If the transfer is refused, the order is marked failed with
+// the reason; a held or denied one is recorded as a screening first, and a
+// reviewer may still release it.
+// Any other error releases the lease and leaves the order pending, so a
+// busy or unreachable database doesn't fail it for good.
+func (w *OrderWorker) execute(order dueOrder, at time.Time) error {
+	transferErr := runTx(w.db, func(ctx context.Context, q queryer) error {
+		err := screenTransfer(ctx, q, order.fromCardId, order.toCardId, order.amount)
+		if err != nil {
+			return err
+		}
+		transactionId, err := moveFunds(ctx, q, order.fromCardId, order.toCardId, order.amount)
+		if err != nil {
+			return err
+		}
+		err = appendAudit(ctx, q, order.record(), AuditOK)
+		if err != nil {
+			return err
+		}
+		return w.finish(ctx, q, order.id, at, OrderDone, transactionId, nil)
+	})
+	if transferErr == nil || errors.Is(transferErr, errLeaseLost) {
+		return transferErr
+	}
+	var fraudErr *FraudError
+	if errors.As(transferErr, &fraudErr) {
+		transferErr = recordScreening(w.db, fraudErr)
+	}
+	if !orderRefused(transferErr) {
+		_, err := w.db.Exec(releaseTransferOrder, sql.Named("id", order.id), sql.Named("owner", w.owner))
+		if err != nil {
+			return fmt.Errorf("%w (can't release transfer order %d: %v)", transferErr, order.id, err)
+		}
+		return transferErr
+	}
+	return runTx(w.db, func(ctx context.Context, q queryer) error {
+		err := appendAudit(ctx, q, order.record(), transferErr.Error())
+		if err != nil {
+			return err
+		}
+		return w.finish(ctx, q, order.id, at, OrderFailed, 0, transferErr)
+	})
+}
+
+// record is how the transfer of the order is audited.
+func (order dueOrder) record() auditRecord {
+	return auditRecord{actor: fmt.Sprintf("order %d", order.id), action: AuditTransfer,
+		params: map[string]string{"order": fmt.Sprint(order.id), "amount": fmt.Sprint(order.amount)}}
+}
+
+// orderRefused tells the errors that executing the order again won't fix.
+func orderRefused(err error) bool {
+	var fraudErr *FraudError
+	return errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrCardBlocked) ||
+		errors.Is(err, ErrCardNotFound) || errors.As(err, &fraudErr)
+}
+
+func (w *OrderWorker) finish(ctx context.Context, q queryer, orderId int64, at time.Time, status string, transactionId int64, orderErr error) error {
+	message := ""
+	if orderErr != nil {
+		message = orderErr.Error()
+	}
+	result, err := q.ExecContext(ctx, finishTransferOrder,
+		sql.Named("status", status),
+		sql.Named("transactionId", transactionId),
+		sql.Named("error", message),
+		sql.Named("finishedAt", at.Unix()),
+		sql.Named("id", orderId),
+		sql.Named("owner", w.owner),
+	)
+	if err != nil {
+		return fmt.Errorf("can't finish transfer order %d: %w", orderId, err)
+	}
+	finished, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("can't finish transfer order %d: %w", orderId, err)
+	}
+	if finished == 0 {
+		return fmt.Errorf("transfer order %d: %w", orderId, errLeaseLost)
+	}
+	return nil
+}
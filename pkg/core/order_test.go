@@ -0,0 +1,287 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOrderWorker_ExecutesDueOrders(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(1, 0))()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 500, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	_, err = ScheduleTransfer(1, 2021600000000000, 2021600000000001, 100, at(1, 0), db)
+	if !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("order for now just be ErrInvalidDate: %v", err)
+	}
+	_, err = ScheduleTransfer(2, 2021600000000000, 2021600000000001, 100, at(2, 0), db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("order from someone else's card just be ErrCardNotFound: %v", err)
+	}
+	rentId, err := ScheduleTransfer(1, 2021600000000000, 2021600000000001, 300, at(2, 0), db)
+	if err != nil {
+		t.Fatalf("can't schedule transfer: %v", err)
+	}
+	_, err = ScheduleTransfer(2, 2021600000000001, 2021600000000000, 5000, at(2, 0), db)
+	if err != nil {
+		t.Fatalf("can't schedule transfer: %v", err)
+	}
+	cancelledId, err := ScheduleTransfer(1, 2021600000000000, 2021600000000001, 100, at(2, 0), db)
+	if err != nil {
+		t.Fatalf("can't schedule transfer: %v", err)
+	}
+	err = CancelTransferOrder(cancelledId, 2, db)
+	if !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("cancel of someone else's order just be ErrOrderNotFound: %v", err)
+	}
+	err = CancelTransferOrder(cancelledId, 1, db)
+	if err != nil {
+		t.Fatalf("can't cancel order: %v", err)
+	}
+
+	var clock time.Time
+	worker := NewOrderWorker(db, "test")
+	worker.Now = func() time.Time { return clock }
+	clock = at(1, 23)
+	finished, err := worker.RunDue()
+	if err != nil || finished != 0 {
+		t.Errorf("orders before due just not run: %d, %v", finished, err)
+	}
+	clock = at(2, 0)
+	finished, err = worker.RunDue()
+	if err != nil || finished != 2 {
+		t.Errorf("due orders just be finished: %d, %v", finished, err)
+	}
+	err = CancelTransferOrder(rentId, 1, db)
+	if !errors.Is(err, ErrOrderNotPending) {
+		t.Errorf("cancel of done order just be ErrOrderNotPending: %v", err)
+	}
+
+	orders, err := TransferOrdersGet(1, db)
+	if err != nil {
+		t.Fatalf("can't get orders: %v", err)
+	}
+	if len(orders) != 2 || orders[0].Status != OrderDone || orders[0].TransactionId == 0 ||
+		orders[1].Status != OrderCancelled || !orders[0].FinishedAt.Equal(at(2, 0)) {
+		t.Errorf("orders just be done and cancelled: %+v", orders)
+	}
+	orders, err = TransferOrdersGet(2, db)
+	if err != nil {
+		t.Fatalf("can't get orders: %v", err)
+	}
+	if len(orders) != 1 || orders[0].Status != OrderFailed || orders[0].Error == "" {
+		t.Errorf("overdraft order just be failed: %+v", orders)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000001, db)
+	if balance != 800 {
+		t.Errorf("receiver balance just be 800: %d", balance)
+	}
+	entries, err := AuditLog(AuditFilter{Actor: fmt.Sprintf("order %d", rentId)}, db)
+	if err != nil || len(entries) != 1 || entries[0].Action != AuditTransfer || entries[0].Result != AuditOK {
+		t.Errorf("executed order just be audited: %+v %v", entries, err)
+	}
+	entries, err = AuditLog(AuditFilter{Action: AuditTransfer}, db)
+	if err != nil || len(entries) != 2 || entries[1].Result != orders[0].Error {
+		t.Errorf("failed order just be audited with its error: %+v %v", entries, err)
+	}
+}
+
+func TestOrderWorker_ExpiredLease(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(1, 0))()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 500, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	_, err = ScheduleTransfer(1, 2021600000000000, 2021600000000001, 300, at(2, 0), db)
+	if err != nil {
+		t.Fatalf("can't schedule transfer: %v", err)
+	}
+	slow := NewOrderWorker(db, "slow")
+	fast := NewOrderWorker(db, "fast")
+	order, ok, err := slow.lease(at(2, 0))
+	if err != nil || !ok {
+		t.Fatalf("can't lease order: %v", err)
+	}
+	_, ok, err = fast.lease(at(2, 0).Add(time.Second))
+	if err != nil || ok {
+		t.Errorf("leased order just not be leased again: %v", err)
+	}
+	fast.Now = func() time.Time { return at(2, 0).Add(2 * slow.Lease) }
+	finished, err := fast.RunDue()
+	if err != nil || finished != 1 {
+		t.Errorf("order with expired lease just be taken over: %d, %v", finished, err)
+	}
+	err = slow.execute(order, at(2, 0))
+	if !errors.Is(err, errLeaseLost) {
+		t.Errorf("execution after lost lease just be errLeaseLost: %v", err)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000001, db)
+	if balance != 800 {
+		t.Errorf("order just be executed once, balance 800: %d", balance)
+	}
+}
+
+func TestOrderWorker_ScreensOrders(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer func(rules []FraudRule) { FraudRules = rules }(FraudRules)
+	FraudRules = DefaultFraudRules()
+	defer setNow(at(1, 0))()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 0, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	orderId, err := ScheduleTransfer(1, 2021600000000000, 2021600000000001, 600000, at(2, 12), db)
+	if err != nil {
+		t.Fatalf("can't schedule transfer: %v", err)
+	}
+	setNow(at(2, 12))
+	worker := NewOrderWorker(db, "test")
+	worker.Now = func() time.Time { return at(2, 12) }
+	finished, err := worker.RunDue()
+	if err != nil || finished != 1 {
+		t.Fatalf("held order just be finished: %d, %v", finished, err)
+	}
+	orders, err := TransferOrdersGet(1, db)
+	if err != nil || len(orders) != 1 || orders[0].Id != orderId || orders[0].Status != OrderFailed ||
+		!strings.Contains(orders[0].Error, ErrTransferHeld.Error()) {
+		t.Fatalf("big first order just fail as held: %+v %v", orders, err)
+	}
+	screenings, err := ScreeningsGet(ScreeningHeld, db)
+	if err != nil || len(screenings) != 1 || screenings[0].Amount != 600000 {
+		t.Fatalf("held order just be recorded as a screening: %+v %v", screenings, err)
+	}
+	entries, err := AuditLog(AuditFilter{Actor: fmt.Sprintf("order %d", orderId)}, db)
+	if err != nil || len(entries) != 1 || entries[0].Result != orders[0].Error {
+		t.Errorf("held order just be audited: %+v %v", entries, err)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000001, db)
+	if balance != 0 {
+		t.Errorf("held order just not move money: %d", balance)
+	}
+
+	transactionId, err := ReviewTransfer(screenings[0].Id, true, 1, db)
+	if err != nil || transactionId == 0 {
+		t.Fatalf("can't release held order: %v", err)
+	}
+	balance, _, _ = GetCurrentBalanceClientPAN(2021600000000001, db)
+	if balance != 600000 {
+		t.Errorf("released order just move money: %d", balance)
+	}
+	reviews, err := AuditLog(AuditFilter{Action: AuditTransferReview}, db)
+	if err != nil || len(reviews) != 1 || reviews[0].Params["transaction"] != fmt.Sprint(transactionId) {
+		t.Errorf("release just be audited: %+v %v", reviews, err)
+	}
+}
+
+func TestOrderWorker_TransientErrorKeepsOrderPending(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(1, 0))()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 500, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	_, err = ScheduleTransfer(1, 2021600000000000, 2021600000000001, 300, at(2, 0), db)
+	if err != nil {
+		t.Fatalf("can't schedule transfer: %v", err)
+	}
+	_, err = db.Exec(`ALTER TABLE transactions RENAME TO transactions_away;`)
+	if err != nil {
+		t.Fatalf("can't break the ledger: %v", err)
+	}
+	worker := NewOrderWorker(db, "test")
+	worker.Now = func() time.Time { return at(2, 0) }
+	finished, err := worker.RunDue()
+	if err == nil || finished != 0 {
+		t.Errorf("order just not be finished while the ledger is broken: %d, %v", finished, err)
+	}
+	orders, err := TransferOrdersGet(1, db)
+	if err != nil || len(orders) != 1 || orders[0].Status != OrderPending {
+		t.Fatalf("order just stay pending: %+v %v", orders, err)
+	}
+	_, err = db.Exec(`ALTER TABLE transactions_away RENAME TO transactions;`)
+	if err != nil {
+		t.Fatalf("can't mend the ledger: %v", err)
+	}
+	finished, err = worker.RunDue()
+	if err != nil || finished != 1 {
+		t.Errorf("pending order just be executed on the next run: %d, %v", finished, err)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000001, db)
+	if balance != 800 {
+		t.Errorf("receiver balance just be 800: %d", balance)
+	}
+}
+
+func TestOrderWorker_ConcurrentWorkers(t *testing.T) {
+	db, closeDb := openFileDb(t)
+	defer closeDb()
+	err := Init(db)
+	if err != nil {
+		t.Fatalf("can't init db: %v", err)
+	}
+	defer setNow(at(1, 0))()
+	_, err = db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 0, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	const orders = 50
+	for i := 0; i < orders; i++ {
+		_, err = ScheduleTransfer(1, 2021600000000000, 2021600000000001, 10, at(2, 0), db)
+		if err != nil {
+			t.Fatalf("can't schedule transfer: %v", err)
+		}
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < 4; i++ {
+		worker := NewOrderWorker(db, fmt.Sprintf("worker-%d", i))
+		worker.Now = func() time.Time { return at(2, 0) }
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			finished, err := worker.RunDue()
+			if err != nil {
+				t.Errorf("can't run orders: %v", err)
+			}
+			mu.Lock()
+			total += finished
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if total != orders {
+		t.Errorf("workers just finish %d orders: %d", orders, total)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000001, db)
+	if balance != orders*10 {
+		t.Errorf("every order just be executed once, balance %d: %d", orders*10, balance)
+	}
+}
@@ -0,0 +1,285 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ATM statuses. Only online ATMs serve clients.
+const ATMOnline = "online"
+const ATMOffline = "offline"
+const ATMOutOfService = "out-of-service"
+
+// ATM capabilities. Currencies the ATM handles are kept apart, as ISO 4217
+// codes.
+const CapabilityCashIn = "cash-in"
+const CapabilityCashOut = "cash-out"
+const CapabilityContactless = "contactless"
+
+const minutesPerDay = 24 * 60
+
+var ErrInvalidStatus = errors.New("atm status is not valid")
+var ErrInvalidHours = errors.New("opening hours are not valid")
+var ErrInvalidCapability = errors.New("atm capability is not valid")
+
+// Hours are the opening hours of one weekday, in minutes after midnight of
+// the ATM's timezone. Hours that close before they open run past midnight
+// into the next day; 0 to 1440 is the whole day.
+type Hours struct {
+	Weekday time.Weekday
+	Opens   int
+	Closes  int
+}
+
+func (h Hours) Validate() error {
+	if h.Weekday < time.Sunday || h.Weekday > time.Saturday || h.Opens < 0 || h.Opens >= minutesPerDay ||
+		h.Closes <= 0 || h.Closes > minutesPerDay || h.Opens == h.Closes {
+		return fmt.Errorf("%v %d-%d: %w", h.Weekday, h.Opens, h.Closes, ErrInvalidHours)
+	}
+	return nil
+}
+
+// ATMFilter narrows ATM searches. Zero fields match every ATM.
+type ATMFilter struct {
+	// OpenNow keeps online ATMs whose hours cover the current time.
+	OpenNow bool
+	// Capability is one of the Capability constants.
+	Capability string
+	// Currency is an ISO 4217 code.
+	Currency string
+}
+
+// OpenAt tells whether the ATM serves clients at t. Online ATMs without
+// hours are always open.
+func (atm Atm) OpenAt(t time.Time) bool {
+	if atm.Status != ATMOnline {
+		return false
+	}
+	if len(atm.Hours) == 0 {
+		return true
+	}
+	location, err := time.LoadLocation(atm.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := t.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	yesterday := (local.Weekday() + 6) % 7
+	for _, hours := range atm.Hours {
+		overnight := hours.Closes < hours.Opens
+		if hours.Weekday == local.Weekday() && minute >= hours.Opens && (overnight || minute < hours.Closes) {
+			return true
+		}
+		if hours.Weekday == yesterday && overnight && minute < hours.Closes {
+			return true
+		}
+	}
+	return false
+}
+
+func (atm Atm) matches(filter ATMFilter, t time.Time) bool {
+	return !filter.OpenNow || atm.OpenAt(t)
+}
+
+// SetATMStatus takes the ATM online, offline or out of service.
+func SetATMStatus(atmId int64, status string, managerId int, db *sql.DB) error {
+	switch status {
+	case ATMOnline, ATMOffline, ATMOutOfService:
+	default:
+		return fmt.Errorf("status %q: %w", status, ErrInvalidStatus)
+	}
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManagedATM(ctx, q, atmId, managerId)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, setAtmStatus, sql.Named("atmId", atmId), sql.Named("status", status))
+		if err != nil {
+			return fmt.Errorf("can't set status of atm %d: %w", atmId, err)
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogATM, atmId, CatalogSetStatus)
+	})
+}
+
+// SetATMHours replaces the opening hours of the ATM. Weekdays missing from
+// hours are closed; no hours at all open the ATM around the clock.
+func SetATMHours(atmId int64, timezone string, hours []Hours, managerId int, db *sql.DB) error {
+	_, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" || timezone == "Local" {
+		return fmt.Errorf("timezone %q: %w", timezone, ErrInvalidHours)
+	}
+	seen := map[time.Weekday]bool{}
+	for _, h := range hours {
+		err = h.Validate()
+		if err != nil {
+			return err
+		}
+		if seen[h.Weekday] {
+			return fmt.Errorf("%v is given twice: %w", h.Weekday, ErrInvalidHours)
+		}
+		seen[h.Weekday] = true
+	}
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManagedATM(ctx, q, atmId, managerId)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, setAtmTimezone, sql.Named("atmId", atmId), sql.Named("timezone", timezone))
+		if err != nil {
+			return fmt.Errorf("can't set timezone of atm %d: %w", atmId, err)
+		}
+		_, err = q.ExecContext(ctx, deleteAtmHours, atmId)
+		if err != nil {
+			return fmt.Errorf("can't clear hours of atm %d: %w", atmId, err)
+		}
+		for _, h := range hours {
+			_, err = q.ExecContext(ctx, insertAtmHours,
+				sql.Named("atmId", atmId),
+				sql.Named("weekday", int(h.Weekday)),
+				sql.Named("opens", h.Opens),
+				sql.Named("closes", h.Closes),
+			)
+			if err != nil {
+				return fmt.Errorf("can't set hours of atm %d: %w", atmId, err)
+			}
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogATM, atmId, CatalogSetHours)
+	})
+}
+
+// SetATMCapabilities replaces what the ATM can do and the currencies it
+// handles.
+func SetATMCapabilities(atmId int64, capabilities, currencies []string, managerId int, db *sql.DB) error {
+	for _, capability := range capabilities {
+		switch capability {
+		case CapabilityCashIn, CapabilityCashOut, CapabilityContactless:
+		default:
+			return fmt.Errorf("capability %q: %w", capability, ErrInvalidCapability)
+		}
+	}
+	for _, currency := range currencies {
+		if !isCurrencyCode(currency) {
+			return fmt.Errorf("currency %q: %w", currency, ErrInvalidCapability)
+		}
+	}
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManagedATM(ctx, q, atmId, managerId)
+		if err != nil {
+			return err
+		}
+		err = replaceAtmSet(ctx, q, atmId, deleteAtmCapabilities, insertAtmCapability, "capability", capabilities)
+		if err != nil {
+			return err
+		}
+		err = replaceAtmSet(ctx, q, atmId, deleteAtmCurrencies, insertAtmCurrency, "currency", currencies)
+		if err != nil {
+			return err
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogATM, atmId, CatalogSetCapabilities)
+	})
+}
+
+// checkManagedATM checks the manager changing the ATM and the ATM itself.
+func checkManagedATM(ctx context.Context, q queryer, atmId int64, managerId int) error {
+	err := checkManager(ctx, q, managerId)
+	if err != nil {
+		return err
+	}
+	return checkATM(ctx, q, atmId)
+}
+
+func replaceAtmSet(ctx context.Context, q queryer, atmId int64, clear, insert, name string, values []string) error {
+	_, err := q.ExecContext(ctx, clear, atmId)
+	if err != nil {
+		return fmt.Errorf("can't clear %s of atm %d: %w", name, atmId, err)
+	}
+	seen := map[string]bool{}
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		_, err = q.ExecContext(ctx, insert, sql.Named("atmId", atmId), sql.Named(name, value))
+		if err != nil {
+			return fmt.Errorf("can't set %s %s of atm %d: %w", name, value, atmId, err)
+		}
+	}
+	return nil
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// filterArgs are the named arguments of atmFilters.
+func filterArgs(filter ATMFilter) []interface{} {
+	return []interface{}{
+		sql.Named("online", filter.OpenNow),
+		sql.Named("capability", filter.Capability),
+		sql.Named("currency", filter.Currency),
+	}
+}
+
+// scanAtm reads a row of atmColumns and the columns after them into extra.
+func scanAtm(rows *sql.Rows, extra ...interface{}) (atm Atm, err error) {
+	var latitude, longitude sql.NullFloat64
+	var hours, capabilities, currencies string
+	err = rows.Scan(append([]interface{}{&atm.Id, &atm.City, &atm.District, &atm.Street, &latitude, &longitude,
+		&atm.Status, &atm.Timezone, &hours, &capabilities, &currencies}, extra...)...)
+	if err != nil {
+		return Atm{}, fmt.Errorf("can't scan atm: %w", err)
+	}
+	if latitude.Valid && longitude.Valid {
+		atm.Location = &Location{Latitude: latitude.Float64, Longitude: longitude.Float64}
+	}
+	atm.Hours, err = parseHours(hours)
+	if err != nil {
+		return Atm{}, fmt.Errorf("can't scan hours of atm %d: %w", atm.Id, err)
+	}
+	atm.Capabilities = splitSorted(capabilities)
+	atm.Currencies = splitSorted(currencies)
+	return atm, nil
+}
+
+// parseHours reads the "weekday opens closes" items atmColumns concatenates.
+func parseHours(text string) (hours []Hours, err error) {
+	for _, item := range splitSorted(text) {
+		fields := strings.Fields(item)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("hours %q: %w", item, ErrInvalidHours)
+		}
+		var numbers [3]int
+		for i, field := range fields {
+			numbers[i], err = strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("hours %q: %w", item, err)
+			}
+		}
+		hours = append(hours, Hours{Weekday: time.Weekday(numbers[0]), Opens: numbers[1], Closes: numbers[2]})
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Weekday < hours[j].Weekday })
+	return hours, nil
+}
+
+func splitSorted(text string) []string {
+	if text == "" {
+		return nil
+	}
+	items := strings.Split(text, ",")
+	sort.Strings(items)
+	return items
+}
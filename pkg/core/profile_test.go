@@ -0,0 +1,159 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAtm_OpenAt(t *testing.T) {
+	// 2 March 2026 is a Monday; Dushanbe is UTC+5
+	atm := Atm{Status: ATMOnline, Timezone: "Asia/Dushanbe", Hours: []Hours{
+		{Weekday: time.Monday, Opens: 9 * 60, Closes: 18 * 60},
+		{Weekday: time.Friday, Opens: 20 * 60, Closes: 2 * 60},
+		{Weekday: time.Saturday, Opens: 0, Closes: minutesPerDay},
+	}}
+	cases := []struct {
+		name string
+		at   time.Time
+		open bool
+	}{
+		{"monday before opening", at(2, 3), false},
+		{"monday at opening", at(2, 4), true},
+		{"monday before closing", at(2, 12).Add(59 * time.Minute), true},
+		{"monday at closing", at(2, 13), false},
+		{"tuesday", at(3, 8), false},
+		{"friday night", at(6, 16), true},
+		{"past midnight into saturday", at(6, 20), true},
+		{"saturday all day", at(7, 18), true},
+		{"sunday night after saturday", at(8, 20), false},
+	}
+	for _, c := range cases {
+		if open := atm.OpenAt(c.at); open != c.open {
+			t.Errorf("%s just be open %v: %v", c.name, c.open, open)
+		}
+	}
+	atm.Status = ATMOutOfService
+	if atm.OpenAt(at(2, 5)) {
+		t.Errorf("out of service atm just be closed")
+	}
+	if !(Atm{Status: ATMOnline}).OpenAt(at(3, 3)) {
+		t.Errorf("atm without hours just be open around the clock")
+	}
+}
+
+func TestSetATMProfile(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	hours := []Hours{{Weekday: time.Tuesday, Opens: 600, Closes: 1200}, {Weekday: time.Monday, Opens: 540, Closes: 1080}}
+	err := SetATMHours(1, "Asia/Dushanbe", hours, 1, db)
+	if err != nil {
+		t.Fatalf("can't set hours: %v", err)
+	}
+	err = SetATMCapabilities(1, []string{CapabilityCashOut, CapabilityCashIn, CapabilityCashOut}, []string{"USD", "TJS"}, 1, db)
+	if err != nil {
+		t.Fatalf("can't set capabilities: %v", err)
+	}
+	err = SetATMStatus(1, ATMOffline, 1, db)
+	if err != nil {
+		t.Fatalf("can't set status: %v", err)
+	}
+	atms, err := ATMsGet(db)
+	if err != nil || len(atms) != 1 {
+		t.Fatalf("can't get atms: %v %v", atms, err)
+	}
+	want := Atm{Id: 1, City: "Dushanbe", District: "Somoni", Street: "Foteh51", Status: ATMOffline, Timezone: "Asia/Dushanbe",
+		Hours:        []Hours{hours[1], hours[0]},
+		Capabilities: []string{CapabilityCashIn, CapabilityCashOut},
+		Currencies:   []string{"TJS", "USD"},
+	}
+	if !reflect.DeepEqual(atms[0], want) {
+		t.Errorf("atm just be %+v: %+v", want, atms[0])
+	}
+	invalid := []error{
+		SetATMStatus(1, "broken", 1, db),
+		SetATMHours(1, "Mars/Olympus", nil, 1, db),
+		SetATMHours(1, "UTC", []Hours{{Weekday: time.Monday, Opens: 600, Closes: 600}}, 1, db),
+		SetATMHours(1, "UTC", []Hours{{Weekday: time.Monday, Opens: 0, Closes: 60}, {Weekday: time.Monday, Opens: 60, Closes: 120}}, 1, db),
+		SetATMHours(1, "UTC", []Hours{{Weekday: 7, Opens: 0, Closes: 60}}, 1, db),
+		SetATMCapabilities(1, []string{"teleport"}, nil, 1, db),
+		SetATMCapabilities(1, nil, []string{"usd"}, 1, db),
+	}
+	for i, err := range invalid {
+		if !errors.Is(err, ErrInvalidStatus) && !errors.Is(err, ErrInvalidHours) && !errors.Is(err, ErrInvalidCapability) {
+			t.Errorf("update %d just be refused: %v", i, err)
+		}
+	}
+	if err = SetATMStatus(404, ATMOnline, 1, db); !errors.Is(err, ErrATMNotFound) {
+		t.Errorf("unknown atm just be ErrATMNotFound: %v", err)
+	}
+	if err = SetATMStatus(1, ATMOnline, 404, db); !errors.Is(err, ErrManagerNotFound) {
+		t.Errorf("unknown manager just be ErrManagerNotFound: %v", err)
+	}
+	changes, err := CatalogChangesGet(db)
+	if err != nil || len(changes) != 3 {
+		t.Fatalf("profile just have 3 changes: %+v %v", changes, err)
+	}
+	for i, action := range []string{CatalogSetHours, CatalogSetCapabilities, CatalogSetStatus} {
+		if changes[i].ManagerId != 1 || changes[i].ItemId != 1 || changes[i].Action != action {
+			t.Errorf("change %d just be %s of atm 1 by manager 1: %+v", i, action, changes[i])
+		}
+	}
+}
+
+func TestATMsSearch_OpenNow(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(3, 6))()
+	for id := int64(2); id <= 7; id++ {
+		insertAtm(t, db, id, "Dushanbe", "Sino", "Rudaki")
+	}
+	// a Tuesday morning: 1 and 2 are closed for the day, 3 is out of service
+	closed := []Hours{{Weekday: time.Monday, Opens: 0, Closes: minutesPerDay}}
+	for _, id := range []int64{1, 2} {
+		if err := SetATMHours(id, "UTC", closed, 1, db); err != nil {
+			t.Fatalf("can't set hours: %v", err)
+		}
+	}
+	if err := SetATMStatus(3, ATMOutOfService, 1, db); err != nil {
+		t.Fatalf("can't set status: %v", err)
+	}
+	for _, id := range []int64{4, 6, 7} {
+		if err := SetATMCapabilities(id, []string{CapabilityCashIn}, []string{"TJS"}, 1, db); err != nil {
+			t.Fatalf("can't set capabilities: %v", err)
+		}
+	}
+	query := ATMQuery{ATMFilter: ATMFilter{OpenNow: true}, Limit: 2}
+	var seen []int64
+	for {
+		page, err := ATMsSearch(query, db)
+		if err != nil {
+			t.Fatalf("can't search atms: %v", err)
+		}
+		seen = append(seen, atmIds(page.ATMs)...)
+		if page.NextPageToken == "" {
+			break
+		}
+		query.PageToken = page.NextPageToken
+	}
+	if !reflect.DeepEqual(seen, []int64{4, 5, 6, 7}) {
+		t.Errorf("open atms just be 4 to 7: %v", seen)
+	}
+	atms, err := ATMsFiltered(ATMFilter{OpenNow: true, Capability: CapabilityCashIn, Currency: "TJS"}, db)
+	if err != nil || !reflect.DeepEqual(atmIds(atms), []int64{4, 6, 7}) {
+		t.Errorf("open cash-in atms just be 4, 6 and 7: %v %v", atmIds(atms), err)
+	}
+	atms, err = ATMsFiltered(ATMFilter{Currency: "USD"}, db)
+	if err != nil || len(atms) != 0 {
+		t.Errorf("no atm just handle USD: %v %v", atmIds(atms), err)
+	}
+}
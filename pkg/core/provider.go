@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidReference = errors.New("customer reference is not valid")
+var ErrAmountOutOfRange = errors.New("amount is out of the service's range")
+var ErrServiceInactive = errors.New("service is not active")
+var ErrInvalidProvider = errors.New("service provider is not valid")
+
+// ReferenceValidators are the named reference formats. A ReferenceFormat
+// that is not one of them is a regular expression.
+var ReferenceValidators = map[string]func(reference string) bool{
+	"phone":  regexp.MustCompile(`^(\+?992)?[0-9]{9}$`).MatchString,
+	"digits": regexp.MustCompile(`^[0-9]+$`).MatchString,
+}
+
+// ServiceProvider is the catalog entry of a service: what the client must
+// give to identify their account with the provider and how much they may pay.
+type ServiceProvider struct {
+	Id             int
+	Service        string
+	Category       string
+	ReferenceLabel string
+	// ReferenceFormat names one of ReferenceValidators or is a regular
+	// expression the whole reference must match. Empty takes any reference.
+	ReferenceFormat string
+	MinAmount       int
+	// MaxAmount is unlimited when zero.
+	MaxAmount int
+	// Fee is charged to the card on top of the amount.
+	Fee    int
+	Active bool
+	// Biller names the entry of Billers that confirms payments with the
+	// provider. Empty takes payments without asking anyone.
+	Biller string
+}
+
+// Validate checks a payment of amount to the customer account reference.
+func (p ServiceProvider) Validate(reference string, amount int) error {
+	if !p.Active {
+		return fmt.Errorf("service %s: %w", p.Service, ErrServiceInactive)
+	}
+	if amount < p.MinAmount || (p.MaxAmount > 0 && amount > p.MaxAmount) {
+		return fmt.Errorf("service %s takes %d-%d, not %d: %w", p.Service, p.MinAmount, p.MaxAmount, amount, ErrAmountOutOfRange)
+	}
+	valid, err := p.matcher()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(reference) == "" || !valid(reference) {
+		return fmt.Errorf("%s %q for service %s: %w", p.ReferenceLabel, reference, p.Service, ErrInvalidReference)
+	}
+	return nil
+}
+
+func (p ServiceProvider) matcher() (func(string) bool, error) {
+	if validator, ok := ReferenceValidators[p.ReferenceFormat]; ok {
+		return validator, nil
+	}
+	if p.ReferenceFormat == "" {
+		return func(string) bool { return true }, nil
+	}
+	pattern, err := regexp.Compile(`^(?:` + p.ReferenceFormat + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("reference format of service %s: %v: %w", p.Service, err, ErrInvalidProvider)
+	}
+	return pattern.MatchString, nil
+}
+
+func ProvidersGet(db *sql.DB) (providers []ServiceProvider, err error) {
+	rows, err := db.Query(getProviders)
+	if err != nil {
+		return nil, fmt.Errorf("can't get providers: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close providers: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var provider ServiceProvider
+		err = rows.Scan(&provider.Id, &provider.Service, &provider.Category, &provider.ReferenceLabel,
+			&provider.ReferenceFormat, &provider.MinAmount, &provider.MaxAmount, &provider.Fee, &provider.Active, &provider.Biller)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan provider: %w", err)
+		}
+		providers = append(providers, provider)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get providers: %w", err)
+	}
+	return providers, nil
+}
+
+func ProviderGet(service string, db *sql.DB) (provider ServiceProvider, err error) {
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		provider, err = providerByName(ctx, q, service)
+		return err
+	})
+	return provider, err
+}
+
+// SetProvider creates or replaces the catalog entry of provider.Service.
+// It is how a manager updates a service, and is recorded as a catalog
+// change like AddService.
+func SetProvider(provider ServiceProvider, managerId int, db *sql.DB) error {
+	if provider.MinAmount <= 0 || (provider.MaxAmount != 0 && provider.MaxAmount < provider.MinAmount) {
+		return fmt.Errorf("amounts %d-%d: %w", provider.MinAmount, provider.MaxAmount, ErrInvalidProvider)
+	}
+	if provider.Fee < 0 {
+		return fmt.Errorf("fee %d: %w", provider.Fee, ErrInvalidProvider)
+	}
+	if provider.Category == "" || provider.ReferenceLabel == "" {
+		return fmt.Errorf("category and reference label are required: %w", ErrInvalidProvider)
+	}
+	_, err := provider.matcher()
+	if err != nil {
+		return err
+	}
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		current, err := providerByName(ctx, q, provider.Service)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, setProvider,
+			sql.Named("serviceId", current.Id),
+			sql.Named("category", provider.Category),
+			sql.Named("referenceLabel", provider.ReferenceLabel),
+			sql.Named("referenceFormat", provider.ReferenceFormat),
+			sql.Named("minAmount", provider.MinAmount),
+			sql.Named("maxAmount", provider.MaxAmount),
+			sql.Named("fee", provider.Fee),
+			sql.Named("active", provider.Active),
+			sql.Named("biller", provider.Biller),
+		)
+		if err != nil {
+			return fmt.Errorf("can't set provider %s: %w", provider.Service, err)
+		}
+		return recordCatalogChange(ctx, q, managerId, CatalogService, int64(current.Id), CatalogUpdate)
+	})
+}
+
+func providerByName(ctx context.Context, q queryer, service string) (provider ServiceProvider, err error) {
+	err = q.QueryRowContext(ctx, getProvider, service).Scan(&provider.Id, &provider.Service, &provider.Category,
+		&provider.ReferenceLabel, &provider.ReferenceFormat, &provider.MinAmount, &provider.MaxAmount, &provider.Fee, &provider.Active,
+		&provider.Biller)
+	if err == sql.ErrNoRows {
+		return provider, fmt.Errorf("service %s: %w", service, ErrServiceNotFound)
+	}
+	if err != nil {
+		return provider, fmt.Errorf("can't get provider %s: %w", service, err)
+	}
+	return provider, nil
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestServiceProvider_Validate(t *testing.T) {
+	phone := ServiceProvider{Service: "tcell", ReferenceLabel: "phone number", ReferenceFormat: "phone", MinAmount: 5, MaxAmount: 500, Active: true}
+	contract := ServiceProvider{Service: "internet", ReferenceLabel: "contract id", ReferenceFormat: "[0-9]{6}", MinAmount: 1, Active: true}
+	for _, tt := range []struct {
+		provider  ServiceProvider
+		reference string
+		amount    int
+		want      error
+	}{
+		{phone, "+992900123456", 100, nil},
+		{phone, "900123456", 5, nil},
+		{phone, "90012345", 100, ErrInvalidReference},
+		{phone, "900123456", 4, ErrAmountOutOfRange},
+		{phone, "900123456", 501, ErrAmountOutOfRange},
+		{contract, "123456", 1000000, nil},
+		{contract, "1234567", 10, ErrInvalidReference},
+		{contract, "", 10, ErrInvalidReference},
+		{ServiceProvider{Service: "water", MinAmount: 1}, "any", 10, ErrServiceInactive},
+		{ServiceProvider{Service: "gas", MinAmount: 1, Active: true}, " ", 10, ErrInvalidReference},
+	} {
+		err := tt.provider.Validate(tt.reference, tt.amount)
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s %q %d just be %v: %v", tt.provider.Service, tt.reference, tt.amount, tt.want, err)
+		}
+	}
+}
+
+func TestServicesPay_ChecksCatalog(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := ServicesPayMoreCard("internet", "12-34", 2021600000000000, 100, db)
+	if !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("bad contract id just be ErrInvalidReference: %v", err)
+	}
+	err = SetProvider(ServiceProvider{Service: "internet", Category: "internet", ReferenceLabel: "contract id",
+		ReferenceFormat: "[", MinAmount: 1, Active: true}, 1, db)
+	if !errors.Is(err, ErrInvalidProvider) {
+		t.Errorf("bad format just be ErrInvalidProvider: %v", err)
+	}
+	err = SetProvider(ServiceProvider{Service: "water", Category: "utilities", ReferenceLabel: "account", MinAmount: 1}, 1, db)
+	if !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("unknown service just be ErrServiceNotFound: %v", err)
+	}
+	err = SetProvider(ServiceProvider{Service: "internet", Category: "internet", ReferenceLabel: "contract id",
+		ReferenceFormat: "digits", MinAmount: 10, MaxAmount: 300, Active: true}, 1, db)
+	if err != nil {
+		t.Fatalf("can't set provider: %v", err)
+	}
+	changes, err := CatalogChangesGet(db)
+	if err != nil || len(changes) != 1 || changes[0].ManagerId != 1 || changes[0].Kind != CatalogService ||
+		changes[0].ItemId != 1 || changes[0].Action != CatalogUpdate {
+		t.Errorf("provider update just be recorded: %+v %v", changes, err)
+	}
+	err = SetProvider(ServiceProvider{Service: "internet", Category: "internet", ReferenceLabel: "contract id",
+		MinAmount: 1, Active: true}, 404, db)
+	if !errors.Is(err, ErrManagerNotFound) {
+		t.Errorf("unknown manager just be ErrManagerNotFound: %v", err)
+	}
+	_, err = ServicesPayMoreCard("internet", "42", 2021600000000000, 301, db)
+	if !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("payment over max just be ErrAmountOutOfRange: %v", err)
+	}
+	_, err = ServicesPayMoreCard("internet", "42", 2021600000000000, 300, db)
+	if err != nil {
+		t.Fatalf("can't pay service: %v", err)
+	}
+	var reference string
+	err = db.QueryRow(`SELECT reference FROM transactions WHERE kind = ?`, KindService).Scan(&reference)
+	if err != nil {
+		t.Fatalf("can't get payment: %v", err)
+	}
+	if reference != "42" {
+		t.Errorf("reference just be stored: %q", reference)
+	}
+	providers, err := ProvidersGet(db)
+	if err != nil {
+		t.Fatalf("can't get providers: %v", err)
+	}
+	if len(providers) != 1 || providers[0].MaxAmount != 300 || providers[0].ReferenceFormat != "digits" {
+		t.Errorf("providers just be the updated internet: %+v", providers)
+	}
+}
@@ -0,0 +1,784 @@
+package core
+
+///////////////////////////////////// queries for Transactions ///////////////////////////////////////////////
+
+const beginImmediate = `BEGIN IMMEDIATE;`
+const commitTx = `COMMIT;`
+const rollbackTx = `ROLLBACK;`
+
+///////////////////////////////////// queries for Transfer ///////////////////////////////////////////////////
+
+const getCardIdByClient = `SELECT id FROM clients_cards WHERE client_id = ? ORDER BY id LIMIT 1;`
+const getCardIdByPAN = `SELECT id FROM clients_cards WHERE pan = ?;`
+const lockCard = `UPDATE clients_cards SET balance = balance WHERE id = ?;`
+const getCardAvailable = `
+SELECT c.balance - ifnull((SELECT sum(h.amount)
+                           FROM holds h
+                           WHERE h.card_id = c.id AND h.status = 'authorized' AND h.expires_at > :now), 0)
+FROM clients_cards c
+WHERE c.id = :idCard;`
+const addCardBalance = `UPDATE clients_cards SET balance = balance + :amount WHERE id = :idCard;`
+const getBlockReason = `SELECT reason FROM blocked_cards WHERE card_id = ?;`
+
+///////////////////////////////////// queries for Services ///////////////////////////////////////////////////
+
+const lockService = `UPDATE services SET balance = balance WHERE service = ?;`
+const addServiceBalance = `UPDATE services SET balance = ifnull(balance, 0) + :amount WHERE service = :serviceName;`
+const deactivatedServicesDDL = `
+CREATE TABLE IF NOT EXISTS deactivated_services
+(
+    service_id     INTEGER PRIMARY KEY REFERENCES services,
+    deactivated_at INTEGER NOT NULL
+);`
+const getActiveServices = `
+SELECT s.id, s.service
+FROM services s
+WHERE s.id NOT IN (SELECT service_id FROM deactivated_services)
+ORDER BY s.id;`
+const getServiceId = `
+SELECT id
+FROM services
+WHERE service = ?
+  AND id NOT IN (SELECT service_id FROM deactivated_services);`
+const deactivateService = `INSERT INTO deactivated_services(service_id, deactivated_at) VALUES (:serviceId, :deactivatedAt);`
+const getCatalogServices = `
+SELECT s.id, s.service, ifnull(s.balance, 0), ifnull(d.deactivated_at, 0)
+FROM services s
+         LEFT JOIN deactivated_services d ON d.service_id = s.id
+ORDER BY s.id;`
+const catalogChangesDDL = `
+CREATE TABLE IF NOT EXISTS catalog_changes
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    manager_id INTEGER NOT NULL REFERENCES managers,
+    kind       TEXT    NOT NULL,
+    item_id    INTEGER NOT NULL,
+    action     TEXT    NOT NULL,
+    changed_at INTEGER NOT NULL
+);`
+const insertCatalogChange = `
+INSERT INTO catalog_changes(manager_id, kind, item_id, action, changed_at)
+VALUES (:managerId, :kind, :itemId, :action, :changedAt);`
+const getCatalogChanges = `SELECT id, manager_id, kind, item_id, action, changed_at FROM catalog_changes ORDER BY id;`
+
+///////////////////////////////////// queries for Cards //////////////////////////////////////////////////////
+
+const blockedCardsDDL = `
+CREATE TABLE IF NOT EXISTS blocked_cards
+(
+    card_id    INTEGER PRIMARY KEY REFERENCES clients_cards,
+    reason     TEXT    NOT NULL,
+    blocked_at INTEGER NOT NULL
+);`
+const blockCard = `INSERT INTO blocked_cards(card_id, reason, blocked_at) VALUES (:idCard, :reason, :blockedAt)
+ON CONFLICT(card_id) DO UPDATE SET reason = excluded.reason, blocked_at = excluded.blocked_at;`
+const unblockCard = `DELETE FROM blocked_cards WHERE card_id = ?;`
+
+///////////////////////////////////// queries for Ledger /////////////////////////////////////////////////////
+
+const transactionsDDL = `
+CREATE TABLE IF NOT EXISTS transactions
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind         TEXT    NOT NULL,
+    from_card_id INTEGER REFERENCES clients_cards,
+    to_card_id   INTEGER REFERENCES clients_cards,
+    service      TEXT,
+    reference    TEXT,
+    amount       INTEGER NOT NULL,
+    atm_id       INTEGER REFERENCES atms,
+    created_at   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS transactions_from ON transactions (from_card_id, created_at);
+CREATE INDEX IF NOT EXISTS transactions_to ON transactions (to_card_id, created_at);`
+const insertTransaction = `INSERT INTO transactions(kind, from_card_id, to_card_id, service, reference, amount, atm_id, created_at)
+VALUES (:kind, nullif(:fromCardId, 0), nullif(:toCardId, 0), nullif(:service, ''), nullif(:reference, ''), :amount,
+        nullif(:atmId, 0), :createdAt);`
+
+///////////////////////////////////// queries for ATMs ///////////////////////////////////////////////////////
+
+const checkAtm = `SELECT id FROM atms WHERE id = ? AND id NOT IN (SELECT atm_id FROM deactivated_atms);`
+const getAtmService = `
+SELECT ifnull(p.status, 'online'),
+       NOT EXISTS(SELECT 1 FROM atm_capabilities c WHERE c.atm_id = a.id)
+           OR EXISTS(SELECT 1 FROM atm_capabilities c WHERE c.atm_id = a.id AND c.capability = :capability)
+FROM atms a
+         LEFT JOIN atm_profiles p ON p.atm_id = a.id
+WHERE a.id = :atmId
+  AND a.id NOT IN (SELECT atm_id FROM deactivated_atms);`
+const deactivatedAtmsDDL = `
+CREATE TABLE IF NOT EXISTS deactivated_atms
+(
+    atm_id         INTEGER PRIMARY KEY REFERENCES atms,
+    deactivated_at INTEGER NOT NULL
+);`
+const updateAtm = `UPDATE atms SET city = :cityName, district = :districtName, street = :streetName WHERE id = :atmId;`
+const deactivateAtm = `INSERT INTO deactivated_atms(atm_id, deactivated_at) VALUES (:atmId, :deactivatedAt);`
+const atmLocationsDDL = `
+CREATE TABLE IF NOT EXISTS atm_locations
+(
+    atm_id    INTEGER PRIMARY KEY REFERENCES atms,
+    latitude  REAL NOT NULL,
+    longitude REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS atm_locations_position ON atm_locations (latitude, longitude);`
+const atmsSearchDDL = `
+CREATE INDEX IF NOT EXISTS atms_city ON atms (city COLLATE NOCASE, district COLLATE NOCASE);`
+
+// atmColumns are the columns scanAtm reads; atmFilters narrow them down
+// the way ATMFilter asks.
+const atmColumns = `
+SELECT a.id, a.city, a.district, a.street, l.latitude, l.longitude,
+       ifnull(p.status, 'online'), ifnull(p.timezone, 'UTC'),
+       ifnull((SELECT group_concat(h.weekday || ' ' || h.opens || ' ' || h.closes) FROM atm_hours h WHERE h.atm_id = a.id), ''),
+       ifnull((SELECT group_concat(c.capability) FROM atm_capabilities c WHERE c.atm_id = a.id), ''),
+       ifnull((SELECT group_concat(c.currency) FROM atm_currencies c WHERE c.atm_id = a.id), '')`
+const getCatalogAtms = atmColumns + `, ifnull(d.deactivated_at, 0)
+FROM atms a
+         LEFT JOIN atm_locations l ON l.atm_id = a.id
+         LEFT JOIN atm_profiles p ON p.atm_id = a.id
+         LEFT JOIN deactivated_atms d ON d.atm_id = a.id
+ORDER BY a.id;`
+const atmFilters = `
+  AND a.id NOT IN (SELECT atm_id FROM deactivated_atms)
+  AND (:online = 0 OR ifnull(p.status, 'online') = 'online')
+  AND (:capability = '' OR EXISTS(SELECT 1 FROM atm_capabilities c WHERE c.atm_id = a.id AND c.capability = :capability))
+  AND (:currency = '' OR EXISTS(SELECT 1 FROM atm_currencies c WHERE c.atm_id = a.id AND c.currency = :currency))`
+const searchAtms = atmColumns + `
+FROM atms a
+         LEFT JOIN atm_locations l ON l.atm_id = a.id
+         LEFT JOIN atm_profiles p ON p.atm_id = a.id
+WHERE (:city = '' OR a.city = :city COLLATE NOCASE)
+  AND (:district = '' OR a.district = :district COLLATE NOCASE)
+  AND (:street = '' OR a.street LIKE '%' || :street || '%' ESCAPE '\')
+  AND (:afterId = 0
+    OR (CASE :sort WHEN 'city' THEN a.city WHEN 'district' THEN a.district WHEN 'street' THEN a.street ELSE '' END)
+           COLLATE NOCASE > :afterValue
+    OR ((CASE :sort WHEN 'city' THEN a.city WHEN 'district' THEN a.district WHEN 'street' THEN a.street ELSE '' END)
+            COLLATE NOCASE = :afterValue AND a.id > :afterId))` + atmFilters + `
+ORDER BY (CASE :sort WHEN 'city' THEN a.city WHEN 'district' THEN a.district WHEN 'street' THEN a.street ELSE '' END)
+             COLLATE NOCASE, a.id
+LIMIT :limit;`
+const setAtmLocation = `
+INSERT INTO atm_locations(atm_id, latitude, longitude)
+VALUES (:atmId, :latitude, :longitude)
+ON CONFLICT (atm_id) DO UPDATE SET latitude = excluded.latitude, longitude = excluded.longitude;`
+const getAtmsInBox = atmColumns + `
+FROM atm_locations l
+         JOIN atms a ON a.id = l.atm_id
+         LEFT JOIN atm_profiles p ON p.atm_id = a.id
+WHERE l.latitude BETWEEN :minLat AND :maxLat
+  AND (CASE
+           WHEN :minLon <= :maxLon THEN l.longitude BETWEEN :minLon AND :maxLon
+           ELSE l.longitude >= :minLon OR l.longitude <= :maxLon END)` + atmFilters + `;`
+const atmProfilesDDL = `
+CREATE TABLE IF NOT EXISTS atm_profiles
+(
+    atm_id   INTEGER PRIMARY KEY REFERENCES atms,
+    status   TEXT NOT NULL DEFAULT 'online',
+    timezone TEXT NOT NULL DEFAULT 'UTC'
+);
+CREATE TABLE IF NOT EXISTS atm_hours
+(
+    atm_id  INTEGER NOT NULL REFERENCES atms,
+    weekday INTEGER NOT NULL,
+    opens   INTEGER NOT NULL,
+    closes  INTEGER NOT NULL,
+    PRIMARY KEY (atm_id, weekday)
+);
+CREATE TABLE IF NOT EXISTS atm_capabilities
+(
+    atm_id     INTEGER NOT NULL REFERENCES atms,
+    capability TEXT    NOT NULL,
+    PRIMARY KEY (atm_id, capability)
+);
+CREATE TABLE IF NOT EXISTS atm_currencies
+(
+    atm_id   INTEGER NOT NULL REFERENCES atms,
+    currency TEXT    NOT NULL,
+    PRIMARY KEY (atm_id, currency)
+);`
+const setAtmStatus = `
+INSERT INTO atm_profiles(atm_id, status)
+VALUES (:atmId, :status)
+ON CONFLICT (atm_id) DO UPDATE SET status = excluded.status;`
+const setAtmTimezone = `
+INSERT INTO atm_profiles(atm_id, timezone)
+VALUES (:atmId, :timezone)
+ON CONFLICT (atm_id) DO UPDATE SET timezone = excluded.timezone;`
+const deleteAtmHours = `DELETE FROM atm_hours WHERE atm_id = ?;`
+const insertAtmHours = `INSERT INTO atm_hours(atm_id, weekday, opens, closes) VALUES (:atmId, :weekday, :opens, :closes);`
+const deleteAtmCapabilities = `DELETE FROM atm_capabilities WHERE atm_id = ?;`
+const insertAtmCapability = `INSERT INTO atm_capabilities(atm_id, capability) VALUES (:atmId, :capability);`
+const deleteAtmCurrencies = `DELETE FROM atm_currencies WHERE atm_id = ?;`
+const insertAtmCurrency = `INSERT INTO atm_currencies(atm_id, currency) VALUES (:atmId, :currency);`
+const getCardPIN = `SELECT id, pin FROM clients_cards WHERE pan = ?;`
+const sumWithdrawalsSince = `
+SELECT ifnull(sum(amount), 0)
+FROM transactions
+WHERE from_card_id = :idCard AND kind = 'withdrawal' AND created_at >= :since;`
+const cassettesDDL = `
+CREATE TABLE IF NOT EXISTS atm_cassettes
+(
+    atm_id       INTEGER NOT NULL REFERENCES atms,
+    denomination INTEGER NOT NULL,
+    notes        INTEGER NOT NULL,
+    PRIMARY KEY (atm_id, denomination)
+);
+CREATE TABLE IF NOT EXISTS atm_replenishments
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    atm_id       INTEGER NOT NULL REFERENCES atms,
+    denomination INTEGER NOT NULL,
+    removed      INTEGER NOT NULL,
+    loaded       INTEGER NOT NULL,
+    created_at   INTEGER NOT NULL
+);`
+const getCassettes = `SELECT denomination, notes FROM atm_cassettes WHERE atm_id = ? ORDER BY denomination DESC;`
+const takeNotes = `
+UPDATE atm_cassettes
+SET notes = notes - :notes
+WHERE atm_id = :atmId AND denomination = :denomination AND notes >= :notes;`
+const loadCassette = `
+INSERT INTO atm_cassettes(atm_id, denomination, notes)
+VALUES (:atmId, :denomination, :notes)
+ON CONFLICT (atm_id, denomination) DO UPDATE SET notes = excluded.notes;`
+const insertReplenishment = `
+INSERT INTO atm_replenishments(atm_id, denomination, removed, loaded, created_at)
+VALUES (:atmId, :denomination, :removed, :loaded, :createdAt);`
+const getLowCassettes = `
+SELECT atm_id, denomination, notes
+FROM atm_cassettes
+WHERE notes < :lowNotes
+  AND atm_id NOT IN (SELECT atm_id FROM deactivated_atms)
+ORDER BY atm_id, denomination DESC;`
+
+///////////////////////////////////// queries for Statement //////////////////////////////////////////////////
+
+const getCardForStatement = `SELECT id, balance, holderName FROM clients_cards WHERE pan = ?;`
+const sumMovementsSince = `
+SELECT ifnull(sum(CASE WHEN to_card_id = :idCard THEN amount ELSE 0 END)
+           - sum(CASE WHEN from_card_id = :idCard THEN amount ELSE 0 END), 0)
+FROM transactions
+WHERE (from_card_id = :idCard OR to_card_id = :idCard) AND created_at >= :since;`
+const getStatementEntries = `
+SELECT t.id, t.kind, t.created_at, ifnull(t.service, ''),
+       CASE WHEN t.to_card_id = :idCard THEN t.amount ELSE 0 END
+           - CASE WHEN t.from_card_id = :idCard THEN t.amount ELSE 0 END,
+       ifnull(CASE WHEN t.from_card_id = :idCard THEN receiver.pan ELSE sender.pan END, 0)
+FROM transactions t
+         LEFT JOIN clients_cards sender ON sender.id = t.from_card_id
+         LEFT JOIN clients_cards receiver ON receiver.id = t.to_card_id
+WHERE (t.from_card_id = :idCard OR t.to_card_id = :idCard)
+  AND t.created_at >= :since
+  AND t.created_at < :until
+ORDER BY t.created_at, t.id;`
+
+///////////////////////////////////// queries for Reversal ///////////////////////////////////////////////////
+
+const reversalsDDL = `
+CREATE TABLE IF NOT EXISTS reversals
+(
+    id                      INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id          INTEGER NOT NULL REFERENCES transactions,
+    reversal_transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions,
+    amount                  INTEGER NOT NULL,
+    reason                  TEXT    NOT NULL,
+    manager_id              INTEGER NOT NULL REFERENCES managers,
+    created_at              INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS reversals_transaction ON reversals (transaction_id);`
+const getTransaction = `
+SELECT kind, ifnull(from_card_id, 0), ifnull(to_card_id, 0), ifnull(service, ''), ifnull(reference, ''), amount
+FROM transactions
+WHERE id = ?;`
+const getReversedAmount = `SELECT ifnull(sum(amount), 0) FROM reversals WHERE transaction_id = ?;`
+const getManagerId = `SELECT id FROM managers WHERE id = ?;`
+const getServiceBalance = `SELECT ifnull(balance, 0) FROM services WHERE service = ?;`
+const insertReversal = `INSERT INTO reversals(transaction_id, reversal_transaction_id, amount, reason, manager_id, created_at)
+VALUES (:transactionId, :reversalTransactionId, :amount, :reason, :managerId, :createdAt);`
+const getReversals = `SELECT id, transaction_id, reversal_transaction_id, amount, reason, manager_id, created_at
+FROM reversals WHERE transaction_id = ? ORDER BY id;`
+
+///////////////////////////////////// queries for Schedules //////////////////////////////////////////////////
+
+const schedulesDDL = `
+CREATE TABLE IF NOT EXISTS payment_schedules
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    card_id     INTEGER NOT NULL REFERENCES clients_cards,
+    service     TEXT    NOT NULL,
+    reference   TEXT    NOT NULL,
+    amount      INTEGER NOT NULL,
+    rule        TEXT    NOT NULL,
+    status      TEXT    NOT NULL DEFAULT 'active',
+    next_run_at INTEGER NOT NULL,
+    attempts    INTEGER NOT NULL DEFAULT 0,
+    created_at  INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS payment_schedules_due ON payment_schedules (status, next_run_at);
+CREATE TABLE IF NOT EXISTS schedule_runs
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id    INTEGER NOT NULL REFERENCES payment_schedules,
+    run_at         INTEGER NOT NULL,
+    attempt        INTEGER NOT NULL,
+    transaction_id INTEGER REFERENCES transactions,
+    error          TEXT
+);
+CREATE INDEX IF NOT EXISTS schedule_runs_schedule ON schedule_runs (schedule_id);`
+const getCardIdByClientPAN = `SELECT id FROM clients_cards WHERE pan = ? AND client_id = ?;`
+const insertSchedule = `INSERT INTO payment_schedules(card_id, service, reference, amount, rule, next_run_at, created_at)
+VALUES (:idCard, :service, :reference, :amount, :rule, :nextRunAt, :createdAt);`
+const getSchedulesByClient = `
+SELECT s.id, c.pan, s.service, s.reference, s.amount, s.rule, s.status, s.next_run_at, s.attempts
+FROM payment_schedules s
+         JOIN clients_cards c ON c.id = s.card_id
+WHERE c.client_id = ?
+ORDER BY s.id;`
+const getScheduleStatus = `
+SELECT s.status, s.rule
+FROM payment_schedules s
+         JOIN clients_cards c ON c.id = s.card_id
+WHERE s.id = ? AND c.client_id = ?;`
+const setScheduleStatus = `UPDATE payment_schedules SET status = :status, next_run_at = :nextRunAt, attempts = 0 WHERE id = :id;`
+const getDueSchedules = `
+SELECT id, card_id, service, reference, amount, rule, attempts
+FROM payment_schedules
+WHERE status = 'active' AND next_run_at <= ?
+ORDER BY next_run_at, id;`
+const claimDueSchedule = `
+UPDATE payment_schedules
+SET next_run_at = :nextRunAt, attempts = :attempts
+WHERE id = :id AND status = 'active' AND next_run_at <= :at;`
+const retrySchedule = `
+UPDATE payment_schedules
+SET next_run_at = :retryAt, attempts = :attempts
+WHERE id = :id AND status = 'active' AND next_run_at = :claimedRunAt;`
+const insertScheduleRun = `INSERT INTO schedule_runs(schedule_id, run_at, attempt, transaction_id, error)
+VALUES (:scheduleId, :runAt, :attempt, nullif(:transactionId, 0), nullif(:error, ''));`
+const getScheduleRuns = `
+SELECT r.id, r.run_at, r.attempt, ifnull(r.transaction_id, 0), ifnull(r.error, '')
+FROM schedule_runs r
+         JOIN payment_schedules s ON s.id = r.schedule_id
+         JOIN clients_cards c ON c.id = s.card_id
+WHERE r.schedule_id = ? AND c.client_id = ?
+ORDER BY r.id;`
+
+///////////////////////////////////// queries for Transfer orders ////////////////////////////////////////////
+
+const transferOrdersDDL = `
+CREATE TABLE IF NOT EXISTS transfer_orders
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_card_id   INTEGER NOT NULL REFERENCES clients_cards,
+    to_card_id     INTEGER NOT NULL REFERENCES clients_cards,
+    amount         INTEGER NOT NULL,
+    execute_at     INTEGER NOT NULL,
+    status         TEXT    NOT NULL DEFAULT 'pending',
+    lease_owner    TEXT,
+    lease_until    INTEGER,
+    transaction_id INTEGER REFERENCES transactions,
+    error          TEXT,
+    created_at     INTEGER NOT NULL,
+    finished_at    INTEGER
+);
+CREATE INDEX IF NOT EXISTS transfer_orders_due ON transfer_orders (status, execute_at);`
+const insertTransferOrder = `INSERT INTO transfer_orders(from_card_id, to_card_id, amount, execute_at, created_at)
+VALUES (:fromCardId, :toCardId, :amount, :executeAt, :createdAt);`
+const getTransferOrdersByClient = `
+SELECT o.id, sender.pan, receiver.pan, o.amount, o.execute_at, o.status,
+       ifnull(o.transaction_id, 0), ifnull(o.error, ''), ifnull(o.finished_at, 0)
+FROM transfer_orders o
+         JOIN clients_cards sender ON sender.id = o.from_card_id
+         JOIN clients_cards receiver ON receiver.id = o.to_card_id
+WHERE sender.client_id = ?
+ORDER BY o.execute_at, o.id;`
+const getTransferOrderStatus = `
+SELECT o.status
+FROM transfer_orders o
+         JOIN clients_cards sender ON sender.id = o.from_card_id
+WHERE o.id = ? AND sender.client_id = ?;`
+const cancelTransferOrder = `UPDATE transfer_orders SET status = 'cancelled', finished_at = :finishedAt WHERE id = :id AND status = 'pending';`
+const getDueTransferOrder = `
+SELECT id, from_card_id, to_card_id, amount
+FROM transfer_orders
+WHERE status = 'pending' AND execute_at <= :now AND ifnull(lease_until, 0) <= :now
+ORDER BY execute_at, id
+LIMIT 1;`
+const leaseTransferOrder = `
+UPDATE transfer_orders
+SET lease_owner = :owner, lease_until = :until
+WHERE id = :id AND status = 'pending' AND ifnull(lease_until, 0) <= :now;`
+const finishTransferOrder = `
+UPDATE transfer_orders
+SET status = :status, transaction_id = nullif(:transactionId, 0), error = nullif(:error, ''),
+    finished_at = :finishedAt, lease_owner = NULL, lease_until = NULL
+WHERE id = :id AND status = 'pending' AND lease_owner = :owner;`
+const releaseTransferOrder = `
+UPDATE transfer_orders
+SET lease_owner = NULL, lease_until = NULL
+WHERE id = :id AND status = 'pending' AND lease_owner = :owner;`
+
+///////////////////////////////////// queries for Providers //////////////////////////////////////////////////
+
+const providersDDL = `
+CREATE TABLE IF NOT EXISTS service_providers
+(
+    service_id       INTEGER PRIMARY KEY REFERENCES services,
+    category         TEXT    NOT NULL,
+    reference_label  TEXT    NOT NULL,
+    reference_format TEXT    NOT NULL,
+    min_amount       INTEGER NOT NULL,
+    max_amount       INTEGER NOT NULL,
+    fee              INTEGER NOT NULL DEFAULT 0,
+    active           INTEGER NOT NULL,
+    biller           TEXT    NOT NULL DEFAULT ''
+);`
+const providersDML = `
+INSERT INTO service_providers(service_id, category, reference_label, reference_format, min_amount, max_amount, fee, active)
+SELECT id, 'internet', 'contract id', '^[0-9]{6,10}$', 1, 0, 0, 1
+FROM services
+WHERE service = 'internet'
+ON CONFLICT DO NOTHING;`
+
+// Services missing from service_providers take any reference and amount.
+const selectProviders = `
+SELECT s.id, s.service, ifnull(p.category, 'other'), ifnull(p.reference_label, 'reference'),
+       ifnull(p.reference_format, ''), ifnull(p.min_amount, 1), ifnull(p.max_amount, 0), ifnull(p.fee, 0),
+       ifnull(p.active, 1) AND d.service_id IS NULL, ifnull(p.biller, '')
+FROM services s
+         LEFT JOIN service_providers p ON p.service_id = s.id
+         LEFT JOIN deactivated_services d ON d.service_id = s.id`
+const getProviders = selectProviders + `
+WHERE d.service_id IS NULL
+ORDER BY s.id;`
+const getProvider = selectProviders + `
+WHERE s.service = ?
+ORDER BY s.id
+LIMIT 1;`
+const setProvider = `
+INSERT INTO service_providers(service_id, category, reference_label, reference_format, min_amount, max_amount, fee, active,
+                              biller)
+VALUES (:serviceId, :category, :referenceLabel, :referenceFormat, :minAmount, :maxAmount, :fee, :active, :biller)
+ON CONFLICT (service_id) DO UPDATE SET category         = excluded.category,
+                                       reference_label  = excluded.reference_label,
+                                       reference_format = excluded.reference_format,
+                                       min_amount       = excluded.min_amount,
+                                       max_amount       = excluded.max_amount,
+                                       fee              = excluded.fee,
+                                       active           = excluded.active,
+                                       biller           = excluded.biller;`
+
+///////////////////////////////////// queries for Receipts ///////////////////////////////////////////////////
+
+const receiptsDDL = `
+CREATE TABLE IF NOT EXISTS receipts
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    number         TEXT    NOT NULL UNIQUE,
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions,
+    card_id        INTEGER NOT NULL REFERENCES clients_cards,
+    masked_pan     TEXT    NOT NULL,
+    service        TEXT    NOT NULL,
+    reference      TEXT    NOT NULL,
+    amount         INTEGER NOT NULL,
+    fee            INTEGER NOT NULL,
+    created_at     INTEGER NOT NULL
+);`
+const getCardPAN = `SELECT pan FROM clients_cards WHERE id = ?;`
+const insertReceipt = `
+INSERT INTO receipts(number, transaction_id, card_id, masked_pan, service, reference, amount, fee, created_at)
+VALUES (:number, :transactionId, :idCard, :maskedPan, :service, :reference, :amount, :fee, :createdAt);`
+const getReceipt = `
+SELECT r.number, r.transaction_id, r.masked_pan, r.service, r.reference, r.amount, r.fee, r.created_at
+FROM receipts r
+         JOIN clients_cards c ON c.id = r.card_id
+WHERE r.number = ? AND c.client_id = ?;`
+
+///////////////////////////////////// queries for Settlements ////////////////////////////////////////////////
+
+const settlementsDDL = `
+CREATE TABLE IF NOT EXISTS settlements
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    cutoff     INTEGER NOT NULL,
+    status     TEXT    NOT NULL DEFAULT 'settled',
+    created_at INTEGER NOT NULL,
+    voided_at  INTEGER
+);
+CREATE TABLE IF NOT EXISTS settlement_entries
+(
+    settlement_id  INTEGER NOT NULL REFERENCES settlements,
+    transaction_id INTEGER NOT NULL REFERENCES transactions,
+    PRIMARY KEY (settlement_id, transaction_id)
+);
+CREATE INDEX IF NOT EXISTS settlement_entries_transaction ON settlement_entries (transaction_id);`
+const insertSettlement = `INSERT INTO settlements(cutoff, created_at) VALUES (:cutoff, :createdAt);`
+const settleTransactions = `
+INSERT INTO settlement_entries(settlement_id, transaction_id)
+SELECT :id, t.id
+FROM transactions t
+WHERE (t.kind = 'service' OR (t.kind = 'reversal' AND t.service IS NOT NULL))
+  AND t.created_at < :cutoff
+  AND NOT EXISTS(SELECT 1
+                 FROM settlement_entries e
+                          JOIN settlements s ON s.id = e.settlement_id
+                 WHERE e.transaction_id = t.id AND s.status = 'settled');`
+const getSettlement = `SELECT id, cutoff, status, created_at, ifnull(voided_at, 0) FROM settlements WHERE id = ?;`
+const getSettlementEntries = `
+SELECT t.id, t.created_at, t.service, ifnull(t.reference, ''),
+       CASE WHEN t.kind = 'reversal' THEN -t.amount ELSE t.amount END
+FROM settlement_entries e
+         JOIN transactions t ON t.id = e.transaction_id
+WHERE e.settlement_id = ?
+ORDER BY t.service, t.id;`
+const voidSettlement = `
+UPDATE settlements
+SET status = 'voided', voided_at = :voidedAt
+WHERE id = :id AND status = 'settled';`
+
+///////////////////////////////////// queries for Biller payments /////////////////////////////////////////////
+
+const billerPaymentsDDL = `
+CREATE TABLE IF NOT EXISTS biller_payments
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    card_id        INTEGER NOT NULL REFERENCES clients_cards,
+    service        TEXT    NOT NULL,
+    reference      TEXT    NOT NULL,
+    amount         INTEGER NOT NULL,
+    fee            INTEGER NOT NULL,
+    biller         TEXT    NOT NULL,
+    hold_id        INTEGER NOT NULL REFERENCES holds,
+    status         TEXT    NOT NULL DEFAULT 'pending',
+    confirmation   TEXT,
+    transaction_id INTEGER REFERENCES transactions,
+    error          TEXT,
+    created_at     INTEGER NOT NULL,
+    finished_at    INTEGER
+);
+CREATE INDEX IF NOT EXISTS biller_payments_pending ON biller_payments (status, created_at);`
+const insertBillerPayment = `
+INSERT INTO biller_payments(card_id, service, reference, amount, fee, biller, hold_id, created_at)
+VALUES (:idCard, :service, :reference, :amount, :fee, :biller, :holdId, :createdAt);`
+const getBillerPayment = `
+SELECT id, card_id, service, reference, amount, fee, biller, hold_id, status
+FROM biller_payments
+WHERE id = ?;`
+const getPendingBillerPayments = `
+SELECT id, card_id, service, reference, amount, fee, biller, hold_id, status
+FROM biller_payments
+WHERE status = 'pending' AND created_at <= ?
+ORDER BY id;`
+const confirmBillerPayment = `
+UPDATE biller_payments
+SET status = 'confirmed', confirmation = :confirmation, finished_at = :finishedAt
+WHERE id = :id AND status = 'pending';`
+const setBillerPaymentTransaction = `UPDATE biller_payments SET transaction_id = :transactionId WHERE id = :id;`
+const rejectBillerPayment = `
+UPDATE biller_payments
+SET status = 'rejected', error = :error, finished_at = :finishedAt
+WHERE id = :id AND status = 'pending';`
+
+///////////////////////////////////// queries for Holds //////////////////////////////////////////////////////
+
+const holdsDDL = `
+CREATE TABLE IF NOT EXISTS holds
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    card_id        INTEGER NOT NULL REFERENCES clients_cards,
+    amount         INTEGER NOT NULL,
+    status         TEXT    NOT NULL DEFAULT 'authorized',
+    captured       INTEGER NOT NULL DEFAULT 0,
+    transaction_id INTEGER REFERENCES transactions,
+    expires_at     INTEGER NOT NULL,
+    created_at     INTEGER NOT NULL,
+    finished_at    INTEGER
+);
+CREATE INDEX IF NOT EXISTS holds_card ON holds (card_id, status, expires_at);`
+const insertHold = `INSERT INTO holds(card_id, amount, expires_at, created_at) VALUES (:idCard, :amount, :expiresAt, :createdAt);`
+const getHold = `
+SELECT h.id, h.card_id, c.pan, h.amount, h.status, h.captured, ifnull(h.transaction_id, 0), h.expires_at, h.created_at,
+       ifnull(h.finished_at, 0)
+FROM holds h
+         JOIN clients_cards c ON c.id = h.card_id
+WHERE h.id = ?;`
+const closeHold = `
+UPDATE holds
+SET status = :status, captured = :captured, finished_at = :finishedAt
+WHERE id = :id AND status = 'authorized';`
+const setHoldTransaction = `UPDATE holds SET transaction_id = :transactionId WHERE id = :id;`
+const getCardBalances = `
+SELECT c.balance,
+       c.balance - ifnull((SELECT sum(h.amount)
+                           FROM holds h
+                           WHERE h.card_id = c.id AND h.status = 'authorized' AND h.expires_at > :now), 0)
+FROM clients_cards c
+WHERE c.pan = :pan;`
+
+///////////////////////////////////// queries for Audit //////////////////////////////////////////////////////////
+
+// The triggers keep the audit log append-only for everyone going through
+// SQL; the hash chain catches whoever goes around them. The head, which
+// only moves forward, marks where the chain has to end.
+const auditDDL = `
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id         INTEGER PRIMARY KEY,
+    actor      TEXT    NOT NULL,
+    action     TEXT    NOT NULL,
+    params     TEXT    NOT NULL,
+    result     TEXT    NOT NULL,
+    created_at INTEGER NOT NULL,
+    prev_hash  TEXT    NOT NULL,
+    hash       TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_actor ON audit_log (actor, id);
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+    BEFORE UPDATE
+    ON audit_log
+BEGIN
+    SELECT raise(ABORT, 'audit log is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete
+    BEFORE DELETE
+    ON audit_log
+BEGIN
+    SELECT raise(ABORT, 'audit log is append-only');
+END;
+CREATE TABLE IF NOT EXISTS audit_head
+(
+    id      INTEGER PRIMARY KEY CHECK (id = 1),
+    last_id INTEGER NOT NULL,
+    hash    TEXT    NOT NULL
+);
+INSERT OR IGNORE INTO audit_head(id, last_id, hash)
+SELECT 1, id, hash FROM audit_log ORDER BY id DESC LIMIT 1;
+CREATE TRIGGER IF NOT EXISTS audit_head_forward
+    BEFORE UPDATE
+    ON audit_head
+    WHEN NEW.last_id <= OLD.last_id
+BEGIN
+    SELECT raise(ABORT, 'audit head only moves forward');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_head_no_delete
+    BEFORE DELETE
+    ON audit_head
+BEGIN
+    SELECT raise(ABORT, 'audit head only moves forward');
+END;`
+const getLastAudit = `SELECT id, hash FROM audit_log ORDER BY id DESC LIMIT 1;`
+const getAuditHead = `SELECT last_id, hash FROM audit_head WHERE id = 1;`
+const moveAuditHead = `
+INSERT INTO audit_head(id, last_id, hash) VALUES (1, :lastId, :hash)
+ON CONFLICT (id) DO UPDATE SET last_id = excluded.last_id, hash = excluded.hash;`
+const insertAudit = `
+INSERT INTO audit_log(id, actor, action, params, result, created_at, prev_hash, hash)
+VALUES (:id, :actor, :action, :params, :result, :createdAt, :prevHash, :hash);`
+const selectAudit = `SELECT id, actor, action, params, result, created_at, prev_hash, hash FROM audit_log`
+const getAuditLog = selectAudit + `
+WHERE (:actor = '' OR actor = :actor)
+  AND (:action = '' OR action = :action)
+  AND (:since = 0 OR created_at >= :since)
+  AND (:until = 0 OR created_at < :until)
+  AND id > :afterId
+ORDER BY id
+LIMIT :limit;`
+const getAuditChain = selectAudit + `
+ORDER BY id;`
+
+///////////////////////////////////// queries for Fraud screening ////////////////////////////////////////////////
+
+const screeningsDDL = `
+CREATE TABLE IF NOT EXISTS fraud_screenings
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_card_id   INTEGER NOT NULL REFERENCES clients_cards,
+    to_card_id     INTEGER NOT NULL REFERENCES clients_cards,
+    amount         INTEGER NOT NULL,
+    decision       TEXT    NOT NULL,
+    reasons        TEXT    NOT NULL,
+    status         TEXT    NOT NULL,
+    transaction_id INTEGER REFERENCES transactions,
+    created_at     INTEGER NOT NULL,
+    reviewed_at    INTEGER,
+    reviewer_id    INTEGER REFERENCES managers
+);
+CREATE INDEX IF NOT EXISTS fraud_screenings_status ON fraud_screenings (status, id);`
+const getTransferStats = `
+SELECT count(*), ifnull(avg(amount), 0), ifnull(max(to_card_id = :toCardId), 0)
+FROM transactions
+WHERE kind = 'transfer' AND from_card_id = :idCard;`
+const getRecentTransfers = `
+SELECT to_card_id, amount, created_at
+FROM transactions
+WHERE kind = 'transfer' AND from_card_id = :idCard AND created_at >= :since
+ORDER BY created_at DESC, id DESC;`
+const insertScreening = `
+INSERT INTO fraud_screenings(from_card_id, to_card_id, amount, decision, reasons, status, created_at)
+VALUES (:fromCardId, :toCardId, :amount, :decision, :reasons, :status, :createdAt);`
+const getScreenings = `
+SELECT s.id, f.pan, t.pan, s.amount, s.decision, s.reasons, s.status, ifnull(s.transaction_id, 0), s.created_at,
+       ifnull(s.reviewed_at, 0), ifnull(s.reviewer_id, 0)
+FROM fraud_screenings s
+         JOIN clients_cards f ON f.id = s.from_card_id
+         JOIN clients_cards t ON t.id = s.to_card_id
+WHERE :status = '' OR s.status = :status
+ORDER BY s.id;`
+const getScreening = `SELECT from_card_id, to_card_id, amount, status FROM fraud_screenings WHERE id = ?;`
+const closeScreening = `
+UPDATE fraud_screenings
+SET status = :status, transaction_id = nullif(:transactionId, 0), reviewed_at = :reviewedAt, reviewer_id = :reviewerId
+WHERE id = :id AND status = 'held';`
+
+///////////////////////////////////// queries for Beneficiaries //////////////////////////////////////////////
+
+const beneficiariesDDL = `
+CREATE TABLE IF NOT EXISTS beneficiaries
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id   INTEGER NOT NULL REFERENCES clients,
+    nickname    TEXT    NOT NULL COLLATE NOCASE,
+    pan         INTEGER NOT NULL,
+    holder_name TEXT    NOT NULL,
+    created_at  INTEGER NOT NULL,
+    UNIQUE (client_id, nickname),
+    UNIQUE (client_id, pan)
+);`
+const getCardHolder = `SELECT holderName FROM clients_cards WHERE pan = ?;`
+const getClientCardId = `SELECT id FROM clients_cards WHERE client_id = :clientId AND pan = :pan;`
+const countBeneficiaries = `
+SELECT count(*)
+FROM beneficiaries
+WHERE client_id = :clientId AND (nickname = :nickname OR pan = :pan);`
+const insertBeneficiary = `
+INSERT INTO beneficiaries(client_id, nickname, pan, holder_name, created_at)
+VALUES (:clientId, :nickname, :pan, :holderName, :createdAt);`
+const getBeneficiaries = `
+SELECT id, nickname, pan, holder_name, created_at
+FROM beneficiaries
+WHERE client_id = ?
+ORDER BY nickname, id;`
+const getBeneficiaryPAN = `SELECT pan FROM beneficiaries WHERE id = :id AND client_id = :clientId;`
+const deleteBeneficiary = `DELETE FROM beneficiaries WHERE id = :id AND client_id = :clientId;`
+
+///////////////////////////////////// queries for Receiver lookups ///////////////////////////////////////////
+
+const receiverLookupsDDL = `
+CREATE TABLE IF NOT EXISTS receiver_lookups
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id    INTEGER NOT NULL REFERENCES clients,
+    pan          INTEGER NOT NULL,
+    looked_up_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS receiver_lookups_client ON receiver_lookups (client_id, looked_up_at);`
+const deleteOldLookups = `DELETE FROM receiver_lookups WHERE client_id = :clientId AND looked_up_at <= :since;`
+const countLookups = `
+SELECT count(DISTINCT pan), count(CASE WHEN pan = :pan THEN 1 END), min(looked_up_at)
+FROM receiver_lookups
+WHERE client_id = :clientId;`
+const insertLookup = `
+INSERT INTO receiver_lookups(client_id, pan, looked_up_at)
+VALUES (:clientId, :pan, :lookedUpAt);`
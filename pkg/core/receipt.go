@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const KindFee = "fee"
+
+var ErrReceiptNotFound = errors.New("receipt not found")
+
+// Receipt is the proof of a service payment. It is written with the
+// payment and never changes, so it keeps the card as it was masked then.
+type Receipt struct {
+	Number        string
+	TransactionId int64
+	Time          time.Time
+	MaskedPAN     string
+	Service       string
+	Reference     string
+	Amount        int
+	Fee           int
+}
+
+// Total is what the payment took from the card.
+func (r Receipt) Total() int {
+	return r.Amount + r.Fee
+}
+
+// receiptNumber is unique because transaction ids are; the date makes it
+// readable over the phone.
+func receiptNumber(at time.Time, transactionId int64) string {
+	return fmt.Sprintf("%s-%08d", at.UTC().Format("20060102"), transactionId)
+}
+
+func ReceiptGet(number string, clientId int, db *sql.DB) (receipt Receipt, err error) {
+	var createdAt int64
+	err = db.QueryRow(getReceipt, number, clientId).Scan(&receipt.Number, &receipt.TransactionId, &receipt.MaskedPAN,
+		&receipt.Service, &receipt.Reference, &receipt.Amount, &receipt.Fee, &createdAt)
+	if err == sql.ErrNoRows {
+		return Receipt{}, fmt.Errorf("receipt %s of client %d: %w", number, clientId, ErrReceiptNotFound)
+	}
+	if err != nil {
+		return Receipt{}, fmt.Errorf("can't get receipt %s: %w", number, err)
+	}
+	receipt.Time = time.Unix(createdAt, 0)
+	return receipt, nil
+}
+
+func writeReceipt(ctx context.Context, q queryer, cardId int64, receipt Receipt) (Receipt, error) {
+	var pan int64
+	err := q.QueryRowContext(ctx, getCardPAN, cardId).Scan(&pan)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("can't get card %d: %w", cardId, err)
+	}
+	receipt.MaskedPAN = MaskPAN(pan)
+	receipt.Number = receiptNumber(receipt.Time, receipt.TransactionId)
+	_, err = q.ExecContext(ctx, insertReceipt,
+		sql.Named("number", receipt.Number),
+		sql.Named("transactionId", receipt.TransactionId),
+		sql.Named("idCard", cardId),
+		sql.Named("maskedPan", receipt.MaskedPAN),
+		sql.Named("service", receipt.Service),
+		sql.Named("reference", receipt.Reference),
+		sql.Named("amount", receipt.Amount),
+		sql.Named("fee", receipt.Fee),
+		sql.Named("createdAt", receipt.Time.Unix()),
+	)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("can't write receipt of transaction %d: %w", receipt.TransactionId, err)
+	}
+	return receipt, nil
+}
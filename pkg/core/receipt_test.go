@@ -0,0 +1,85 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestServicesPay_WritesReceipt(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(5, 12))()
+	err := SetProvider(ServiceProvider{Service: "internet", Category: "internet", ReferenceLabel: "contract id",
+		ReferenceFormat: "digits", MinAmount: 1, Fee: 3, Active: true}, 1, db)
+	if err != nil {
+		t.Fatalf("can't set provider: %v", err)
+	}
+	receipt, err := ServicesPayOneCard("internet", "100200", 1, 200, db)
+	if err != nil {
+		t.Fatalf("can't pay service: %v", err)
+	}
+	want := Receipt{
+		Number:        "20260305-00000001",
+		TransactionId: 1,
+		Time:          at(5, 12),
+		MaskedPAN:     "202160******0000",
+		Service:       "internet",
+		Reference:     "100200",
+		Amount:        200,
+		Fee:           3,
+	}
+	if receipt != want {
+		t.Errorf("receipt just be %+v: %+v", want, receipt)
+	}
+	stored, err := ReceiptGet(receipt.Number, 1, db)
+	if err != nil {
+		t.Fatalf("can't get receipt: %v", err)
+	}
+	if !stored.Time.Equal(want.Time) {
+		t.Errorf("stored receipt time just be %v: %v", want.Time, stored.Time)
+	}
+	stored.Time = want.Time
+	if stored != want {
+		t.Errorf("stored receipt just be %+v: %+v", want, stored)
+	}
+	_, err = ReceiptGet(receipt.Number, 2, db)
+	if !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("someone else's receipt just be ErrReceiptNotFound: %v", err)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000000-203 {
+		t.Errorf("balance just be %d: %d", 1000000-203, balance)
+	}
+	statement, err := GetStatement(2021600000000000, at(1, 0), at(9, 0), db)
+	if err != nil {
+		t.Fatalf("can't get statement: %v", err)
+	}
+	if statement.OpeningBalance != 1000000 || len(statement.Entries) != 2 || statement.Entries[1].Kind != KindFee {
+		t.Errorf("statement just show the payment and the fee: %+v", statement)
+	}
+}
+
+func TestServicesPay_NoReceiptOnFailure(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := ServicesPayOneCard("internet", "100200", 1, 1000001, db)
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("overdraft just be ErrInsufficientFunds: %v", err)
+	}
+	var receipts int
+	err = db.QueryRow(`SELECT count(*) FROM receipts`).Scan(&receipts)
+	if err != nil {
+		t.Fatalf("can't count receipts: %v", err)
+	}
+	if receipts != 0 {
+		t.Errorf("failed payment just have no receipt: %d", receipts)
+	}
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A client may look up ReceiverLookupLimit different cards within
+// ReceiverLookupWindow. Looking up the same card again is free, so the
+// limit only gets in the way of trying PANs one after another.
+const ReceiverLookupLimit = 10
+const ReceiverLookupWindow = time.Hour
+
+// Card schemes by PAN prefix. SchemeLocal is any card outside them, the
+// bank's own 20216 cards among them.
+const SchemeVisa = "visa"
+const SchemeMastercard = "mastercard"
+const SchemeMir = "mir"
+const SchemeUnionPay = "unionpay"
+const SchemeKortiMilli = "korti-milli"
+const SchemeLocal = "local"
+
+var ErrTooManyLookups = errors.New("too many receiver lookups")
+
+// LookupLimitError tells the client when the oldest lookup leaves the
+// window and another card can be looked up.
+type LookupLimitError struct {
+	ClientId int
+	RetryAt  time.Time
+}
+
+func (e *LookupLimitError) Error() string {
+	return fmt.Sprintf("client %d: %v, retry at %s", e.ClientId, ErrTooManyLookups, e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+func (e *LookupLimitError) Is(target error) bool {
+	return target == ErrTooManyLookups
+}
+
+// Receiver is what a client may learn about a card before sending money
+// to it: enough to notice a mistyped PAN, nothing about its balance.
+type Receiver struct {
+	MaskedPAN  string
+	MaskedName string
+	Scheme     string
+}
+
+// LookupReceiver shows the client who gets the money sent to the card.
+// Lookups of cards that don't exist count toward the limit as well.
+func LookupReceiver(clientId int, pan int64, db *sql.DB) (Receiver, error) {
+	var holderName string
+	found := true
+	err := runTx(db, func(ctx context.Context, q queryer) error {
+		err := countLookup(ctx, q, clientId, pan)
+		if err != nil {
+			return err
+		}
+		err = q.QueryRowContext(ctx, getCardHolder, pan).Scan(&holderName)
+		if err == sql.ErrNoRows {
+			// the lookup still counts, so the transaction has to commit
+			found = false
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("can't get holder of card %d: %w", pan, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Receiver{}, err
+	}
+	if !found {
+		return Receiver{}, fmt.Errorf("card %s: %w", MaskPAN(pan), ErrCardNotFound)
+	}
+	return Receiver{MaskedPAN: MaskPAN(pan), MaskedName: MaskName(holderName), Scheme: CardScheme(pan)}, nil
+}
+
+// countLookup records the lookup unless the client has used up the window.
+func countLookup(ctx context.Context, q queryer, clientId int, pan int64) error {
+	at := now()
+	since := at.Add(-ReceiverLookupWindow).Unix()
+	_, err := q.ExecContext(ctx, deleteOldLookups, sql.Named("clientId", clientId), sql.Named("since", since))
+	if err != nil {
+		return fmt.Errorf("can't forget old lookups of client %d: %w", clientId, err)
+	}
+	var cards, seen int
+	var oldest sql.NullInt64
+	err = q.QueryRowContext(ctx, countLookups, sql.Named("clientId", clientId), sql.Named("pan", pan)).Scan(&cards, &seen, &oldest)
+	if err != nil {
+		return fmt.Errorf("can't count lookups of client %d: %w", clientId, err)
+	}
+	if seen == 0 && cards >= ReceiverLookupLimit {
+		return &LookupLimitError{ClientId: clientId, RetryAt: time.Unix(oldest.Int64, 0).Add(ReceiverLookupWindow)}
+	}
+	_, err = q.ExecContext(ctx, insertLookup,
+		sql.Named("clientId", clientId),
+		sql.Named("pan", pan),
+		sql.Named("lookedUpAt", at.Unix()),
+	)
+	if err != nil {
+		return fmt.Errorf("can't record lookup of client %d: %w", clientId, err)
+	}
+	return nil
+}
+
+// MaskName keeps the first letter of every word: "JACK JACKSON" becomes
+// "J*** J***".
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		first, _ := utf8.DecodeRuneInString(word)
+		words[i] = string(first) + "***"
+	}
+	return strings.Join(words, " ")
+}
+
+// CardScheme tells the scheme from the first digits of the PAN.
+func CardScheme(pan int64) string {
+	digits := strconv.FormatInt(pan, 10)
+	prefix := func(n int) int {
+		if len(digits) < n {
+			return -1
+		}
+		value, _ := strconv.Atoi(digits[:n])
+		return value
+	}
+	switch {
+	case prefix(1) == 4:
+		return SchemeVisa
+	case prefix(2) >= 51 && prefix(2) <= 55, prefix(4) >= 2221 && prefix(4) <= 2720:
+		return SchemeMastercard
+	case prefix(4) >= 2200 && prefix(4) <= 2204:
+		return SchemeMir
+	case prefix(2) == 62:
+		return SchemeUnionPay
+	case prefix(4) == 9762:
+		return SchemeKortiMilli
+	}
+	return SchemeLocal
+}
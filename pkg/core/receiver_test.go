@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaskName(t *testing.T) {
+	cases := map[string]string{
+		"JACK JACKSON":     "J*** J***",
+		" ADMIN   CLIENT ": "A*** C***",
+		"Шамсиддин":        "Ш***",
+		"":                 "",
+	}
+	for name, want := range cases {
+		if got := MaskName(name); got != want {
+			t.Errorf("%q just be masked as %q: %q", name, want, got)
+		}
+	}
+}
+
+func TestCardScheme(t *testing.T) {
+	cases := map[int64]string{
+		4111111111111111: SchemeVisa,
+		5500000000000004: SchemeMastercard,
+		2221000000000009: SchemeMastercard,
+		2200000000000004: SchemeMir,
+		6200000000000005: SchemeUnionPay,
+		9762000000000000: SchemeKortiMilli,
+		2021600000000000: SchemeLocal,
+		4:                SchemeVisa,
+	}
+	for pan, want := range cases {
+		if got := CardScheme(pan); got != want {
+			t.Errorf("%d just be %s: %s", pan, want, got)
+		}
+	}
+}
+
+func TestLookupReceiver(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	restore := setNow(at(2, 12))
+	defer restore()
+	receiver, err := LookupReceiver(2, 2021600000000000, db)
+	if err != nil {
+		t.Fatalf("can't look up receiver: %v", err)
+	}
+	if receiver != (Receiver{MaskedPAN: "202160******0000", MaskedName: "A*** C***", Scheme: SchemeLocal}) {
+		t.Errorf("receiver just be the masked admin card: %+v", receiver)
+	}
+	_, err = LookupReceiver(2, 404, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("unknown card just be ErrCardNotFound: %v", err)
+	}
+	for pan := int64(405); pan < 405+ReceiverLookupLimit-2; pan++ {
+		_, err = LookupReceiver(2, pan, db)
+		if !errors.Is(err, ErrCardNotFound) {
+			t.Fatalf("lookup %d just be counted: %v", pan, err)
+		}
+	}
+	setNow(at(2, 12).Add(30 * time.Minute))
+	_, err = LookupReceiver(2, 1000, db)
+	var limitErr *LookupLimitError
+	if !errors.Is(err, ErrTooManyLookups) || !errors.As(err, &limitErr) || !limitErr.RetryAt.Equal(at(2, 13)) {
+		t.Errorf("lookup over the limit just be ErrTooManyLookups until 13:00: %v", err)
+	}
+	_, err = LookupReceiver(2, 2021600000000000, db)
+	if err != nil {
+		t.Errorf("card looked up before just be looked up again: %v", err)
+	}
+	_, err = LookupReceiver(3, 2021600000000000, db)
+	if err != nil {
+		t.Errorf("another client just have a limit of their own: %v", err)
+	}
+	setNow(at(2, 13))
+	_, err = LookupReceiver(2, 1000, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("lookups just be allowed once the window moves: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const KindReversal = "reversal"
+
+type Reversal struct {
+	Id                    int64
+	TransactionId         int64
+	ReversalTransactionId int64
+	Amount                int
+	Reason                string
+	ManagerId             int
+	Time                  time.Time
+}
+
+// Reverse moves amount back along transaction transactionId and records the
+// manager who approved it. Zero amount reverses whatever is left of the
+// original. Transfers are only reversed in full, and only while the receiver
+// still has the money; service payments may be refunded in several parts up
+// to the amount paid. Blocked cards don't stop a reversal.
+func Reverse(transactionId int64, amount int, reason string, managerId int, db *sql.DB) (reversalId int64, err error) {
+	if amount < 0 {
+		return 0, ErrInvalidAmount
+	}
+	record := auditRecord{actor: fmt.Sprintf("manager %d", managerId), action: AuditReversal,
+		params: map[string]string{"transaction": fmt.Sprint(transactionId), "amount": fmt.Sprint(amount), "reason": reason}}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		original, err := transactionById(ctx, q, transactionId)
+		if err != nil {
+			return err
+		}
+		var reversed int
+		err = q.QueryRowContext(ctx, getReversedAmount, transactionId).Scan(&reversed)
+		if err != nil {
+			return fmt.Errorf("can't get reversals of transaction %d: %w", transactionId, err)
+		}
+		left := original.amount - reversed
+		if left <= 0 {
+			return fmt.Errorf("transaction %d: %w", transactionId, ErrAlreadyReversed)
+		}
+		refund := amount
+		if refund == 0 {
+			refund = left
+		}
+		if refund > left {
+			return fmt.Errorf("transaction %d has %d left to refund: %w", transactionId, left, ErrAlreadyReversed)
+		}
+		var compensation ledgerEntry
+		switch original.kind {
+		case KindTransfer:
+			if reversed > 0 {
+				return fmt.Errorf("transaction %d: %w", transactionId, ErrAlreadyReversed)
+			}
+			if refund != original.amount {
+				return fmt.Errorf("transfer %d can only be reversed in full: %w", transactionId, ErrNotReversible)
+			}
+			compensation, err = reverseTransfer(ctx, q, original)
+		case KindService:
+			compensation, err = refundService(ctx, q, original, refund)
+		default:
+			return fmt.Errorf("%s %d: %w", original.kind, transactionId, ErrNotReversible)
+		}
+		if err != nil {
+			return err
+		}
+		reversalTransactionId, err := recordTransaction(ctx, q, compensation)
+		if err != nil {
+			return err
+		}
+		result, err := q.ExecContext(ctx, insertReversal,
+			sql.Named("transactionId", transactionId),
+			sql.Named("reversalTransactionId", reversalTransactionId),
+			sql.Named("amount", refund),
+			sql.Named("reason", reason),
+			sql.Named("managerId", managerId),
+			sql.Named("createdAt", now().Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("can't record reversal of transaction %d: %w", transactionId, err)
+		}
+		reversalId, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("can't record reversal of transaction %d: %w", transactionId, err)
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return 0, auditFailure(db, record, err)
+	}
+	return reversalId, nil
+}
+
+func ReversalsGet(transactionId int64, db *sql.DB) (reversals []Reversal, err error) {
+	rows, err := db.Query(getReversals, transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get reversals of transaction %d: %w", transactionId, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close reversals: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var reversal Reversal
+		var createdAt int64
+		err = rows.Scan(&reversal.Id, &reversal.TransactionId, &reversal.ReversalTransactionId,
+			&reversal.Amount, &reversal.Reason, &reversal.ManagerId, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan reversal: %w", err)
+		}
+		reversal.Time = time.Unix(createdAt, 0)
+		reversals = append(reversals, reversal)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get reversals of transaction %d: %w", transactionId, err)
+	}
+	return reversals, nil
+}
+
+func checkManager(ctx context.Context, q queryer, managerId int) error {
+	var id int
+	err := q.QueryRowContext(ctx, getManagerId, managerId).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("manager %d: %w", managerId, ErrManagerNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("can't get manager %d: %w", managerId, err)
+	}
+	return nil
+}
+
+func transactionById(ctx context.Context, q queryer, transactionId int64) (entry ledgerEntry, err error) {
+	err = q.QueryRowContext(ctx, getTransaction, transactionId).Scan(
+		&entry.kind, &entry.fromCardId, &entry.toCardId, &entry.service, &entry.reference, &entry.amount)
+	if err == sql.ErrNoRows {
+		return entry, fmt.Errorf("transaction %d: %w", transactionId, ErrTransactionNotFound)
+	}
+	if err != nil {
+		return entry, fmt.Errorf("can't get transaction %d: %w", transactionId, err)
+	}
+	return entry, nil
+}
+
+func reverseTransfer(ctx context.Context, q queryer, original ledgerEntry) (ledgerEntry, error) {
+	err := lockCards(ctx, q, original.fromCardId, original.toCardId)
+	if err != nil {
+		return ledgerEntry{}, err
+	}
+	err = debitCard(ctx, q, original.toCardId, original.amount)
+	if err != nil {
+		return ledgerEntry{}, err
+	}
+	err = addToCard(ctx, q, original.fromCardId, original.amount)
+	if err != nil {
+		return ledgerEntry{}, err
+	}
+	return ledgerEntry{
+		kind:       KindReversal,
+		fromCardId: original.toCardId,
+		toCardId:   original.fromCardId,
+		amount:     original.amount,
+	}, nil
+}
+
+func refundService(ctx context.Context, q queryer, original ledgerEntry, amount int) (ledgerEntry, error) {
+	err := lockCards(ctx, q, original.fromCardId)
+	if err != nil {
+		return ledgerEntry{}, err
+	}
+	err = lockServiceRow(ctx, q, original.service)
+	if err != nil {
+		return ledgerEntry{}, err
+	}
+	var balance int
+	err = q.QueryRowContext(ctx, getServiceBalance, original.service).Scan(&balance)
+	if err != nil {
+		return ledgerEntry{}, fmt.Errorf("can't get balance of service %s: %w", original.service, err)
+	}
+	if balance < amount {
+		return ledgerEntry{}, fmt.Errorf("service %s has %d, needs %d: %w", original.service, balance, amount, ErrInsufficientFunds)
+	}
+	_, err = q.ExecContext(ctx, addServiceBalance,
+		sql.Named("amount", -amount),
+		sql.Named("serviceName", original.service),
+	)
+	if err != nil {
+		return ledgerEntry{}, fmt.Errorf("can't refund service %s: %w", original.service, err)
+	}
+	err = addToCard(ctx, q, original.fromCardId, amount)
+	if err != nil {
+		return ledgerEntry{}, err
+	}
+	return ledgerEntry{
+		kind:      KindReversal,
+		toCardId:  original.fromCardId,
+		service:   original.service,
+		reference: original.reference,
+		amount:    amount,
+	}, nil
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func lastTransactionId(t *testing.T, db *sql.DB) (id int64) {
+	err := db.QueryRow(`SELECT max(id) FROM transactions`).Scan(&id)
+	if err != nil {
+		t.Fatalf("can't get last transaction: %v", err)
+	}
+	return id
+}
+
+func TestReverse_Transfer(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 500, 'JACK JACKSON', 111, 0525, 1);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	_, err = MoreCard(2021600000000000, 2021600000000001, 300, db)
+	if err != nil {
+		t.Fatalf("can't execute transfer money: %v", err)
+	}
+	transactionId := lastTransactionId(t, db)
+	err = BlockCard(2021600000000001, "mistake", db)
+	if err != nil {
+		t.Fatalf("can't block card: %v", err)
+	}
+	_, err = Reverse(transactionId, 100, "wrong card", 1, db)
+	if !errors.Is(err, ErrNotReversible) {
+		t.Errorf("partial transfer reversal just be ErrNotReversible: %v", err)
+	}
+	_, err = Reverse(transactionId, 0, "wrong card", 404, db)
+	if !errors.Is(err, ErrManagerNotFound) {
+		t.Errorf("unknown manager just be ErrManagerNotFound: %v", err)
+	}
+	reversalId, err := Reverse(transactionId, 0, "wrong card", 1, db)
+	if err != nil {
+		t.Fatalf("can't reverse transfer: %v", err)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000001, db)
+	if balance != 500 {
+		t.Errorf("receiver balance just be 500: %d", balance)
+	}
+	balance, _, _ = GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000000 {
+		t.Errorf("sender balance just be 1000000: %d", balance)
+	}
+	_, err = Reverse(transactionId, 0, "again", 1, db)
+	if !errors.Is(err, ErrAlreadyReversed) {
+		t.Errorf("second reversal just be ErrAlreadyReversed: %v", err)
+	}
+	reversals, err := ReversalsGet(transactionId, db)
+	if err != nil {
+		t.Fatalf("can't get reversals: %v", err)
+	}
+	if len(reversals) != 1 || reversals[0].Id != reversalId || reversals[0].Amount != 300 ||
+		reversals[0].ManagerId != 1 || reversals[0].Reason != "wrong card" {
+		t.Errorf("reversals just be one of 300 by manager 1: %+v", reversals)
+	}
+	_, err = Reverse(reversals[0].ReversalTransactionId, 0, "undo", 1, db)
+	if !errors.Is(err, ErrNotReversible) {
+		t.Errorf("reversal of reversal just be ErrNotReversible: %v", err)
+	}
+}
+
+func TestReverse_ReceiverSpentFunds(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 0, 'JACK JACKSON', 111, 0525, 1);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	_, err = MoreCard(2021600000000000, 2021600000000001, 300, db)
+	if err != nil {
+		t.Fatalf("can't execute transfer money: %v", err)
+	}
+	transactionId := lastTransactionId(t, db)
+	_, err = ServicesPayMoreCard("internet", "100200", 2021600000000001, 200, db)
+	if err != nil {
+		t.Fatalf("can't pay service: %v", err)
+	}
+	_, err = Reverse(transactionId, 0, "wrong card", 1, db)
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("reversal of spent funds just be ErrInsufficientFunds: %v", err)
+	}
+	reversals, err := ReversalsGet(transactionId, db)
+	if err != nil {
+		t.Fatalf("can't get reversals: %v", err)
+	}
+	if len(reversals) != 0 {
+		t.Errorf("failed reversal just not be recorded: %+v", reversals)
+	}
+}
+
+func TestReverse_PartialServiceRefunds(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := ServicesPayMoreCard("internet", "100200", 2021600000000000, 500, db)
+	if err != nil {
+		t.Fatalf("can't pay service: %v", err)
+	}
+	transactionId := lastTransactionId(t, db)
+	_, err = Reverse(transactionId, 200, "wrong provider", 1, db)
+	if err != nil {
+		t.Fatalf("can't refund service: %v", err)
+	}
+	_, err = Reverse(transactionId, 400, "wrong provider", 1, db)
+	if !errors.Is(err, ErrAlreadyReversed) {
+		t.Errorf("refund over the paid amount just be ErrAlreadyReversed: %v", err)
+	}
+	_, err = Reverse(transactionId, 0, "wrong provider", 1, db)
+	if err != nil {
+		t.Fatalf("can't refund the rest: %v", err)
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000000, db)
+	if balance != 1000000 {
+		t.Errorf("payer balance just be 1000000: %d", balance)
+	}
+	var serviceBalance int
+	err = db.QueryRow(`SELECT balance FROM services WHERE service = 'internet'`).Scan(&serviceBalance)
+	if err != nil {
+		t.Fatalf("can't get service balance: %v", err)
+	}
+	if serviceBalance != 1500 {
+		t.Errorf("service balance just be 1500: %d", serviceBalance)
+	}
+	reversals, err := ReversalsGet(transactionId, db)
+	if err != nil {
+		t.Fatalf("can't get reversals: %v", err)
+	}
+	if len(reversals) != 2 || reversals[0].Amount != 200 || reversals[1].Amount != 300 {
+		t.Errorf("reversals just be 200 and 300: %+v", reversals)
+	}
+	_, err = Reverse(transactionId, 0, "again", 1, db)
+	if !errors.Is(err, ErrAlreadyReversed) {
+		t.Errorf("fully refunded payment just be ErrAlreadyReversed: %v", err)
+	}
+	_, err = Reverse(404, 0, "nothing", 1, db)
+	if !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("unknown transaction just be ErrTransactionNotFound: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("schedule rule is not valid")
+
+// Rule is a cron expression: "minute hour day-of-month month day-of-week".
+// Fields take *, numbers, ranges a-b, steps */n or a-b/n and comma lists.
+// As in cron, a day matches when either day field matches if both are set.
+type Rule struct {
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+var ruleAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// ruleHorizon bounds the search for rules like "0 0 31 2 *" that never fire.
+const ruleHorizon = 5 * 366 * 24 * time.Hour
+
+func ParseRule(spec string) (rule Rule, err error) {
+	if alias, ok := ruleAliases[spec]; ok {
+		spec = alias
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return Rule{}, fmt.Errorf("%q needs 5 fields: %w", spec, ErrInvalidRule)
+	}
+	bounds := []struct {
+		set      *uint64
+		min, max int
+	}{
+		{&rule.minutes, 0, 59},
+		{&rule.hours, 0, 23},
+		{&rule.days, 1, 31},
+		{&rule.months, 1, 12},
+		{&rule.weekdays, 0, 7},
+	}
+	for i, field := range fields {
+		*bounds[i].set, err = parseRuleField(field, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return Rule{}, fmt.Errorf("%q: %w", spec, err)
+		}
+	}
+	if rule.weekdays&(1<<7) != 0 {
+		rule.weekdays |= 1
+	}
+	rule.anyDay = fields[2] == "*"
+	rule.anyWeekday = fields[4] == "*"
+	return rule, nil
+}
+
+func parseRuleField(field string, min, max int) (set uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			stepped = true
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("step in %q: %w", part, ErrInvalidRule)
+			}
+			part = part[:i]
+		}
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			from, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("value %q: %w", part, ErrInvalidRule)
+			}
+			if !stepped {
+				to = from
+			}
+			if len(bounds) == 2 {
+				to, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, fmt.Errorf("value %q: %w", part, ErrInvalidRule)
+				}
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("%q is out of %d-%d: %w", part, min, max, ErrInvalidRule)
+		}
+		for value := from; value <= to; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first matching minute strictly after t, in t's location,
+// or the zero time if the rule never matches.
+func (r Rule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(ruleHorizon)
+	for t.Before(limit) {
+		switch {
+		case r.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !r.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case r.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case r.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (r Rule) matchesDay(t time.Time) bool {
+	day := r.days&(1<<uint(t.Day())) != 0
+	weekday := r.weekdays&(1<<uint(t.Weekday())) != 0
+	if r.anyDay || r.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
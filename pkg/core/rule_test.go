@@ -0,0 +1,45 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRule_Next(t *testing.T) {
+	from := time.Date(2020, 1, 31, 10, 30, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		rule string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2020, 1, 31, 10, 31, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2020, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2020, 1, 31, 10, 40, 0, 0, time.UTC)},
+		{"0 9 1 * *", time.Date(2020, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 9 31 * *", time.Date(2020, 3, 31, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 1-5", time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2020, 2, 2, 12, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 1", time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	} {
+		rule, err := ParseRule(tt.rule)
+		if err != nil {
+			t.Errorf("can't parse %q: %v", tt.rule, err)
+			continue
+		}
+		got := rule.Next(from)
+		if !got.Equal(tt.want) {
+			t.Errorf("next of %q just be %v: %v", tt.rule, tt.want, got)
+		}
+	}
+}
+
+func TestParseRule_Invalid(t *testing.T) {
+	for _, rule := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseRule(rule)
+		if !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("%q just be ErrInvalidRule: %v", rule, err)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const ScheduleActive = "active"
+const SchedulePaused = "paused"
+const ScheduleCancelled = "cancelled"
+
+const defaultRetryDelay = time.Hour
+const defaultMaxAttempts = 3
+
+var ErrScheduleNotFound = errors.New("schedule not found")
+var ErrScheduleCancelled = errors.New("schedule is cancelled")
+
+type Schedule struct {
+	Id        int64
+	PAN       int64
+	Service   string
+	Reference string
+	Amount    int
+	Rule      string
+	Status    string
+	NextRun   time.Time
+	Attempts  int
+}
+
+// ScheduleRun is the outcome of one attempt: a transaction or an error.
+type ScheduleRun struct {
+	Id            int64
+	Time          time.Time
+	Attempt       int
+	TransactionId int64
+	Error         string
+}
+
+// CreateSchedule sets up a standing order paying amount to the customer
+// account reference at service from the client's card whenever rule fires.
+func CreateSchedule(clientId int, pan int64, service, reference, rule string, amount int, db *sql.DB) (id int64, err error) {
+	return createSchedule(now(), clientId, pan, service, reference, rule, amount, db)
+}
+
+func createSchedule(at time.Time, clientId int, pan int64, service, reference, rule string, amount int, db *sql.DB) (id int64, err error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	parsed, err := ParseRule(rule)
+	if err != nil {
+		return 0, err
+	}
+	nextRun := parsed.Next(at)
+	if nextRun.IsZero() {
+		return 0, fmt.Errorf("%q never fires: %w", rule, ErrInvalidRule)
+	}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		var cardId int64
+		err := q.QueryRowContext(ctx, getCardIdByClientPAN, pan, clientId).Scan(&cardId)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("card %d of client %d: %w", pan, clientId, ErrCardNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("can't get card %d: %w", pan, err)
+		}
+		provider, err := providerByName(ctx, q, service)
+		if err != nil {
+			return err
+		}
+		err = provider.Validate(reference, amount)
+		if err != nil {
+			return err
+		}
+		result, err := q.ExecContext(ctx, insertSchedule,
+			sql.Named("idCard", cardId),
+			sql.Named("service", service),
+			sql.Named("reference", reference),
+			sql.Named("amount", amount),
+			sql.Named("rule", rule),
+			sql.Named("nextRunAt", nextRun.Unix()),
+			sql.Named("createdAt", at.Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("can't create schedule: %w", err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("can't create schedule: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func SchedulesGet(clientId int, db *sql.DB) (schedules []Schedule, err error) {
+	rows, err := db.Query(getSchedulesByClient, clientId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get schedules of client %d: %w", clientId, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close schedules: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var schedule Schedule
+		var nextRunAt int64
+		err = rows.Scan(&schedule.Id, &schedule.PAN, &schedule.Service, &schedule.Reference, &schedule.Amount,
+			&schedule.Rule, &schedule.Status, &nextRunAt, &schedule.Attempts)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan schedule: %w", err)
+		}
+		schedule.NextRun = time.Unix(nextRunAt, 0)
+		schedules = append(schedules, schedule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get schedules of client %d: %w", clientId, err)
+	}
+	return schedules, nil
+}
+
+func ScheduleRunsGet(scheduleId int64, clientId int, db *sql.DB) (runs []ScheduleRun, err error) {
+	rows, err := db.Query(getScheduleRuns, scheduleId, clientId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get runs of schedule %d: %w", scheduleId, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close schedule runs: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var run ScheduleRun
+		var runAt int64
+		err = rows.Scan(&run.Id, &runAt, &run.Attempt, &run.TransactionId, &run.Error)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan schedule run: %w", err)
+		}
+		run.Time = time.Unix(runAt, 0)
+		runs = append(runs, run)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get runs of schedule %d: %w", scheduleId, err)
+	}
+	return runs, nil
+}
+
+// PauseSchedule stops a schedule from firing until ResumeSchedule.
+func PauseSchedule(scheduleId int64, clientId int, db *sql.DB) error {
+	return setStatus(now(), scheduleId, clientId, SchedulePaused, db)
+}
+
+// ResumeSchedule restarts a paused schedule from its next occurrence;
+// payments missed while paused are not made up.
+func ResumeSchedule(scheduleId int64, clientId int, db *sql.DB) error {
+	return setStatus(now(), scheduleId, clientId, ScheduleActive, db)
+}
+
+func CancelSchedule(scheduleId int64, clientId int, db *sql.DB) error {
+	return setStatus(now(), scheduleId, clientId, ScheduleCancelled, db)
+}
+
+func setStatus(at time.Time, scheduleId int64, clientId int, status string, db *sql.DB) error {
+	return runTx(db, func(ctx context.Context, q queryer) error {
+		var current, rule string
+		err := q.QueryRowContext(ctx, getScheduleStatus, scheduleId, clientId).Scan(&current, &rule)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("schedule %d of client %d: %w", scheduleId, clientId, ErrScheduleNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("can't get schedule %d: %w", scheduleId, err)
+		}
+		if current == ScheduleCancelled {
+			return fmt.Errorf("schedule %d: %w", scheduleId, ErrScheduleCancelled)
+		}
+		if current == status {
+			return nil
+		}
+		parsed, err := ParseRule(rule)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, setScheduleStatus,
+			sql.Named("status", status),
+			sql.Named("nextRunAt", parsed.Next(at).Unix()),
+			sql.Named("id", scheduleId),
+		)
+		if err != nil {
+			return fmt.Errorf("can't set schedule %d %s: %w", scheduleId, status, err)
+		}
+		return nil
+	})
+}
+
+// Scheduler makes the payments of due schedules. A failed payment is
+// retried after RetryDelay up to MaxAttempts times, then that occurrence
+// is skipped. Occurrences missed while the scheduler was down are paid
+// once, not once per occurrence.
+type Scheduler struct {
+	db          *sql.DB
+	Now         func() time.Time
+	RetryDelay  time.Duration
+	MaxAttempts int
+	// OnError receives the errors Run can't return without stopping.
+	OnError func(error)
+}
+
+func NewScheduler(db *sql.DB) *Scheduler {
+	return &Scheduler{db: db, Now: time.Now, RetryDelay: defaultRetryDelay, MaxAttempts: defaultMaxAttempts}
+}
+
+// CreateSchedule is CreateSchedule on the scheduler's clock, so the same
+// Now sets up the schedule and runs it.
+func (s *Scheduler) CreateSchedule(clientId int, pan int64, service, reference, rule string, amount int) (id int64, err error) {
+	return createSchedule(s.Now(), clientId, pan, service, reference, rule, amount, s.db)
+}
+
+// PauseSchedule is PauseSchedule on the scheduler's clock.
+func (s *Scheduler) PauseSchedule(scheduleId int64, clientId int) error {
+	return setStatus(s.Now(), scheduleId, clientId, SchedulePaused, s.db)
+}
+
+// ResumeSchedule is ResumeSchedule on the scheduler's clock: the schedule
+// restarts from its first occurrence after the scheduler's Now.
+func (s *Scheduler) ResumeSchedule(scheduleId int64, clientId int) error {
+	return setStatus(s.Now(), scheduleId, clientId, ScheduleActive, s.db)
+}
+
+type dueSchedule struct {
+	id        int64
+	cardId    int64
+	service   string
+	reference string
+	amount    int
+	rule      string
+	attempts  int
+}
+
+// Run calls RunDue every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := s.RunDue()
+		if err != nil && s.OnError != nil {
+			s.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue attempts every schedule due by Now and returns how many payments
+// went through. Failed payments are recorded, not returned.
+func (s *Scheduler) RunDue() (paid int, err error) {
+	at := s.Now()
+	due, err := s.dueSchedules(at)
+	if err != nil {
+		return 0, err
+	}
+	for _, schedule := range due {
+		ok, err := s.attempt(schedule, at)
+		if err != nil {
+			return paid, err
+		}
+		if ok {
+			paid++
+		}
+	}
+	return paid, nil
+}
+
+func (s *Scheduler) dueSchedules(at time.Time) (due []dueSchedule, err error) {
+	rows, err := s.db.Query(getDueSchedules, at.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("can't get due schedules: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close due schedules: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		var schedule dueSchedule
+		err = rows.Scan(&schedule.id, &schedule.cardId, &schedule.service, &schedule.reference,
+			&schedule.amount, &schedule.rule, &schedule.attempts)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan due schedule: %w", err)
+		}
+		due = append(due, schedule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get due schedules: %w", err)
+	}
+	return due, nil
+}
+
+// attempt pays one schedule and records the outcome. The schedule row is
+// claimed in the same transaction, so concurrent schedulers pay it once.
+func (s *Scheduler) attempt(schedule dueSchedule, at time.Time) (paid bool, err error) {
+	rule, err := ParseRule(schedule.rule)
+	if err != nil {
+		return false, err
+	}
+	attempt := schedule.attempts + 1
+	var claimed bool
+	payErr := runTx(s.db, func(ctx context.Context, q queryer) error {
+		var err error
+		claimed, err = claimSchedule(ctx, q, schedule.id, rule.Next(at), 0, at)
+		if err != nil || !claimed {
+			return err
+		}
+		receipt, err := payService(ctx, q, schedule.cardId, schedule.service, schedule.reference, schedule.amount)
+		if err != nil {
+			return err
+		}
+		err = appendAudit(ctx, q, schedule.record(), AuditOK)
+		if err != nil {
+			return err
+		}
+		return recordRun(ctx, q, schedule.id, at, attempt, receipt.TransactionId, nil)
+	})
+	if payErr == nil {
+		return claimed, nil
+	}
+	if payErr == errNeedsBiller {
+		return s.attemptThroughBiller(schedule, rule, at, attempt)
+	}
+	nextRun, attempts := at.Add(s.RetryDelay), attempt
+	if attempt >= s.MaxAttempts {
+		nextRun, attempts = rule.Next(at), 0
+	}
+	err = runTx(s.db, func(ctx context.Context, q queryer) error {
+		claimed, err := claimSchedule(ctx, q, schedule.id, nextRun, attempts, at)
+		if err != nil || !claimed {
+			return err
+		}
+		err = appendAudit(ctx, q, schedule.record(), payErr.Error())
+		if err != nil {
+			return err
+		}
+		return recordRun(ctx, q, schedule.id, at, attempt, 0, payErr)
+	})
+	if err != nil {
+		return false, fmt.Errorf("can't record failure of schedule %d: %w", schedule.id, err)
+	}
+	return false, nil
+}
+
+// attemptThroughBiller claims the occurrence before paying, because a
+// payment through a biller spans several transactions. A scheduler that
+// dies in between skips the occurrence rather than paying it twice, and a
+// payment left pending is not retried.
+func (s *Scheduler) attemptThroughBiller(schedule dueSchedule, rule Rule, at time.Time, attempt int) (paid bool, err error) {
+	nextRun := rule.Next(at)
+	var claimed bool
+	err = runTx(s.db, func(ctx context.Context, q queryer) error {
+		var err error
+		claimed, err = claimSchedule(ctx, q, schedule.id, nextRun, 0, at)
+		return err
+	})
+	if err != nil || !claimed {
+		return false, err
+	}
+	receipt, payErr := payThroughBiller(schedule.service, schedule.reference, schedule.amount, s.db,
+		func(ctx context.Context, q queryer) (int64, error) {
+			return schedule.cardId, nil
+		})
+	err = runTx(s.db, func(ctx context.Context, q queryer) error {
+		if payErr != nil && !errors.Is(payErr, ErrPaymentPending) && attempt < s.MaxAttempts {
+			_, err := q.ExecContext(ctx, retrySchedule,
+				sql.Named("retryAt", at.Add(s.RetryDelay).Unix()),
+				sql.Named("attempts", attempt),
+				sql.Named("id", schedule.id),
+				sql.Named("claimedRunAt", nextRun.Unix()),
+			)
+			if err != nil {
+				return fmt.Errorf("can't retry schedule %d: %w", schedule.id, err)
+			}
+		}
+		result := AuditOK
+		if payErr != nil {
+			result = payErr.Error()
+		}
+		err := appendAudit(ctx, q, schedule.record(), result)
+		if err != nil {
+			return err
+		}
+		return recordRun(ctx, q, schedule.id, at, attempt, receipt.TransactionId, payErr)
+	})
+	if err != nil {
+		return false, fmt.Errorf("can't record run of schedule %d: %w", schedule.id, err)
+	}
+	return payErr == nil, nil
+}
+
+// record is how a payment of the schedule is audited.
+func (schedule dueSchedule) record() auditRecord {
+	return auditRecord{actor: fmt.Sprintf("schedule %d", schedule.id), action: AuditServicePayment,
+		params: map[string]string{"service": schedule.service, "reference": schedule.reference, "amount": fmt.Sprint(schedule.amount)}}
+}
+
+func claimSchedule(ctx context.Context, q queryer, scheduleId int64, nextRun time.Time, attempts int, at time.Time) (bool, error) {
+	result, err := q.ExecContext(ctx, claimDueSchedule,
+		sql.Named("nextRunAt", nextRun.Unix()),
+		sql.Named("attempts", attempts),
+		sql.Named("id", scheduleId),
+		sql.Named("at", at.Unix()),
+	)
+	if err != nil {
+		return false, fmt.Errorf("can't claim schedule %d: %w", scheduleId, err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("can't claim schedule %d: %w", scheduleId, err)
+	}
+	return claimed == 1, nil
+}
+
+func recordRun(ctx context.Context, q queryer, scheduleId int64, at time.Time, attempt int, transactionId int64, runErr error) error {
+	message := ""
+	if runErr != nil {
+		message = runErr.Error()
+	}
+	_, err := q.ExecContext(ctx, insertScheduleRun,
+		sql.Named("scheduleId", scheduleId),
+		sql.Named("runAt", at.Unix()),
+		sql.Named("attempt", attempt),
+		sql.Named("transactionId", transactionId),
+		sql.Named("error", message),
+	)
+	if err != nil {
+		return fmt.Errorf("can't record run of schedule %d: %w", scheduleId, err)
+	}
+	return nil
+}
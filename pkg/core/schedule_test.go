@@ -0,0 +1,186 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestScheduler_PaysAndRetries(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(1, 0))()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 150, 'ADMIN C', 111, 0525, 1);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	scheduleId, err := CreateSchedule(1, 2021600000000001, "internet", "100200", "0 9 * * *", 100, db)
+	if err != nil {
+		t.Fatalf("can't create schedule: %v", err)
+	}
+	var clock time.Time
+	scheduler := NewScheduler(db)
+	scheduler.Now = func() time.Time { return clock }
+	run := func(now time.Time, want int) {
+		t.Helper()
+		clock = now
+		paid, err := scheduler.RunDue()
+		if err != nil {
+			t.Fatalf("can't run due schedules at %v: %v", now, err)
+		}
+		if paid != want {
+			t.Errorf("payments at %v just be %d: %d", now, want, paid)
+		}
+	}
+	run(at(1, 8), 0)
+	run(at(1, 9), 1)
+	run(at(1, 9), 0)
+	run(at(2, 9), 0)
+	run(at(2, 10), 0)
+	run(at(2, 11), 0)
+	run(at(2, 12), 0)
+	_, err = db.Exec(`UPDATE clients_cards SET balance = 1000 WHERE id = 2`)
+	if err != nil {
+		t.Fatalf("can't top up card: %v", err)
+	}
+	run(at(3, 9), 1)
+
+	runs, err := ScheduleRunsGet(scheduleId, 1, db)
+	if err != nil {
+		t.Fatalf("can't get runs: %v", err)
+	}
+	want := []struct {
+		attempt int
+		paid    bool
+	}{{1, true}, {1, false}, {2, false}, {3, false}, {1, true}}
+	if len(runs) != len(want) {
+		t.Fatalf("runs just be %d: %+v", len(want), runs)
+	}
+	for i, run := range runs {
+		if run.Attempt != want[i].attempt || (run.TransactionId != 0) != want[i].paid || (run.Error == "") != want[i].paid {
+			t.Errorf("run %d just be attempt %d paid %v: %+v", i, want[i].attempt, want[i].paid, run)
+		}
+	}
+	balance, _, _ := GetCurrentBalanceClientPAN(2021600000000001, db)
+	if balance != 900 {
+		t.Errorf("balance just be 900: %d", balance)
+	}
+	entries, err := AuditLog(AuditFilter{Action: AuditServicePayment}, db)
+	if err != nil || len(entries) != len(want) {
+		t.Fatalf("every run just be audited: %+v %v", entries, err)
+	}
+	for i, entry := range entries {
+		if entry.Actor != fmt.Sprintf("schedule %d", scheduleId) || (entry.Result == AuditOK) != want[i].paid {
+			t.Errorf("run %d just be audited paid %v: %+v", i, want[i].paid, entry)
+		}
+	}
+}
+
+func TestScheduler_OwnClock(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	clock := at(1, 0)
+	scheduler := NewScheduler(db)
+	scheduler.Now = func() time.Time { return clock }
+	scheduleId, err := scheduler.CreateSchedule(1, 2021600000000000, "internet", "100200", "0 9 * * *", 100)
+	if err != nil {
+		t.Fatalf("can't create schedule: %v", err)
+	}
+	schedules, err := SchedulesGet(1, db)
+	if err != nil || len(schedules) != 1 || !schedules[0].NextRun.Equal(at(1, 9)) {
+		t.Fatalf("schedule just first run at 9:00 by the scheduler's clock: %+v %v", schedules, err)
+	}
+	clock = at(1, 9)
+	paid, err := scheduler.RunDue()
+	if err != nil || paid != 1 {
+		t.Errorf("schedule just be paid at 9:00: %d %v", paid, err)
+	}
+	err = scheduler.PauseSchedule(scheduleId, 1)
+	if err != nil {
+		t.Fatalf("can't pause schedule: %v", err)
+	}
+	clock = at(4, 12)
+	err = scheduler.ResumeSchedule(scheduleId, 1)
+	if err != nil {
+		t.Fatalf("can't resume schedule: %v", err)
+	}
+	schedules, err = SchedulesGet(1, db)
+	if err != nil || len(schedules) != 1 || !schedules[0].NextRun.Equal(at(5, 9)) {
+		t.Errorf("resumed schedule just run next on the 5th: %+v %v", schedules, err)
+	}
+}
+
+func TestSchedules_PauseResumeCancel(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	defer setNow(at(1, 0))()
+	_, err := CreateSchedule(1, 2021600000000000, "water", "100200", "@monthly", 100, db)
+	if !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("unknown service just be ErrServiceNotFound: %v", err)
+	}
+	_, err = CreateSchedule(2, 2021600000000000, "internet", "100200", "@monthly", 100, db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("someone else's card just be ErrCardNotFound: %v", err)
+	}
+	_, err = CreateSchedule(1, 2021600000000000, "internet", "100200", "monthly", 100, db)
+	if !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("bad rule just be ErrInvalidRule: %v", err)
+	}
+	scheduleId, err := CreateSchedule(1, 2021600000000000, "internet", "100200", "0 9 * * *", 100, db)
+	if err != nil {
+		t.Fatalf("can't create schedule: %v", err)
+	}
+	err = PauseSchedule(scheduleId, 2, db)
+	if !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("someone else's schedule just be ErrScheduleNotFound: %v", err)
+	}
+	err = PauseSchedule(scheduleId, 1, db)
+	if err != nil {
+		t.Fatalf("can't pause schedule: %v", err)
+	}
+	scheduler := NewScheduler(db)
+	scheduler.Now = func() time.Time { return at(3, 9) }
+	paid, err := scheduler.RunDue()
+	if err != nil || paid != 0 {
+		t.Errorf("paused schedule just not pay: %d, %v", paid, err)
+	}
+
+	defer setNow(at(3, 10))()
+	err = ResumeSchedule(scheduleId, 1, db)
+	if err != nil {
+		t.Fatalf("can't resume schedule: %v", err)
+	}
+	schedules, err := SchedulesGet(1, db)
+	if err != nil {
+		t.Fatalf("can't get schedules: %v", err)
+	}
+	if len(schedules) != 1 || schedules[0].Status != ScheduleActive || !schedules[0].NextRun.Equal(at(4, 9)) {
+		t.Errorf("schedule just be active from the 4th: %+v", schedules)
+	}
+	err = CancelSchedule(scheduleId, 1, db)
+	if err != nil {
+		t.Fatalf("can't cancel schedule: %v", err)
+	}
+	err = ResumeSchedule(scheduleId, 1, db)
+	if !errors.Is(err, ErrScheduleCancelled) {
+		t.Errorf("resumed cancelled schedule just be ErrScheduleCancelled: %v", err)
+	}
+	scheduler.Now = func() time.Time { return at(5, 9) }
+	paid, err = scheduler.RunDue()
+	if err != nil || paid != 0 {
+		t.Errorf("cancelled schedule just not pay: %d, %v", paid, err)
+	}
+}
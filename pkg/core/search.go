@@ -0,0 +1,172 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidPageToken = errors.New("page token is not valid")
+var ErrInvalidSort = errors.New("sort is not valid")
+
+// ATMsSearch page sizes.
+const DefaultATMsPage = 20
+const MaxATMsPage = 100
+
+// ATM sort orders. Every order breaks ties by id.
+const SortById = "id"
+const SortByCity = "city"
+const SortByDistrict = "district"
+const SortByStreet = "street"
+
+// ATMQuery filters ATMs. City and District match whole names, Street
+// matches any part of the street; all of them ignore case. Empty fields
+// match everything.
+type ATMQuery struct {
+	ATMFilter
+	City     string
+	District string
+	Street   string
+	// Sort is one of the Sort constants, SortById when empty.
+	Sort string
+	// Limit is the page size, DefaultATMsPage when zero.
+	Limit int
+	// PageToken is the NextPageToken of the previous page.
+	PageToken string
+}
+
+// ATMPage is one page of ATMs. NextPageToken is empty on the last page.
+type ATMPage struct {
+	ATMs          []Atm
+	NextPageToken string
+}
+
+// pageCursor is the last ATM of a page: the next page starts after it. ATMs
+// inserted meanwhile show up on later pages only if they sort after it, and
+// no ATM is ever seen twice.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Id    int64  `json:"i"`
+}
+
+// ATMsSearch returns one page of the ATMs that match the query.
+func ATMsSearch(query ATMQuery, db *sql.DB) (page ATMPage, err error) {
+	if query.Sort == "" {
+		query.Sort = SortById
+	}
+	switch query.Sort {
+	case SortById, SortByCity, SortByDistrict, SortByStreet:
+	default:
+		return ATMPage{}, fmt.Errorf("sort %q: %w", query.Sort, ErrInvalidSort)
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultATMsPage
+	}
+	if query.Limit > MaxATMsPage {
+		query.Limit = MaxATMsPage
+	}
+	var after pageCursor
+	if query.PageToken != "" {
+		after, err = decodePageToken(query.PageToken)
+		if err != nil {
+			return ATMPage{}, err
+		}
+		if after.Sort != query.Sort {
+			return ATMPage{}, fmt.Errorf("page of sort %q asked with sort %q: %w", after.Sort, query.Sort, ErrInvalidPageToken)
+		}
+	}
+	// the database can't tell which ATMs are open: batches are read until
+	// enough of them are
+	at := now()
+	for {
+		batch, err := searchBatch(query, after, db)
+		if err != nil {
+			return ATMPage{}, err
+		}
+		for _, atm := range batch {
+			if atm.matches(query.ATMFilter, at) {
+				page.ATMs = append(page.ATMs, atm)
+			}
+		}
+		if len(page.ATMs) > query.Limit || len(batch) <= query.Limit {
+			break
+		}
+		after = cursorOf(batch[len(batch)-1], query.Sort)
+	}
+	if len(page.ATMs) > query.Limit {
+		page.ATMs = page.ATMs[:query.Limit]
+		page.NextPageToken = encodePageToken(cursorOf(page.ATMs[len(page.ATMs)-1], query.Sort))
+	}
+	return page, nil
+}
+
+// searchBatch reads up to query.Limit+1 ATMs after the cursor.
+func searchBatch(query ATMQuery, after pageCursor, db *sql.DB) (atms []Atm, err error) {
+	rows, err := db.Query(searchAtms, append(filterArgs(query.ATMFilter),
+		sql.Named("city", query.City),
+		sql.Named("district", query.District),
+		sql.Named("street", escapeLike(query.Street)),
+		sql.Named("sort", query.Sort),
+		sql.Named("afterValue", after.Value),
+		sql.Named("afterId", after.Id),
+		sql.Named("limit", query.Limit+1),
+	)...)
+	if err != nil {
+		return nil, fmt.Errorf("can't search atms: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close atms: %w", cerr)
+		}
+	}()
+	for rows.Next() {
+		atm, err := scanAtm(rows)
+		if err != nil {
+			return nil, err
+		}
+		atms = append(atms, atm)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't search atms: %w", err)
+	}
+	return atms, nil
+}
+
+func cursorOf(atm Atm, sort string) pageCursor {
+	cursor := pageCursor{Sort: sort, Id: atm.Id}
+	switch sort {
+	case SortByCity:
+		cursor.Value = atm.City
+	case SortByDistrict:
+		cursor.Value = atm.District
+	case SortByStreet:
+		cursor.Value = atm.Street
+	}
+	return cursor
+}
+
+func encodePageToken(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token string) (cursor pageCursor, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("%v: %w", err, ErrInvalidPageToken)
+	}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.Id <= 0 {
+		return pageCursor{}, fmt.Errorf("page token %q: %w", token, ErrInvalidPageToken)
+	}
+	return cursor, nil
+}
+
+// escapeLike makes a LIKE pattern of the searchAtms query match text as is.
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
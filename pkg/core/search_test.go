@@ -0,0 +1,114 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func insertAtm(t *testing.T, db *sql.DB, id int64, city, district, street string) {
+	_, err := db.Exec(`INSERT INTO atms VALUES (?, ?, ?, ?)`, id, city, district, street)
+	if err != nil {
+		t.Fatalf("can't insert atm %d: %v", id, err)
+	}
+}
+
+func atmIds(atms []Atm) (ids []int64) {
+	for _, atm := range atms {
+		ids = append(ids, atm.Id)
+	}
+	return ids
+}
+
+func TestATMsSearch_Filters(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	insertAtm(t, db, 2, "Dushanbe", "Sino", "Rudaki 10")
+	insertAtm(t, db, 3, "Khujand", "Center", "Lenin 5")
+	insertAtm(t, db, 4, "Dushanbe", "Somoni", "Rudaki 100%")
+	cases := []struct {
+		name  string
+		query ATMQuery
+		ids   []int64
+	}{
+		{"everything", ATMQuery{}, []int64{1, 2, 3, 4}},
+		{"city", ATMQuery{City: "dushanbe"}, []int64{1, 2, 4}},
+		{"district", ATMQuery{City: "Dushanbe", District: "SOMONI"}, []int64{1, 4}},
+		{"street", ATMQuery{Street: "rudaki"}, []int64{2, 4}},
+		{"street with wildcards", ATMQuery{Street: "0%"}, []int64{4}},
+		{"street with underscore", ATMQuery{Street: "_"}, nil},
+		{"sort by street", ATMQuery{Sort: SortByStreet}, []int64{1, 3, 2, 4}},
+		{"sort by district", ATMQuery{Sort: SortByDistrict}, []int64{3, 2, 1, 4}},
+	}
+	for _, c := range cases {
+		page, err := ATMsSearch(c.query, db)
+		if err != nil {
+			t.Errorf("%s: can't search atms: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(atmIds(page.ATMs), c.ids) || page.NextPageToken != "" {
+			t.Errorf("%s just be %v: %v %q", c.name, c.ids, atmIds(page.ATMs), page.NextPageToken)
+		}
+	}
+	_, err := ATMsSearch(ATMQuery{Sort: "pin"}, db)
+	if !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("unknown sort just be ErrInvalidSort: %v", err)
+	}
+}
+
+func TestATMsSearch_Pages(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	insertAtm(t, db, 2, "Dushanbe", "Sino", "Bukhoro 3")
+	insertAtm(t, db, 3, "Dushanbe", "Sino", "Ismoili 7")
+	insertAtm(t, db, 4, "Dushanbe", "Sino", "Rudaki 10")
+	insertAtm(t, db, 5, "Dushanbe", "Sino", "Vahdat 2")
+	query := ATMQuery{Sort: SortByStreet, Limit: 2}
+	page, err := ATMsSearch(query, db)
+	if err != nil {
+		t.Fatalf("can't search atms: %v", err)
+	}
+	if !reflect.DeepEqual(atmIds(page.ATMs), []int64{2, 1}) || page.NextPageToken == "" {
+		t.Fatalf("first page just be 2 and 1: %v %q", atmIds(page.ATMs), page.NextPageToken)
+	}
+	// one ATM sorts before the cursor and one after it
+	insertAtm(t, db, 6, "Dushanbe", "Sino", "Aini 1")
+	insertAtm(t, db, 7, "Dushanbe", "Sino", "Somoni 9")
+	var seen []int64
+	seen = append(seen, atmIds(page.ATMs)...)
+	for page.NextPageToken != "" {
+		query.PageToken = page.NextPageToken
+		page, err = ATMsSearch(query, db)
+		if err != nil {
+			t.Fatalf("can't search atms: %v", err)
+		}
+		if len(page.ATMs) > 2 {
+			t.Errorf("page just be of 2 atms: %v", atmIds(page.ATMs))
+		}
+		seen = append(seen, atmIds(page.ATMs)...)
+	}
+	if !reflect.DeepEqual(seen, []int64{2, 1, 3, 4, 7, 5}) {
+		t.Errorf("pages just go on after the cursor, with no atm twice: %v", seen)
+	}
+	_, err = ATMsSearch(ATMQuery{Sort: SortByCity, PageToken: query.PageToken}, db)
+	if !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("token of another sort just be ErrInvalidPageToken: %v", err)
+	}
+	_, err = ATMsSearch(ATMQuery{PageToken: "not a token"}, db)
+	if !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("garbage token just be ErrInvalidPageToken: %v", err)
+	}
+	all, err := ATMsGet(db)
+	if err != nil || len(all) != 7 {
+		t.Errorf("ATMsGet just return every atm: %v %v", atmIds(all), err)
+	}
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const SettlementSettled = "settled"
+const SettlementVoided = "voided"
+
+var ErrNothingToSettle = errors.New("nothing to settle")
+var ErrSettlementNotFound = errors.New("settlement not found")
+var ErrSettlementVoided = errors.New("settlement is voided")
+
+// SettlementEntry is a payment or, with a negative amount, a refund that a
+// batch passes on to the provider.
+type SettlementEntry struct {
+	TransactionId int64
+	Time          time.Time
+	Reference     string
+	Amount        int
+}
+
+// ProviderSettlement is what one service is paid out in a batch. Total may
+// be negative when refunds outweigh payments.
+type ProviderSettlement struct {
+	Service string
+	Entries []SettlementEntry
+	Total   int
+}
+
+type Settlement struct {
+	Id        int64
+	Cutoff    time.Time
+	Status    string
+	Time      time.Time
+	VoidedAt  time.Time
+	Providers []ProviderSettlement
+}
+
+// Settle gathers every service payment and refund made before cutoff that
+// no other settled batch holds, and pays the totals out of the service
+// balances. Payments a voided batch held are settled again.
+func Settle(cutoff time.Time, db *sql.DB) (settlement Settlement, err error) {
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		createdAt := now()
+		result, err := q.ExecContext(ctx, insertSettlement,
+			sql.Named("cutoff", cutoff.Unix()),
+			sql.Named("createdAt", createdAt.Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("can't create settlement: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("can't create settlement: %w", err)
+		}
+		result, err = q.ExecContext(ctx, settleTransactions,
+			sql.Named("id", id),
+			sql.Named("cutoff", cutoff.Unix()),
+		)
+		if err != nil {
+			return fmt.Errorf("can't settle transactions: %w", err)
+		}
+		settled, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("can't settle transactions: %w", err)
+		}
+		if settled == 0 {
+			return fmt.Errorf("before %s: %w", cutoff.UTC().Format(time.RFC3339), ErrNothingToSettle)
+		}
+		settlement, err = settlementById(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		return payOut(ctx, q, settlement.Providers, -1)
+	})
+	if err != nil {
+		return Settlement{}, err
+	}
+	return settlement, nil
+}
+
+// SettlementGet reads a batch back, so its files can be written again.
+func SettlementGet(id int64, db *sql.DB) (settlement Settlement, err error) {
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		settlement, err = settlementById(ctx, q, id)
+		return err
+	})
+	if err != nil {
+		return Settlement{}, err
+	}
+	return settlement, nil
+}
+
+// VoidSettlement returns a batch's totals to the service balances and frees
+// its payments for the next batch on behalf of the manager. The batch
+// itself is kept for the record.
+func VoidSettlement(id int64, managerId int, db *sql.DB) error {
+	record := auditRecord{actor: fmt.Sprintf("manager %d", managerId), action: AuditSettlementVoid,
+		params: map[string]string{"settlement": fmt.Sprint(id)}}
+	err := runTx(db, func(ctx context.Context, q queryer) error {
+		err := checkManager(ctx, q, managerId)
+		if err != nil {
+			return err
+		}
+		settlement, err := settlementById(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		result, err := q.ExecContext(ctx, voidSettlement,
+			sql.Named("voidedAt", now().Unix()),
+			sql.Named("id", id),
+		)
+		if err != nil {
+			return fmt.Errorf("can't void settlement %d: %w", id, err)
+		}
+		voided, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("can't void settlement %d: %w", id, err)
+		}
+		if voided == 0 {
+			return fmt.Errorf("settlement %d: %w", id, ErrSettlementVoided)
+		}
+		err = payOut(ctx, q, settlement.Providers, 1)
+		if err != nil {
+			return err
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	if err != nil {
+		return auditFailure(db, record, err)
+	}
+	return nil
+}
+
+func settlementById(ctx context.Context, q queryer, id int64) (settlement Settlement, err error) {
+	var cutoff, createdAt, voidedAt int64
+	err = q.QueryRowContext(ctx, getSettlement, id).Scan(&settlement.Id, &cutoff, &settlement.Status, &createdAt, &voidedAt)
+	if err == sql.ErrNoRows {
+		return Settlement{}, fmt.Errorf("settlement %d: %w", id, ErrSettlementNotFound)
+	}
+	if err != nil {
+		return Settlement{}, fmt.Errorf("can't get settlement %d: %w", id, err)
+	}
+	settlement.Cutoff = time.Unix(cutoff, 0)
+	settlement.Time = time.Unix(createdAt, 0)
+	if voidedAt != 0 {
+		settlement.VoidedAt = time.Unix(voidedAt, 0)
+	}
+	settlement.Providers, err = settlementProviders(ctx, q, id)
+	if err != nil {
+		return Settlement{}, err
+	}
+	return settlement, nil
+}
+
+func settlementProviders(ctx context.Context, q queryer, id int64) (providers []ProviderSettlement, err error) {
+	rows, err := q.QueryContext(ctx, getSettlementEntries, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't get entries of settlement %d: %w", id, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("can't close entries of settlement %d: %w", id, cerr)
+		}
+	}()
+	for rows.Next() {
+		var entry SettlementEntry
+		var service string
+		var createdAt int64
+		err = rows.Scan(&entry.TransactionId, &createdAt, &service, &entry.Reference, &entry.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan settlement entry: %w", err)
+		}
+		entry.Time = time.Unix(createdAt, 0)
+		if len(providers) == 0 || providers[len(providers)-1].Service != service {
+			providers = append(providers, ProviderSettlement{Service: service})
+		}
+		provider := &providers[len(providers)-1]
+		provider.Entries = append(provider.Entries, entry)
+		provider.Total += entry.Amount
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get entries of settlement %d: %w", id, err)
+	}
+	return providers, nil
+}
+
+// payOut moves each provider's total out of (sign -1) or back into (sign 1)
+// its service balance.
+func payOut(ctx context.Context, q queryer, providers []ProviderSettlement, sign int) error {
+	for _, provider := range providers {
+		err := lockServiceRow(ctx, q, provider.Service)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, addServiceBalance,
+			sql.Named("amount", sign*provider.Total),
+			sql.Named("serviceName", provider.Service),
+		)
+		if err != nil {
+			return fmt.Errorf("can't settle service %s: %w", provider.Service, err)
+		}
+	}
+	return nil
+}
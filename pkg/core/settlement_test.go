@@ -0,0 +1,113 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func serviceBalance(t *testing.T, db *sql.DB, service string) (balance int) {
+	err := db.QueryRow(`SELECT balance FROM services WHERE service = ?`, service).Scan(&balance)
+	if err != nil {
+		t.Fatalf("can't get balance of %s: %v", service, err)
+	}
+	return balance
+}
+
+func TestSettle(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	payments := []struct {
+		day, hour int
+		reference string
+		amount    int
+	}{
+		{5, 10, "100200", 200},
+		{5, 12, "100201", 300},
+		{7, 0, "100202", 100},
+	}
+	for _, payment := range payments {
+		restore := setNow(at(payment.day, payment.hour))
+		_, err := ServicesPayOneCard("internet", payment.reference, 1, payment.amount, db)
+		restore()
+		if err != nil {
+			t.Fatalf("can't pay service: %v", err)
+		}
+	}
+	restore := setNow(at(6, 9))
+	_, err := Reverse(1, 50, "partial refund", 1, db)
+	restore()
+	if err != nil {
+		t.Fatalf("can't refund payment: %v", err)
+	}
+	defer setNow(at(7, 6))()
+
+	settlement, err := Settle(at(7, 0), db)
+	if err != nil {
+		t.Fatalf("can't settle: %v", err)
+	}
+	if len(settlement.Providers) != 1 || settlement.Status != SettlementSettled {
+		t.Fatalf("settlement just pay out internet: %+v", settlement)
+	}
+	internet := settlement.Providers[0]
+	if internet.Service != "internet" || internet.Total != 450 || len(internet.Entries) != 3 {
+		t.Errorf("internet just be paid 450 for 3 entries: %+v", internet)
+	}
+	if last := internet.Entries[len(internet.Entries)-1]; last.Amount != -50 || last.Reference != "100200" ||
+		!last.Time.Equal(at(6, 9)) {
+		t.Errorf("refund just be the last entry: %+v", last)
+	}
+	if balance := serviceBalance(t, db, "internet"); balance != 1500+600-50-450 {
+		t.Errorf("internet balance just be %d: %d", 1500+600-50-450, balance)
+	}
+	_, err = Settle(at(7, 0), db)
+	if !errors.Is(err, ErrNothingToSettle) {
+		t.Errorf("second settle just be ErrNothingToSettle: %v", err)
+	}
+	stored, err := SettlementGet(settlement.Id, db)
+	if err != nil {
+		t.Fatalf("can't get settlement: %v", err)
+	}
+	if len(stored.Providers) != 1 || stored.Providers[0].Total != 450 || !stored.Cutoff.Equal(at(7, 0)) {
+		t.Errorf("stored settlement just be the same batch: %+v", stored)
+	}
+
+	err = VoidSettlement(settlement.Id, 1, db)
+	if err != nil {
+		t.Fatalf("can't void settlement: %v", err)
+	}
+	if balance := serviceBalance(t, db, "internet"); balance != 1500+600-50 {
+		t.Errorf("voided internet balance just be %d: %d", 1500+600-50, balance)
+	}
+	err = VoidSettlement(settlement.Id, 1, db)
+	if !errors.Is(err, ErrSettlementVoided) {
+		t.Errorf("second void just be ErrSettlementVoided: %v", err)
+	}
+	voided, err := SettlementGet(settlement.Id, db)
+	if err != nil || voided.Status != SettlementVoided || !voided.VoidedAt.Equal(at(7, 6)) || len(voided.Providers) != 1 {
+		t.Errorf("voided settlement just keep its entries: %+v %v", voided, err)
+	}
+
+	again, err := Settle(at(8, 0), db)
+	if err != nil {
+		t.Fatalf("can't settle again: %v", err)
+	}
+	if len(again.Providers) != 1 || again.Providers[0].Total != 550 || len(again.Providers[0].Entries) != 4 {
+		t.Errorf("next batch just take the voided payments too: %+v", again)
+	}
+	if balance := serviceBalance(t, db, "internet"); balance != 1500 {
+		t.Errorf("internet balance just be 1500: %d", balance)
+	}
+	_, err = SettlementGet(404, db)
+	if !errors.Is(err, ErrSettlementNotFound) {
+		t.Errorf("unknown settlement just be ErrSettlementNotFound: %v", err)
+	}
+	err = VoidSettlement(404, 1, db)
+	if !errors.Is(err, ErrSettlementNotFound) {
+		t.Errorf("void of unknown settlement just be ErrSettlementNotFound: %v", err)
+	}
+}
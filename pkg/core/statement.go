@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type StatementEntry struct {
+	TransactionId int64
+	Time          time.Time
+	Kind          string
+	Service       string
+	CounterPAN    int64
+	Amount        int
+	Balance       int
+}
+
+type Statement struct {
+	PAN            int64
+	HolderName     string
+	From           time.Time
+	To             time.Time
+	OpeningBalance int
+	ClosingBalance int
+	Entries        []StatementEntry
+}
+
+// GetStatement lists the movements of a card in [from, to). The opening
+// balance is derived from the current balance minus everything recorded
+// since from, so cards that predate the ledger open with their old balance.
+func GetStatement(pan int64, from, to time.Time, db *sql.DB) (statement Statement, err error) {
+	statement = Statement{PAN: pan, From: from, To: to}
+	err = runTx(db, func(ctx context.Context, q queryer) error {
+		var cardId int64
+		var balance int
+		err := q.QueryRowContext(ctx, getCardForStatement, pan).Scan(&cardId, &balance, &statement.HolderName)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("card %d: %w", pan, ErrCardNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("can't get card %d: %w", pan, err)
+		}
+		var sinceFrom int
+		err = q.QueryRowContext(ctx, sumMovementsSince,
+			sql.Named("idCard", cardId),
+			sql.Named("since", from.Unix()),
+		).Scan(&sinceFrom)
+		if err != nil {
+			return fmt.Errorf("can't sum movements of card %d: %w", pan, err)
+		}
+		statement.OpeningBalance = balance - sinceFrom
+		statement.Entries, err = statementEntries(ctx, q, cardId, from, to, statement.OpeningBalance)
+		if err != nil {
+			return err
+		}
+		statement.ClosingBalance = statement.OpeningBalance
+		if len(statement.Entries) > 0 {
+			statement.ClosingBalance = statement.Entries[len(statement.Entries)-1].Balance
+		}
+		return nil
+	})
+	if err != nil {
+		return Statement{}, err
+	}
+	return statement, nil
+}
+
+func statementEntries(ctx context.Context, q queryer, cardId int64, from, to time.Time, balance int) (entries []StatementEntry, err error) {
+	rows, err := q.QueryContext(ctx, getStatementEntries,
+		sql.Named("idCard", cardId),
+		sql.Named("since", from.Unix()),
+		sql.Named("until", to.Unix()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't get statement entries: %w", err)
+	}
+	defer func() {
+		if innerErr := rows.Close(); innerErr != nil {
+			entries = nil
+		}
+	}()
+	for rows.Next() {
+		entry := StatementEntry{}
+		var createdAt int64
+		err = rows.Scan(&entry.TransactionId, &entry.Kind, &createdAt, &entry.Service, &entry.Amount, &entry.CounterPAN)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan statement entry: %w", err)
+		}
+		entry.Time = time.Unix(createdAt, 0).UTC()
+		balance += entry.Amount
+		entry.Balance = balance
+		entries = append(entries, entry)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("can't read statement entries: %w", rows.Err())
+	}
+	return entries, nil
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func at(day, hour int) time.Time {
+	return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC)
+}
+
+func setNow(t time.Time) func() {
+	previous := now
+	now = func() time.Time { return t }
+	return func() { now = previous }
+}
+
+func TestGetStatement_Period(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := db.Exec(`INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 1000, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't execute insert card to DB: %v", err)
+	}
+	steps := []struct {
+		when time.Time
+		do   func() error
+	}{
+		{at(1, 9), func() error { _, err := MoreCard(2021600000000001, 2021600000000000, 100, db); return err }},
+		{at(2, 10), func() error { _, err := MoreCard(2021600000000000, 2021600000000001, 500, db); return err }},
+		{at(5, 12), func() error {
+			_, err := ServicesPayMoreCard("internet", "100200", 2021600000000001, 200, db)
+			return err
+		}},
+		{at(9, 8), func() error { _, err := MoreCard(2021600000000001, 2021600000000000, 50, db); return err }},
+	}
+	for _, step := range steps {
+		restore := setNow(step.when)
+		err = step.do()
+		restore()
+		if err != nil {
+			t.Fatalf("can't prepare statement: %v", err)
+		}
+	}
+
+	statement, err := GetStatement(2021600000000001, at(2, 0), at(9, 0), db)
+	if err != nil {
+		t.Fatalf("can't get statement: %v", err)
+	}
+	if statement.OpeningBalance != 900 || statement.ClosingBalance != 1200 {
+		t.Errorf("opening and closing just be 900 and 1200: %d %d", statement.OpeningBalance, statement.ClosingBalance)
+	}
+	if len(statement.Entries) != 2 {
+		t.Fatalf("just be 2 entries: %v", statement.Entries)
+	}
+	first, second := statement.Entries[0], statement.Entries[1]
+	if first.Kind != KindTransfer || first.Amount != 500 || first.CounterPAN != 2021600000000000 || first.Balance != 1400 {
+		t.Errorf("first entry just be the incoming transfer: %+v", first)
+	}
+	if second.Kind != KindService || second.Amount != -200 || second.Service != "internet" || !second.Time.Equal(at(5, 12)) {
+		t.Errorf("second entry just be the internet payment: %+v", second)
+	}
+	if statement.HolderName != "JACK JACKSON" {
+		t.Errorf("holder just be JACK JACKSON: %s", statement.HolderName)
+	}
+}
+
+func TestGetStatement_UnknownCard(t *testing.T) {
+	db := openBankDb(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err := GetStatement(404, at(1, 0), at(2, 0), db)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("just be ErrCardNotFound: %v", err)
+	}
+}
+
+func TestMaskPAN(t *testing.T) {
+	if masked := MaskPAN(2021600000000001); masked != "202160******0001" {
+		t.Errorf("just be 202160******0001: %s", masked)
+	}
+	if masked := MaskPAN(1234); masked != "1234" {
+		t.Errorf("short pans stay as they are: %s", masked)
+	}
+}
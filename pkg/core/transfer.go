@@ -0,0 +1,337 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const maxTxAttempts = 5
+const retryBackoff = 5 * time.Millisecond
+
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// runTx executes fn in a transaction and repeats it when the database reports
+// a lock conflict or a serialization failure.
+func runTx(db *sql.DB, fn func(ctx context.Context, q queryer) error) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = runTxOnce(db, fn)
+		if err == nil || attempt == maxTxAttempts || !isRetryable(err) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * retryBackoff)
+	}
+}
+
+func runTxOnce(db *sql.DB, fn func(ctx context.Context, q queryer) error) (err error) {
+	ctx := context.Background()
+	if isSQLite(db) {
+		return runImmediateTx(ctx, db, fn)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(ctx, tx)
+}
+
+// runImmediateTx takes the SQLite write lock at BEGIN, so two writers never
+// both hold a read lock and then fail to upgrade it.
+func runImmediateTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context, q queryer) error) (err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_, err = conn.ExecContext(ctx, beginImmediate)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			_, err = conn.ExecContext(ctx, commitTx)
+		}
+		if err != nil {
+			_, _ = conn.ExecContext(ctx, rollbackTx)
+		}
+	}()
+	return fn(ctx, conn)
+}
+
+func isSQLite(db *sql.DB) bool {
+	_, ok := db.Driver().(*sqlite3.SQLiteDriver)
+	return ok
+}
+
+func isRetryable(err error) bool {
+	var liteErr sqlite3.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code == sqlite3.ErrBusy || liteErr.Code == sqlite3.ErrLocked
+	}
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		state := stateErr.SQLState()
+		return state == "40001" || state == "40P01"
+	}
+	return false
+}
+
+// lockCards locks the rows of the given cards in ascending id order, so
+// concurrent transfers in opposite directions can't deadlock each other.
+func lockCards(ctx context.Context, q queryer, ids ...int64) error {
+	ordered := append([]int64(nil), ids...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+	for i, id := range ordered {
+		if i > 0 && ordered[i-1] == id {
+			continue
+		}
+		result, err := q.ExecContext(ctx, lockCard, id)
+		if err != nil {
+			return fmt.Errorf("can't lock card %d: %w", id, err)
+		}
+		locked, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("can't lock card %d: %w", id, err)
+		}
+		if locked == 0 {
+			return fmt.Errorf("can't lock card %d: %w", id, ErrCardNotFound)
+		}
+	}
+	return nil
+}
+
+// lockActiveCards is lockCards for operations that blocked cards must refuse.
+func lockActiveCards(ctx context.Context, q queryer, ids ...int64) error {
+	err := lockCards(ctx, q, ids...)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = checkNotBlocked(ctx, q, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkNotBlocked(ctx context.Context, q queryer, cardId int64) error {
+	var reason string
+	err := q.QueryRowContext(ctx, getBlockReason, cardId).Scan(&reason)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't check card %d: %w", cardId, err)
+	}
+	return &CardBlockedError{CardId: cardId, Reason: reason}
+}
+
+// debitCard takes amount from the card's available balance, the part no
+// hold has reserved.
+func debitCard(ctx context.Context, q queryer, cardId int64, amount int) error {
+	err := checkAvailable(ctx, q, cardId, amount)
+	if err != nil {
+		return err
+	}
+	return addToCard(ctx, q, cardId, -amount)
+}
+
+func checkAvailable(ctx context.Context, q queryer, cardId int64, amount int) error {
+	var available int
+	err := q.QueryRowContext(ctx, getCardAvailable,
+		sql.Named("now", now().Unix()),
+		sql.Named("idCard", cardId),
+	).Scan(&available)
+	if err != nil {
+		return fmt.Errorf("can't get balance of card %d: %w", cardId, err)
+	}
+	if available < amount {
+		return &InsufficientFundsError{CardId: cardId, Balance: available, Amount: amount}
+	}
+	return nil
+}
+
+func addToCard(ctx context.Context, q queryer, cardId int64, amount int) error {
+	_, err := q.ExecContext(ctx, addCardBalance,
+		sql.Named("amount", amount),
+		sql.Named("idCard", cardId),
+	)
+	if err != nil {
+		return fmt.Errorf("can't change balance of card %d: %w", cardId, err)
+	}
+	return nil
+}
+
+// transfer screens and makes a transfer between the cards cardsOf finds,
+// audited as the record. A held or denied transfer is rolled back and then
+// recorded as a screening.
+func transfer(db *sql.DB, record auditRecord, amount int,
+	cardsOf func(ctx context.Context, q queryer) (from, to int64, err error)) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	err := runTx(db, func(ctx context.Context, q queryer) error {
+		senderCardId, receiverCardId, err := cardsOf(ctx, q)
+		if err != nil {
+			return err
+		}
+		err = screenTransfer(ctx, q, senderCardId, receiverCardId, amount)
+		if err != nil {
+			return err
+		}
+		_, err = moveFunds(ctx, q, senderCardId, receiverCardId, amount)
+		if err != nil {
+			return err
+		}
+		return appendAudit(ctx, q, record, AuditOK)
+	})
+	var fraudErr *FraudError
+	if errors.As(err, &fraudErr) {
+		err = recordScreening(db, fraudErr)
+	}
+	if err != nil {
+		return auditFailure(db, record, err)
+	}
+	return nil
+}
+
+func moveFunds(ctx context.Context, q queryer, senderCardId, receiverCardId int64, amount int) (transactionId int64, err error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	err = lockActiveCards(ctx, q, senderCardId, receiverCardId)
+	if err != nil {
+		return 0, err
+	}
+	err = debitCard(ctx, q, senderCardId, amount)
+	if err != nil {
+		return 0, err
+	}
+	err = addToCard(ctx, q, receiverCardId, amount)
+	if err != nil {
+		return 0, err
+	}
+	return recordTransaction(ctx, q, ledgerEntry{
+		kind:       KindTransfer,
+		fromCardId: senderCardId,
+		toCardId:   receiverCardId,
+		amount:     amount,
+	})
+}
+
+func cardIdByPAN(ctx context.Context, q queryer, pan int64) (id int64, err error) {
+	err = q.QueryRowContext(ctx, getCardIdByPAN, pan).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("card %d: %w", pan, ErrCardNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("can't find card %d: %w", pan, err)
+	}
+	return id, nil
+}
+
+func cardIdByClient(ctx context.Context, q queryer, clientId int) (id int64, err error) {
+	err = q.QueryRowContext(ctx, getCardIdByClient, clientId).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("client %d: %w", clientId, ErrClientNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("can't find card of client %d: %w", clientId, err)
+	}
+	return id, nil
+}
+
+// payService moves amount from the card to the service balance, on the
+// customer account reference at the provider, charges the provider's fee
+// and writes the receipt. Cards are always locked before services.
+// Services with a biller are refused with errNeedsBiller: their payments
+// can't be made inside one transaction.
+func payService(ctx context.Context, q queryer, cardId int64, nameService, reference string, amount int) (receipt Receipt, err error) {
+	err = lockActiveCards(ctx, q, cardId)
+	if err != nil {
+		return Receipt{}, err
+	}
+	err = lockServiceRow(ctx, q, nameService)
+	if err != nil {
+		return Receipt{}, err
+	}
+	provider, err := providerByName(ctx, q, nameService)
+	if err != nil {
+		return Receipt{}, err
+	}
+	if provider.Biller != "" {
+		return Receipt{}, errNeedsBiller
+	}
+	err = provider.Validate(reference, amount)
+	if err != nil {
+		return Receipt{}, err
+	}
+	err = debitCard(ctx, q, cardId, amount+provider.Fee)
+	if err != nil {
+		return Receipt{}, err
+	}
+	return creditService(ctx, q, cardId, nameService, reference, amount, provider.Fee)
+}
+
+// creditService adds a payment already taken from the card to the service
+// balance, records it with its fee and writes the receipt.
+func creditService(ctx context.Context, q queryer, cardId int64, nameService, reference string, amount, fee int) (receipt Receipt, err error) {
+	_, err = q.ExecContext(ctx, addServiceBalance,
+		sql.Named("amount", amount),
+		sql.Named("serviceName", nameService),
+	)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("can't pay service %s: %w", nameService, err)
+	}
+	// receipts are stored to the second, so the one returned now reads
+	// the same as the one fetched later.
+	receipt = Receipt{Time: now().Truncate(time.Second), Service: nameService, Reference: reference, Amount: amount, Fee: fee}
+	payment := ledgerEntry{kind: KindService, fromCardId: cardId, service: nameService, reference: reference, amount: amount, at: receipt.Time}
+	receipt.TransactionId, err = recordTransaction(ctx, q, payment)
+	if err != nil {
+		return Receipt{}, err
+	}
+	if fee > 0 {
+		feeEntry := payment
+		feeEntry.kind, feeEntry.amount = KindFee, fee
+		_, err = recordTransaction(ctx, q, feeEntry)
+		if err != nil {
+			return Receipt{}, err
+		}
+	}
+	return writeReceipt(ctx, q, cardId, receipt)
+}
+
+func lockServiceRow(ctx context.Context, q queryer, nameService string) error {
+	result, err := q.ExecContext(ctx, lockService, nameService)
+	if err != nil {
+		return fmt.Errorf("can't lock service %s: %w", nameService, err)
+	}
+	locked, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("can't lock service %s: %w", nameService, err)
+	}
+	if locked == 0 {
+		return fmt.Errorf("service %s: %w", nameService, ErrServiceNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const cardsTable = `
+CREATE TABLE IF NOT EXISTS clients_cards
+(
+    Id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    pan        INTEGER NOT NULL UNIQUE,
+    balance    INTEGER NOT NULL,
+    client_id  INTEGER NOT NULL REFERENCES clients
+);`
+
+// ledgerTables are the tables core adds next to the ones from the database package.
+const ledgerTables = blockedCardsDDL + transactionsDDL + reversalsDDL + providersDDL + receiptsDDL + holdsDDL +
+	deactivatedServicesDDL + auditDDL + screeningsDDL
+
+func openFileDb(t *testing.T) (*sql.DB, func()) {
+	dir, err := ioutil.TempDir("", "clients-core")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	db, err := sql.Open(dbDriver, filepath.Join(dir, "bank.sqlite")+"?_busy_timeout=10000&_journal_mode=WAL")
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	return db, func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func totalBalance(t *testing.T, db *sql.DB) (total int) {
+	err := db.QueryRow(`SELECT sum(balance) FROM clients_cards;`).Scan(&total)
+	if err != nil {
+		t.Fatalf("can't sum balances: %v", err)
+	}
+	return total
+}
+
+func TestMoreCard_InsufficientFunds(t *testing.T) {
+	db, err := sql.Open(dbDriver, dbMemory)
+	if err != nil {
+		t.Errorf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err = db.Exec(cardsTable + ledgerTables)
+	if err != nil {
+		t.Errorf("can't execute query to base: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients_cards(Id, pan, balance, client_id)
+VALUES (1, 1111, 100, 1),
+(2, 2222, 200, 2);`)
+	if err != nil {
+		t.Errorf("can't execute insert card to DB: %v", err)
+	}
+	result, err := MoreCard(1111, 2222, 101, db)
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("transfer just be ErrInsufficientFunds: %v", err)
+	}
+	if result == true {
+		t.Errorf("transfer just be false: %v", result)
+	}
+	balance, _, err := GetCurrentBalanceClientPAN(1111, db)
+	if err != nil {
+		t.Errorf("can't query GetBalanceFromClientPAN: %v", err)
+	}
+	if balance != 100 {
+		t.Errorf("balance just be 100: %d", balance)
+	}
+}
+
+func TestMoreCard_UnknownReceiver(t *testing.T) {
+	db, err := sql.Open(dbDriver, dbMemory)
+	if err != nil {
+		t.Errorf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err = db.Exec(cardsTable + ledgerTables)
+	if err != nil {
+		t.Errorf("can't execute query to base: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients_cards(Id, pan, balance, client_id) VALUES (1, 1111, 100, 1);`)
+	if err != nil {
+		t.Errorf("can't execute insert card to DB: %v", err)
+	}
+	_, err = MoreCard(1111, 9999, 50, db)
+	if err == nil {
+		t.Errorf("transfer to unknown card just have error: %v", err)
+	}
+	if total := totalBalance(t, db); total != 100 {
+		t.Errorf("money must not leave the bank: %d", total)
+	}
+}
+
+func TestOneCard_MovesFunds(t *testing.T) {
+	db, err := sql.Open(dbDriver, dbMemory)
+	if err != nil {
+		t.Errorf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err = db.Exec(cardsTable + ledgerTables)
+	if err != nil {
+		t.Errorf("can't execute query to base: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients_cards(Id, pan, balance, client_id)
+VALUES (1, 1111, 100, 1),
+(2, 2222, 200, 2);`)
+	if err != nil {
+		t.Errorf("can't execute insert card to DB: %v", err)
+	}
+	_, err = OneCard(1111, 2, 150, db)
+	if err != nil {
+		t.Errorf("can't execute transfer money: %v", err)
+	}
+	sender, _, _ := GetCurrentBalanceClientPAN(2222, db)
+	receiver, _, _ := GetCurrentBalanceClientPAN(1111, db)
+	if sender != 50 || receiver != 250 {
+		t.Errorf("balances just be 50 and 250: %d %d", sender, receiver)
+	}
+}
+
+func TestTransfer_NegativeAmount(t *testing.T) {
+	db, err := sql.Open(dbDriver, dbMemory)
+	if err != nil {
+		t.Errorf("can't open db: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}()
+	_, err = db.Exec(cardsTable + ledgerTables)
+	if err != nil {
+		t.Errorf("can't execute query to base: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients_cards(Id, pan, balance, client_id)
+VALUES (1, 1111, 100, 1),
+(2, 2222, 200, 2);`)
+	if err != nil {
+		t.Errorf("can't execute insert card to DB: %v", err)
+	}
+	_, err = MoreCard(1111, 2222, -50, db)
+	if !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("negative transfer just be ErrInvalidAmount: %v", err)
+	}
+	_, err = OneCard(1111, 2, -50, db)
+	if !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("negative transfer just be ErrInvalidAmount: %v", err)
+	}
+	_, err = OneCard(1111, 2, 0, db)
+	if !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("zero transfer just be ErrInvalidAmount: %v", err)
+	}
+	sender, _, _ := GetCurrentBalanceClientPAN(1111, db)
+	receiver, _, _ := GetCurrentBalanceClientPAN(2222, db)
+	if sender != 100 || receiver != 200 {
+		t.Errorf("balances just stay 100 and 200: %d %d", sender, receiver)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !isRetryable(sqlite3.Error{Code: sqlite3.ErrBusy}) {
+		t.Error("busy database just be retryable")
+	}
+	if !isRetryable(stateError("40P01")) {
+		t.Error("deadlock just be retryable")
+	}
+	if isRetryable(ErrInsufficientFunds) {
+		t.Error("insufficient funds must not be retryable")
+	}
+}
+
+type stateError string
+
+func (e stateError) Error() string    { return "sql state " + string(e) }
+func (e stateError) SQLState() string { return string(e) }
+
+func TestMoreCard_ConcurrentTransfersConserveMoney(t *testing.T) {
+	db, closeDb := openFileDb(t)
+	defer closeDb()
+	db.SetMaxOpenConns(16)
+	_, err := db.Exec(cardsTable + ledgerTables)
+	if err != nil {
+		t.Fatalf("can't execute query to base: %v", err)
+	}
+	const cards = 20
+	pans := make([]int64, cards)
+	for i := range pans {
+		pans[i] = int64(1000 + i)
+		_, err = db.Exec(`INSERT INTO clients_cards(pan, balance, client_id) VALUES (?, ?, ?);`, pans[i], 10000, i+1)
+		if err != nil {
+			t.Fatalf("can't execute insert card to DB: %v", err)
+		}
+	}
+	before := totalBalance(t, db)
+
+	transfers := 3000
+	if testing.Short() {
+		transfers = 300
+	}
+	random := rand.New(rand.NewSource(1))
+	var wg sync.WaitGroup
+	errs := make(chan error, transfers)
+	for i := 0; i < transfers; i++ {
+		sender := pans[random.Intn(cards)]
+		receiver := pans[random.Intn(cards)]
+		amount := random.Intn(3000) + 1
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := MoreCard(sender, receiver, amount, db)
+			if err != nil && !errors.Is(err, ErrInsufficientFunds) {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("transfer failed: %v", err)
+	}
+
+	if after := totalBalance(t, db); after != before {
+		t.Errorf("total money just be %d: %d", before, after)
+	}
+	var negative int
+	err = db.QueryRow(`SELECT count(*) FROM clients_cards WHERE balance < 0;`).Scan(&negative)
+	if err != nil {
+		t.Fatalf("can't count balances: %v", err)
+	}
+	if negative != 0 {
+		t.Errorf("no card may go below zero: %d", negative)
+	}
+}
@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Security    []map[string][]string `json:"security"`
+	Parameters  []Parameter           `json:"parameters"`
+	RequestBody *RequestBody          `json:"requestBody"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+	Schemas         Properties             `json:"schemas"`
+}
+
+type Schema struct {
+	Ref                  string     `json:"$ref"`
+	Type                 string     `json:"type"`
+	Format               string     `json:"format"`
+	Required             []string   `json:"required"`
+	Properties           Properties `json:"properties"`
+	AdditionalProperties *bool      `json:"additionalProperties"`
+	Items                *Schema    `json:"items"`
+}
+
+// Property keeps its position in the document, so generated structs list
+// fields in the order the spec declares them.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+type Properties []Property
+
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("properties must be an object, got %v", token)
+	}
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+		schema := &Schema{}
+		err = decoder.Decode(schema)
+		if err != nil {
+			return err
+		}
+		*p = append(*p, Property{Name: token.(string), Schema: schema})
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+func (p Properties) Get(name string) *Schema {
+	for _, property := range p {
+		if property.Name == name {
+			return property.Schema
+		}
+	}
+	return nil
+}
+
+func Parse(data []byte) (*Document, error) {
+	document := &Document{}
+	err := json.Unmarshal(data, document)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse openapi document: %w", err)
+	}
+	return document, nil
+}
+
+func Load() (*Document, error) {
+	return Parse([]byte(Spec))
+}
+
+// Resolve follows a $ref into components.schemas.
+func (d *Document) Resolve(schema *Schema) (*Schema, error) {
+	if schema == nil || schema.Ref == "" {
+		return schema, nil
+	}
+	name := strings.TrimPrefix(schema.Ref, schemaRefPrefix)
+	resolved := d.Components.Schemas.Get(name)
+	if resolved == nil {
+		return nil, fmt.Errorf("unknown schema %s", schema.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+func (o *Operation) JSONResponse(status int) *Schema {
+	response, ok := o.Responses[fmt.Sprint(status)]
+	if !ok {
+		response, ok = o.Responses["default"]
+	}
+	if !ok {
+		return nil
+	}
+	return response.Content["application/json"].Schema
+}
+
+func (o *Operation) JSONRequest() *Schema {
+	if o.RequestBody == nil {
+		return nil
+	}
+	return o.RequestBody.Content["application/json"].Schema
+}
@@ -0,0 +1,221 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+var httpMethods = map[string]string{
+	"get":    "http.MethodGet",
+	"post":   "http.MethodPost",
+	"put":    "http.MethodPut",
+	"patch":  "http.MethodPatch",
+	"delete": "http.MethodDelete",
+}
+
+type generator struct {
+	document *Document
+	buffer   bytes.Buffer
+	imports  map[string]bool
+}
+
+// GenerateClient renders the schema types and one Client method per
+// operation. The Client type itself and its do method are written by hand in
+// the target package.
+func GenerateClient(document *Document, packageName string) ([]byte, error) {
+	g := &generator{document: document, imports: map[string]bool{"context": true, "net/http": true}}
+	g.printf("const APIVersion = %q\n\n", document.Info.Version)
+	for _, property := range document.Components.Schemas {
+		err := g.schemaType(property.Name, property.Schema)
+		if err != nil {
+			return nil, err
+		}
+	}
+	paths := make([]string, 0, len(document.Paths))
+	for path := range document.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		methods := make([]string, 0, len(document.Paths[path]))
+		for method := range document.Paths[path] {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			err := g.operation(path, method, document.Paths[path][method])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by client-gen from the openapi package. DO NOT EDIT.\n\npackage %s\n\nimport (\n", packageName)
+	imports := make([]string, 0, len(g.imports))
+	for name := range g.imports {
+		imports = append(imports, name)
+	}
+	sort.Strings(imports)
+	for _, name := range imports {
+		fmt.Fprintf(&out, "\t%q\n", name)
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.buffer.Bytes())
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("can't format generated client: %w", err)
+	}
+	return formatted, nil
+}
+
+func (g *generator) printf(text string, args ...interface{}) {
+	fmt.Fprintf(&g.buffer, text, args...)
+}
+
+func (g *generator) schemaType(name string, schema *Schema) error {
+	if schema.Type != "object" {
+		return fmt.Errorf("schema %s: only objects can be generated", name)
+	}
+	required := map[string]bool{}
+	for _, field := range schema.Required {
+		required[field] = true
+	}
+	g.printf("type %s struct {\n", name)
+	for _, property := range schema.Properties {
+		goType, err := g.goType(property.Schema)
+		if err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		tag := property.Name
+		if !required[property.Name] {
+			tag += ",omitempty"
+		}
+		g.printf("\t%s %s `json:%q`\n", exported(property.Name), goType, tag)
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+func (g *generator) goType(schema *Schema) (string, error) {
+	if schema.Ref != "" {
+		if g.document.Components.Schemas.Get(strings.TrimPrefix(schema.Ref, schemaRefPrefix)) == nil {
+			return "", fmt.Errorf("unknown schema %s", schema.Ref)
+		}
+		return strings.TrimPrefix(schema.Ref, schemaRefPrefix), nil
+	}
+	switch schema.Type {
+	case "integer":
+		if schema.Format == "int64" {
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "string":
+		if schema.Format == "date-time" {
+			g.imports["time"] = true
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "array":
+		item, err := g.goType(schema.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	}
+	return "", fmt.Errorf("unsupported schema type %q", schema.Type)
+}
+
+func (g *generator) operation(path, method string, operation *Operation) error {
+	httpMethod, ok := httpMethods[method]
+	if !ok {
+		return fmt.Errorf("%s %s: unsupported method", method, path)
+	}
+	if operation.OperationId == "" {
+		return fmt.Errorf("%s %s: operationId is required", method, path)
+	}
+	name := exported(operation.OperationId)
+	arguments := []string{"ctx context.Context"}
+	var setQuery []string
+	for _, parameter := range operation.Parameters {
+		if parameter.In != "query" {
+			return fmt.Errorf("%s: only query parameters are supported", name)
+		}
+		goType, err := g.goType(parameter.Schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		arguments = append(arguments, parameter.Name+" "+goType)
+		setQuery = append(setQuery, fmt.Sprintf("query.Set(%q, %s)", parameter.Name, g.formatValue(goType, parameter.Name)))
+	}
+	body := "nil"
+	if request := operation.JSONRequest(); request != nil {
+		goType, err := g.goType(request)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		arguments = append(arguments, "body "+goType)
+		body = "body"
+	}
+	response := operation.JSONResponse(200)
+	if response == nil {
+		return fmt.Errorf("%s: a 200 response is required", name)
+	}
+	resultType, err := g.goType(response)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	g.printf("// %s calls %s %s. %s\n", name, strings.ToUpper(method), path, operation.Summary)
+	query := "nil"
+	isArray := strings.HasPrefix(resultType, "[]")
+	if isArray {
+		g.printf("func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(arguments, ", "), resultType)
+	} else {
+		g.printf("func (c *Client) %s(%s) (*%s, error) {\n", name, strings.Join(arguments, ", "), resultType)
+	}
+	if len(setQuery) > 0 {
+		g.imports["net/url"] = true
+		g.printf("\tquery := url.Values{}\n")
+		for _, line := range setQuery {
+			g.printf("\t%s\n", line)
+		}
+		query = "query"
+	}
+	if isArray {
+		g.printf("\tvar result %s\n", resultType)
+		g.printf("\terr := c.do(ctx, %s, %q, %s, %s, &result)\n", httpMethod, path, query, body)
+		g.printf("\treturn result, err\n}\n\n")
+		return nil
+	}
+	g.printf("\tresult := &%s{}\n", resultType)
+	g.printf("\terr := c.do(ctx, %s, %q, %s, %s, result)\n", httpMethod, path, query, body)
+	g.printf("\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn result, nil\n}\n\n")
+	return nil
+}
+
+func (g *generator) formatValue(goType, name string) string {
+	switch goType {
+	case "int64":
+		g.imports["strconv"] = true
+		return "strconv.FormatInt(" + name + ", 10)"
+	case "int":
+		g.imports["strconv"] = true
+		return "strconv.Itoa(" + name + ")"
+	case "string":
+		return name
+	}
+	g.imports["fmt"] = true
+	return "fmt.Sprint(" + name + ")"
+}
+
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
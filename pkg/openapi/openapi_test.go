@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpec_Parses(t *testing.T) {
+	document, err := Load()
+	if err != nil {
+		t.Fatalf("can't load spec: %v", err)
+	}
+	if document.Info.Version != Version {
+		t.Errorf("info.version just be %s: %s", Version, document.Info.Version)
+	}
+	for path, item := range document.Paths {
+		for method, operation := range item {
+			if operation.OperationId == "" {
+				t.Errorf("%s %s has no operationId", method, path)
+			}
+			for _, schema := range []*Schema{operation.JSONRequest(), operation.JSONResponse(200), operation.JSONResponse(500)} {
+				if schema == nil {
+					continue
+				}
+				if schema.Items != nil {
+					schema = schema.Items
+				}
+				if _, err := document.Resolve(schema); err != nil {
+					t.Errorf("%s %s: %v", method, path, err)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	document, err := Load()
+	if err != nil {
+		t.Fatalf("can't load spec: %v", err)
+	}
+	card := &Schema{Ref: schemaRefPrefix + "Card"}
+	cases := []struct {
+		body  string
+		fails string
+	}{
+		{`{"id":1,"pan":2021600000000000,"balance":10,"holderName":"A","validity":222}`, ""},
+		{`{"id":1,"pan":2021600000000000,"balance":10,"holderName":"A"}`, "missing required validity"},
+		{`{"id":1,"pan":2021600000000000,"balance":10,"holderName":"A","validity":222,"pin":1994}`, "undocumented property pin"},
+		{`{"id":1.5,"pan":2021600000000000,"balance":10,"holderName":"A","validity":222}`, "want integer"},
+		{`[]`, "want object"},
+	}
+	for _, c := range cases {
+		err := document.ValidateJSON(card, []byte(c.body))
+		if c.fails == "" && err != nil {
+			t.Errorf("%s just be valid: %v", c.body, err)
+		}
+		if c.fails != "" && (err == nil || !strings.Contains(err.Error(), c.fails)) {
+			t.Errorf("%s just fail with %q: %v", c.body, c.fails, err)
+		}
+	}
+	err = document.ValidateResponse("GET", "/api/atms", 200, []byte(`[{"id":1,"city":"Dushanbe","district":"Somoni","street":"Foteh51","status":"online","timezone":"UTC"}]`))
+	if err != nil {
+		t.Errorf("atms just be valid: %v", err)
+	}
+	err = document.ValidateResponse("GET", "/api/signin", 200, []byte(`{}`))
+	if err == nil {
+		t.Error("undocumented operation just fail")
+	}
+}
+
+func TestGenerateClient(t *testing.T) {
+	document, err := Load()
+	if err != nil {
+		t.Fatalf("can't load spec: %v", err)
+	}
+	source, err := GenerateClient(document, "client")
+	if err != nil {
+		t.Fatalf("can't generate client: %v", err)
+	}
+	for _, want := range []string{
+		"type Card struct",
+		"func (c *Client) ListAtms(ctx context.Context, openNow bool, capability string, currency string) ([]Atm, error)",
+		"func (c *Client) GetBalance(ctx context.Context, pan int64) (*Balance, error)",
+		"FromPan int64 `json:\"fromPan,omitempty\"`",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("generated client just contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package openapi
+
+// Version is bumped whenever an endpoint or a schema changes.
+const Version = "1.8.2"
+
+const Spec = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "clients-core",
+    "description": "Card, transfer, service payment and ATM operations for account holders.",
+    "version": "1.8.2"
+  },
+  "paths": {
+    "/api/signin": {
+      "post": {
+        "operationId": "signIn",
+        "summary": "Exchange login and password for a bearer token.",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SignInRequest"}}}
+        },
+        "responses": {
+          "200": {"description": "Signed in.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SignInResponse"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/cards": {
+      "get": {
+        "operationId": "listCards",
+        "summary": "List the cards of the signed in client.",
+        "security": [{"bearer": []}],
+        "responses": {
+          "200": {"description": "Cards.", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Card"}}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/balance": {
+      "get": {
+        "operationId": "getBalance",
+        "summary": "Current balance of one of the client's cards.",
+        "security": [{"bearer": []}],
+        "parameters": [
+          {"name": "pan", "in": "query", "required": true, "schema": {"type": "integer", "format": "int64"}}
+        ],
+        "responses": {
+          "200": {"description": "Balance.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Balance"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/transfers": {
+      "post": {
+        "operationId": "createTransfer",
+        "summary": "Transfer money to another card. fromPan is required when the client has several cards.",
+        "security": [{"bearer": []}],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TransferRequest"}}}
+        },
+        "responses": {
+          "200": {"description": "Transferred.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Status"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/receivers": {
+      "get": {
+        "operationId": "lookupReceiver",
+        "summary": "The masked holder name and scheme of a card, to confirm before a transfer. Each client may look up 10 different cards an hour; over the limit the answer is 429 with Retry-After.",
+        "security": [{"bearer": []}],
+        "parameters": [
+          {"name": "pan", "in": "query", "required": true, "schema": {"type": "integer", "format": "int64"}}
+        ],
+        "responses": {
+          "200": {"description": "Receiver.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Receiver"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/beneficiaries": {
+      "get": {
+        "operationId": "listBeneficiaries",
+        "summary": "The client's saved receivers by nickname.",
+        "security": [{"bearer": []}],
+        "responses": {
+          "200": {"description": "Beneficiaries.", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Beneficiary"}}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      },
+      "post": {
+        "operationId": "addBeneficiary",
+        "summary": "Save a receiver card under a nickname. holderName must match the name on the card; an unknown card fails the same way. Counts as a receiver lookup.",
+        "security": [{"bearer": []}],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/BeneficiaryRequest"}}}
+        },
+        "responses": {
+          "200": {"description": "Saved.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Beneficiary"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      },
+      "delete": {
+        "operationId": "removeBeneficiary",
+        "summary": "Forget a saved receiver.",
+        "security": [{"bearer": []}],
+        "parameters": [
+          {"name": "id", "in": "query", "required": true, "schema": {"type": "integer", "format": "int64"}}
+        ],
+        "responses": {
+          "200": {"description": "Removed.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Status"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/beneficiaries/transfers": {
+      "post": {
+        "operationId": "transferToBeneficiary",
+        "summary": "Transfer money to a saved receiver. fromPan is required when the client has several cards.",
+        "security": [{"bearer": []}],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/BeneficiaryTransferRequest"}}}
+        },
+        "responses": {
+          "200": {"description": "Transferred.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Status"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/services": {
+      "get": {
+        "operationId": "listServices",
+        "summary": "Service catalog.",
+        "responses": {
+          "200": {"description": "Services.", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/ServicesStruct"}}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/payments": {
+      "post": {
+        "operationId": "payService",
+        "summary": "Pay a service from a card. fromPan is required when the client has several cards.",
+        "security": [{"bearer": []}],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PaymentRequest"}}}
+        },
+        "responses": {
+          "200": {"description": "Paid.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Receipt"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/receipts": {
+      "get": {
+        "operationId": "getReceipt",
+        "summary": "A receipt of one of the client's service payments.",
+        "security": [{"bearer": []}],
+        "parameters": [
+          {"name": "number", "in": "query", "required": true, "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "Receipt.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Receipt"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/atms": {
+      "get": {
+        "operationId": "listAtms",
+        "summary": "ATM locations. openNow keeps online ATMs open at the moment; capability is cash-in, cash-out or contactless; currency is an ISO 4217 code.",
+        "parameters": [
+          {"name": "openNow", "in": "query", "schema": {"type": "boolean"}},
+          {"name": "capability", "in": "query", "schema": {"type": "string"}},
+          {"name": "currency", "in": "query", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "ATMs.", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Atm"}}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/atms/search": {
+      "get": {
+        "operationId": "searchAtms",
+        "summary": "One page of the ATMs in a city and district whose street contains the text, sorted by id, city, district or street. Empty filters match every ATM.",
+        "parameters": [
+          {"name": "city", "in": "query", "schema": {"type": "string"}},
+          {"name": "district", "in": "query", "schema": {"type": "string"}},
+          {"name": "street", "in": "query", "schema": {"type": "string"}},
+          {"name": "sort", "in": "query", "schema": {"type": "string"}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+          {"name": "pageToken", "in": "query", "schema": {"type": "string"}},
+          {"name": "openNow", "in": "query", "schema": {"type": "boolean"}},
+          {"name": "capability", "in": "query", "schema": {"type": "string"}},
+          {"name": "currency", "in": "query", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "ATMs.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AtmPage"}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/atms/nearest": {
+      "get": {
+        "operationId": "listNearestAtms",
+        "summary": "ATMs nearest to a point, within radius meters. Zero limit and radius take 10 ATMs and 5000 meters.",
+        "parameters": [
+          {"name": "lat", "in": "query", "required": true, "schema": {"type": "number"}},
+          {"name": "lon", "in": "query", "required": true, "schema": {"type": "number"}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+          {"name": "radius", "in": "query", "schema": {"type": "number"}},
+          {"name": "openNow", "in": "query", "schema": {"type": "boolean"}},
+          {"name": "capability", "in": "query", "schema": {"type": "string"}},
+          {"name": "currency", "in": "query", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "ATMs, nearest first.", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/NearbyAtm"}}}}},
+          "default": {"description": "Error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearer": {"type": "http", "scheme": "bearer"}
+    },
+    "schemas": {
+      "Error": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["error"],
+        "properties": {
+          "error": {"type": "string"}
+        }
+      },
+      "SignInRequest": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["login", "password"],
+        "properties": {
+          "login": {"type": "string"},
+          "password": {"type": "string"}
+        }
+      },
+      "SignInResponse": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["token", "clientId", "expiresAt"],
+        "properties": {
+          "token": {"type": "string"},
+          "clientId": {"type": "integer"},
+          "expiresAt": {"type": "string", "format": "date-time"}
+        }
+      },
+      "Card": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["id", "pan", "balance", "holderName", "validity"],
+        "properties": {
+          "id": {"type": "integer"},
+          "pan": {"type": "integer", "format": "int64"},
+          "balance": {"type": "integer"},
+          "holderName": {"type": "string"},
+          "validity": {"type": "integer"}
+        }
+      },
+      "Balance": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["pan", "balance", "available"],
+        "properties": {
+          "pan": {"type": "integer", "format": "int64"},
+          "balance": {"type": "integer"},
+          "available": {"type": "integer", "description": "Balance less the money held by authorizations."}
+        }
+      },
+      "TransferRequest": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["toPan", "amount"],
+        "properties": {
+          "fromPan": {"type": "integer", "format": "int64"},
+          "toPan": {"type": "integer", "format": "int64"},
+          "amount": {"type": "integer"}
+        }
+      },
+      "Receiver": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["card", "maskedName", "scheme"],
+        "properties": {
+          "card": {"type": "string", "description": "The masked PAN."},
+          "maskedName": {"type": "string", "description": "The first letter of every word of the holder name, as in J*** J***."},
+          "scheme": {"type": "string", "description": "visa, mastercard, mir, unionpay, korti-milli or local."}
+        }
+      },
+      "BeneficiaryRequest": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["nickname", "pan", "holderName"],
+        "properties": {
+          "nickname": {"type": "string", "description": "Unique per client, ignoring case."},
+          "pan": {"type": "integer", "format": "int64"},
+          "holderName": {"type": "string", "description": "The name on the card, ignoring case and extra spaces."}
+        }
+      },
+      "Beneficiary": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["id", "nickname", "pan", "holderName", "createdAt"],
+        "properties": {
+          "id": {"type": "integer", "format": "int64"},
+          "nickname": {"type": "string"},
+          "pan": {"type": "integer", "format": "int64"},
+          "holderName": {"type": "string", "description": "The name on the card, masked: JACK JACKSON is J*** J***."},
+          "createdAt": {"type": "string", "format": "date-time"}
+        }
+      },
+      "BeneficiaryTransferRequest": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["beneficiaryId", "amount"],
+        "properties": {
+          "beneficiaryId": {"type": "integer", "format": "int64"},
+          "fromPan": {"type": "integer", "format": "int64"},
+          "amount": {"type": "integer"}
+        }
+      },
+      "PaymentRequest": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["service", "reference", "amount"],
+        "properties": {
+          "service": {"type": "string"},
+          "reference": {"type": "string", "description": "The customer's account at the provider, in the service's referenceFormat."},
+          "fromPan": {"type": "integer", "format": "int64"},
+          "amount": {"type": "integer"}
+        }
+      },
+      "Status": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["status"],
+        "properties": {
+          "status": {"type": "string"}
+        }
+      },
+      "Receipt": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["number", "transactionId", "time", "card", "service", "reference", "amount", "fee", "total"],
+        "properties": {
+          "number": {"type": "string"},
+          "transactionId": {"type": "integer", "format": "int64"},
+          "time": {"type": "string", "format": "date-time"},
+          "card": {"type": "string", "description": "The masked PAN of the paying card."},
+          "service": {"type": "string"},
+          "reference": {"type": "string"},
+          "amount": {"type": "integer"},
+          "fee": {"type": "integer"},
+          "total": {"type": "integer", "description": "amount plus fee, as taken from the card."}
+        }
+      },
+      "ServicesStruct": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["id", "service", "category", "referenceLabel", "referenceFormat", "minAmount", "maxAmount", "fee", "active"],
+        "properties": {
+          "id": {"type": "integer"},
+          "service": {"type": "string"},
+          "category": {"type": "string"},
+          "referenceLabel": {"type": "string"},
+          "referenceFormat": {"type": "string", "description": "A named validator such as phone or digits, or a regular expression. Empty takes any reference."},
+          "minAmount": {"type": "integer"},
+          "maxAmount": {"type": "integer", "description": "Zero means no limit."},
+          "fee": {"type": "integer", "description": "Charged to the card on top of the amount."},
+          "active": {"type": "boolean"}
+        }
+      },
+      "Atm": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["id", "city", "district", "street", "status", "timezone"],
+        "properties": {
+          "id": {"type": "integer", "format": "int64"},
+          "city": {"type": "string"},
+          "district": {"type": "string"},
+          "street": {"type": "string"},
+          "latitude": {"type": "number"},
+          "longitude": {"type": "number"},
+          "status": {"type": "string", "description": "online, offline or out-of-service."},
+          "timezone": {"type": "string"},
+          "hours": {"type": "array", "items": {"$ref": "#/components/schemas/Hours"}},
+          "capabilities": {"type": "array", "items": {"type": "string"}},
+          "currencies": {"type": "array", "items": {"type": "string"}}
+        }
+      },
+      "Hours": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["weekday", "opens", "closes"],
+        "properties": {
+          "weekday": {"type": "integer", "description": "0 is Sunday."},
+          "opens": {"type": "string", "description": "HH:MM in the ATM's timezone."},
+          "closes": {"type": "string", "description": "HH:MM; before opens when the ATM stays open past midnight."}
+        }
+      },
+      "AtmPage": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["atms"],
+        "properties": {
+          "atms": {"type": "array", "items": {"$ref": "#/components/schemas/Atm"}},
+          "nextPageToken": {"type": "string", "description": "Pass it as pageToken for the next page; absent on the last page."}
+        }
+      },
+      "NearbyAtm": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["id", "city", "district", "street", "latitude", "longitude", "status", "timezone", "distance"],
+        "properties": {
+          "id": {"type": "integer", "format": "int64"},
+          "city": {"type": "string"},
+          "district": {"type": "string"},
+          "street": {"type": "string"},
+          "latitude": {"type": "number"},
+          "longitude": {"type": "number"},
+          "status": {"type": "string"},
+          "timezone": {"type": "string"},
+          "hours": {"type": "array", "items": {"$ref": "#/components/schemas/Hours"}},
+          "capabilities": {"type": "array", "items": {"type": "string"}},
+          "currencies": {"type": "array", "items": {"type": "string"}},
+          "distance": {"type": "number", "description": "Meters from the point."}
+        }
+      }
+    }
+  }
+}
+`
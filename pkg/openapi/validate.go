@@ -0,0 +1,103 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ValidateResponse checks a response body against the schema the document
+// declares for the operation and status code.
+func (d *Document) ValidateResponse(method, path string, status int, body []byte) error {
+	operation := d.Operation(method, path)
+	if operation == nil {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+	schema := operation.JSONResponse(status)
+	if schema == nil {
+		return fmt.Errorf("%s %s has no schema for status %d", method, path, status)
+	}
+	return d.ValidateJSON(schema, body)
+}
+
+func (d *Document) ValidateJSON(schema *Schema, body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return fmt.Errorf("body is not json: %w", err)
+	}
+	return d.validate(schema, value, "$")
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, at string) error {
+	schema, err := d.Resolve(schema)
+	if err != nil {
+		return err
+	}
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want object, got %T", at, value)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required %s", at, name)
+			}
+		}
+		for name, field := range object {
+			property := schema.Properties.Get(name)
+			if property == nil {
+				if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+					return fmt.Errorf("%s: undocumented property %s", at, name)
+				}
+				continue
+			}
+			err = d.validate(property, field, at+"."+name)
+			if err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want array, got %T", at, value)
+		}
+		for i, item := range array {
+			err = d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i))
+			if err != nil {
+				return err
+			}
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok || strings.ContainsAny(number.String(), ".eE") {
+			return fmt.Errorf("%s: want integer, got %v", at, value)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s: want number, got %v", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: want boolean, got %v", at, value)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: want string, got %v", at, value)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				return fmt.Errorf("%s: want date-time: %w", at, err)
+			}
+		}
+	default:
+		return fmt.Errorf("%s: unsupported schema type %q", at, schema.Type)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package receipt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+type jsonReceipt struct {
+	Number        string    `json:"number"`
+	TransactionId int64     `json:"transactionId"`
+	Time          time.Time `json:"time"`
+	Card          string    `json:"card"`
+	Service       string    `json:"service"`
+	Reference     string    `json:"reference"`
+	Amount        int       `json:"amount"`
+	Fee           int       `json:"fee"`
+	Total         int       `json:"total"`
+}
+
+// lines are the receipt fields in the order every format shows them.
+func lines(receipt core.Receipt) [][2]string {
+	return [][2]string{
+		{"Receipt", receipt.Number},
+		{"Date", formatTime(receipt.Time)},
+		{"Transaction", strconv.FormatInt(receipt.TransactionId, 10)},
+		{"Card", receipt.MaskedPAN},
+		{"Service", receipt.Service},
+		{"Reference", receipt.Reference},
+		{"Amount", strconv.Itoa(receipt.Amount)},
+		{"Fee", strconv.Itoa(receipt.Fee)},
+		{"Total", strconv.Itoa(receipt.Total())},
+	}
+}
+
+func WriteText(w io.Writer, receipt core.Receipt) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, line := range lines(receipt) {
+		_, err := fmt.Fprintf(writer, "%s:\t%s\n", line[0], line[1])
+		if err != nil {
+			return fmt.Errorf("can't write text receipt: %w", err)
+		}
+	}
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("can't write text receipt: %w", err)
+	}
+	return nil
+}
+
+func WriteJSON(w io.Writer, receipt core.Receipt) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(jsonReceipt{
+		Number:        receipt.Number,
+		TransactionId: receipt.TransactionId,
+		Time:          receipt.Time.UTC(),
+		Card:          receipt.MaskedPAN,
+		Service:       receipt.Service,
+		Reference:     receipt.Reference,
+		Amount:        receipt.Amount,
+		Fee:           receipt.Fee,
+		Total:         receipt.Total(),
+	})
+	if err != nil {
+		return fmt.Errorf("can't write json receipt: %w", err)
+	}
+	return nil
+}
+
+// WritePDF renders an A6 receipt dated with the payment, so a receipt
+// always renders to the same bytes.
+func WritePDF(w io.Writer, receipt core.Receipt) error {
+	pdf := gofpdf.New("P", "mm", "A6", "")
+	pdf.SetCatalogSort(true)
+	pdf.SetCreationDate(receipt.Time.UTC())
+	pdf.SetModificationDate(receipt.Time.UTC())
+	pdf.SetTitle("Receipt "+receipt.Number, false)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 10, "Payment receipt", "", 1, "L", false, 0, "")
+	for _, line := range lines(receipt) {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(30, 6, line[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 6, line[1], "", 1, "L", false, 0, "")
+	}
+
+	err := pdf.Output(w)
+	if err != nil {
+		return fmt.Errorf("can't write pdf receipt: %w", err)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,61 @@
+package receipt
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func sample() core.Receipt {
+	return core.Receipt{
+		Number:        "20260305-00000009",
+		TransactionId: 9,
+		Time:          time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC),
+		MaskedPAN:     "202160******0001",
+		Service:       "internet",
+		Reference:     "100200",
+		Amount:        200,
+		Fee:           3,
+	}
+}
+
+func checkGolden(t *testing.T, name string, write func(io.Writer, core.Receipt) error) {
+	var out bytes.Buffer
+	err := write(&out, sample())
+	if err != nil {
+		t.Fatalf("can't write %s: %v", name, err)
+	}
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, out.Bytes(), 0644); err != nil {
+			t.Fatalf("can't update %s: %v", path, err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("can't read %s: %v", path, err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("%s differs from the golden file, run go test -update if the change is intended", name)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	checkGolden(t, "receipt.txt", WriteText)
+}
+
+func TestWriteJSON(t *testing.T) {
+	checkGolden(t, "receipt.json", WriteJSON)
+}
+
+func TestWritePDF(t *testing.T) {
+	checkGolden(t, "receipt.pdf", WritePDF)
+}
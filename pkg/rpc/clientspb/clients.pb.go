@@ -0,0 +1,1285 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: clients.proto
+
+package clientspb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// Card mirrors core.Card without the PIN and CVV.
+type Card struct {
+	Id                   int64    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Pan                  int64    `protobuf:"varint,2,opt,name=pan,proto3" json:"pan,omitempty"`
+	Balance              int64    `protobuf:"varint,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	HolderName           string   `protobuf:"bytes,4,opt,name=holder_name,json=holderName,proto3" json:"holder_name,omitempty"`
+	Validity             int32    `protobuf:"varint,5,opt,name=validity,proto3" json:"validity,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Card) Reset()         { *m = Card{} }
+func (m *Card) String() string { return proto.CompactTextString(m) }
+func (*Card) ProtoMessage()    {}
+func (*Card) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{0}
+}
+
+func (m *Card) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Card.Unmarshal(m, b)
+}
+func (m *Card) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Card.Marshal(b, m, deterministic)
+}
+func (m *Card) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Card.Merge(m, src)
+}
+func (m *Card) XXX_Size() int {
+	return xxx_messageInfo_Card.Size(m)
+}
+func (m *Card) XXX_DiscardUnknown() {
+	xxx_messageInfo_Card.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Card proto.InternalMessageInfo
+
+func (m *Card) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Card) GetPan() int64 {
+	if m != nil {
+		return m.Pan
+	}
+	return 0
+}
+
+func (m *Card) GetBalance() int64 {
+	if m != nil {
+		return m.Balance
+	}
+	return 0
+}
+
+func (m *Card) GetHolderName() string {
+	if m != nil {
+		return m.HolderName
+	}
+	return ""
+}
+
+func (m *Card) GetValidity() int32 {
+	if m != nil {
+		return m.Validity
+	}
+	return 0
+}
+
+// Atm mirrors core.Atm.
+type Atm struct {
+	Id                   int64    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	City                 string   `protobuf:"bytes,2,opt,name=city,proto3" json:"city,omitempty"`
+	District             string   `protobuf:"bytes,3,opt,name=district,proto3" json:"district,omitempty"`
+	Street               string   `protobuf:"bytes,4,opt,name=street,proto3" json:"street,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Atm) Reset()         { *m = Atm{} }
+func (m *Atm) String() string { return proto.CompactTextString(m) }
+func (*Atm) ProtoMessage()    {}
+func (*Atm) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{1}
+}
+
+func (m *Atm) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Atm.Unmarshal(m, b)
+}
+func (m *Atm) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Atm.Marshal(b, m, deterministic)
+}
+func (m *Atm) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Atm.Merge(m, src)
+}
+func (m *Atm) XXX_Size() int {
+	return xxx_messageInfo_Atm.Size(m)
+}
+func (m *Atm) XXX_DiscardUnknown() {
+	xxx_messageInfo_Atm.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Atm proto.InternalMessageInfo
+
+func (m *Atm) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Atm) GetCity() string {
+	if m != nil {
+		return m.City
+	}
+	return ""
+}
+
+func (m *Atm) GetDistrict() string {
+	if m != nil {
+		return m.District
+	}
+	return ""
+}
+
+func (m *Atm) GetStreet() string {
+	if m != nil {
+		return m.Street
+	}
+	return ""
+}
+
+// Service is a catalog entry: what identifies the customer's account at
+// the provider and how much may be paid.
+type Service struct {
+	Id              int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Service         string `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
+	Category        string `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	ReferenceLabel  string `protobuf:"bytes,4,opt,name=reference_label,json=referenceLabel,proto3" json:"reference_label,omitempty"`
+	ReferenceFormat string `protobuf:"bytes,5,opt,name=reference_format,json=referenceFormat,proto3" json:"reference_format,omitempty"`
+	MinAmount       int64  `protobuf:"varint,6,opt,name=min_amount,json=minAmount,proto3" json:"min_amount,omitempty"`
+	// max_amount is unlimited when zero.
+	MaxAmount int64 `protobuf:"varint,7,opt,name=max_amount,json=maxAmount,proto3" json:"max_amount,omitempty"`
+	Active    bool  `protobuf:"varint,8,opt,name=active,proto3" json:"active,omitempty"`
+	// fee is charged to the card on top of the amount.
+	Fee                  int64    `protobuf:"varint,9,opt,name=fee,proto3" json:"fee,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Service) Reset()         { *m = Service{} }
+func (m *Service) String() string { return proto.CompactTextString(m) }
+func (*Service) ProtoMessage()    {}
+func (*Service) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{2}
+}
+
+func (m *Service) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Service.Unmarshal(m, b)
+}
+func (m *Service) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Service.Marshal(b, m, deterministic)
+}
+func (m *Service) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Service.Merge(m, src)
+}
+func (m *Service) XXX_Size() int {
+	return xxx_messageInfo_Service.Size(m)
+}
+func (m *Service) XXX_DiscardUnknown() {
+	xxx_messageInfo_Service.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Service proto.InternalMessageInfo
+
+func (m *Service) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Service) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *Service) GetCategory() string {
+	if m != nil {
+		return m.Category
+	}
+	return ""
+}
+
+func (m *Service) GetReferenceLabel() string {
+	if m != nil {
+		return m.ReferenceLabel
+	}
+	return ""
+}
+
+func (m *Service) GetReferenceFormat() string {
+	if m != nil {
+		return m.ReferenceFormat
+	}
+	return ""
+}
+
+func (m *Service) GetMinAmount() int64 {
+	if m != nil {
+		return m.MinAmount
+	}
+	return 0
+}
+
+func (m *Service) GetMaxAmount() int64 {
+	if m != nil {
+		return m.MaxAmount
+	}
+	return 0
+}
+
+func (m *Service) GetActive() bool {
+	if m != nil {
+		return m.Active
+	}
+	return false
+}
+
+func (m *Service) GetFee() int64 {
+	if m != nil {
+		return m.Fee
+	}
+	return 0
+}
+
+type SignInRequest struct {
+	Login                string   `protobuf:"bytes,1,opt,name=login,proto3" json:"login,omitempty"`
+	Password             string   `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SignInRequest) Reset()         { *m = SignInRequest{} }
+func (m *SignInRequest) String() string { return proto.CompactTextString(m) }
+func (*SignInRequest) ProtoMessage()    {}
+func (*SignInRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{3}
+}
+
+func (m *SignInRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignInRequest.Unmarshal(m, b)
+}
+func (m *SignInRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignInRequest.Marshal(b, m, deterministic)
+}
+func (m *SignInRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignInRequest.Merge(m, src)
+}
+func (m *SignInRequest) XXX_Size() int {
+	return xxx_messageInfo_SignInRequest.Size(m)
+}
+func (m *SignInRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignInRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SignInRequest proto.InternalMessageInfo
+
+func (m *SignInRequest) GetLogin() string {
+	if m != nil {
+		return m.Login
+	}
+	return ""
+}
+
+func (m *SignInRequest) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+type SignInResponse struct {
+	ClientId             int64    `protobuf:"varint,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SignInResponse) Reset()         { *m = SignInResponse{} }
+func (m *SignInResponse) String() string { return proto.CompactTextString(m) }
+func (*SignInResponse) ProtoMessage()    {}
+func (*SignInResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{4}
+}
+
+func (m *SignInResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignInResponse.Unmarshal(m, b)
+}
+func (m *SignInResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignInResponse.Marshal(b, m, deterministic)
+}
+func (m *SignInResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignInResponse.Merge(m, src)
+}
+func (m *SignInResponse) XXX_Size() int {
+	return xxx_messageInfo_SignInResponse.Size(m)
+}
+func (m *SignInResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignInResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SignInResponse proto.InternalMessageInfo
+
+func (m *SignInResponse) GetClientId() int64 {
+	if m != nil {
+		return m.ClientId
+	}
+	return 0
+}
+
+type ListCardsRequest struct {
+	ClientId             int64    `protobuf:"varint,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListCardsRequest) Reset()         { *m = ListCardsRequest{} }
+func (m *ListCardsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListCardsRequest) ProtoMessage()    {}
+func (*ListCardsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{5}
+}
+
+func (m *ListCardsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListCardsRequest.Unmarshal(m, b)
+}
+func (m *ListCardsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListCardsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListCardsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListCardsRequest.Merge(m, src)
+}
+func (m *ListCardsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListCardsRequest.Size(m)
+}
+func (m *ListCardsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListCardsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListCardsRequest proto.InternalMessageInfo
+
+func (m *ListCardsRequest) GetClientId() int64 {
+	if m != nil {
+		return m.ClientId
+	}
+	return 0
+}
+
+type ListCardsResponse struct {
+	Cards                []*Card  `protobuf:"bytes,1,rep,name=cards,proto3" json:"cards,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListCardsResponse) Reset()         { *m = ListCardsResponse{} }
+func (m *ListCardsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListCardsResponse) ProtoMessage()    {}
+func (*ListCardsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{6}
+}
+
+func (m *ListCardsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListCardsResponse.Unmarshal(m, b)
+}
+func (m *ListCardsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListCardsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListCardsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListCardsResponse.Merge(m, src)
+}
+func (m *ListCardsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListCardsResponse.Size(m)
+}
+func (m *ListCardsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListCardsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListCardsResponse proto.InternalMessageInfo
+
+func (m *ListCardsResponse) GetCards() []*Card {
+	if m != nil {
+		return m.Cards
+	}
+	return nil
+}
+
+type GetBalanceRequest struct {
+	Pan                  int64    `protobuf:"varint,1,opt,name=pan,proto3" json:"pan,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBalanceRequest) Reset()         { *m = GetBalanceRequest{} }
+func (m *GetBalanceRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBalanceRequest) ProtoMessage()    {}
+func (*GetBalanceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{7}
+}
+
+func (m *GetBalanceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBalanceRequest.Unmarshal(m, b)
+}
+func (m *GetBalanceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBalanceRequest.Marshal(b, m, deterministic)
+}
+func (m *GetBalanceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBalanceRequest.Merge(m, src)
+}
+func (m *GetBalanceRequest) XXX_Size() int {
+	return xxx_messageInfo_GetBalanceRequest.Size(m)
+}
+func (m *GetBalanceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBalanceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBalanceRequest proto.InternalMessageInfo
+
+func (m *GetBalanceRequest) GetPan() int64 {
+	if m != nil {
+		return m.Pan
+	}
+	return 0
+}
+
+type GetBalanceResponse struct {
+	Pan     int64 `protobuf:"varint,1,opt,name=pan,proto3" json:"pan,omitempty"`
+	Balance int64 `protobuf:"varint,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	// available is balance less the money held by authorizations.
+	Available            int64    `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBalanceResponse) Reset()         { *m = GetBalanceResponse{} }
+func (m *GetBalanceResponse) String() string { return proto.CompactTextString(m) }
+func (*GetBalanceResponse) ProtoMessage()    {}
+func (*GetBalanceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{8}
+}
+
+func (m *GetBalanceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBalanceResponse.Unmarshal(m, b)
+}
+func (m *GetBalanceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBalanceResponse.Marshal(b, m, deterministic)
+}
+func (m *GetBalanceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBalanceResponse.Merge(m, src)
+}
+func (m *GetBalanceResponse) XXX_Size() int {
+	return xxx_messageInfo_GetBalanceResponse.Size(m)
+}
+func (m *GetBalanceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBalanceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBalanceResponse proto.InternalMessageInfo
+
+func (m *GetBalanceResponse) GetPan() int64 {
+	if m != nil {
+		return m.Pan
+	}
+	return 0
+}
+
+func (m *GetBalanceResponse) GetBalance() int64 {
+	if m != nil {
+		return m.Balance
+	}
+	return 0
+}
+
+func (m *GetBalanceResponse) GetAvailable() int64 {
+	if m != nil {
+		return m.Available
+	}
+	return 0
+}
+
+// TransferRequest moves money between two cards, like core.MoreCard.
+type TransferRequest struct {
+	FromPan              int64    `protobuf:"varint,1,opt,name=from_pan,json=fromPan,proto3" json:"from_pan,omitempty"`
+	ToPan                int64    `protobuf:"varint,2,opt,name=to_pan,json=toPan,proto3" json:"to_pan,omitempty"`
+	Amount               int64    `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferRequest) Reset()         { *m = TransferRequest{} }
+func (m *TransferRequest) String() string { return proto.CompactTextString(m) }
+func (*TransferRequest) ProtoMessage()    {}
+func (*TransferRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{9}
+}
+
+func (m *TransferRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferRequest.Unmarshal(m, b)
+}
+func (m *TransferRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferRequest.Marshal(b, m, deterministic)
+}
+func (m *TransferRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferRequest.Merge(m, src)
+}
+func (m *TransferRequest) XXX_Size() int {
+	return xxx_messageInfo_TransferRequest.Size(m)
+}
+func (m *TransferRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferRequest proto.InternalMessageInfo
+
+func (m *TransferRequest) GetFromPan() int64 {
+	if m != nil {
+		return m.FromPan
+	}
+	return 0
+}
+
+func (m *TransferRequest) GetToPan() int64 {
+	if m != nil {
+		return m.ToPan
+	}
+	return 0
+}
+
+func (m *TransferRequest) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+type TransferResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferResponse) Reset()         { *m = TransferResponse{} }
+func (m *TransferResponse) String() string { return proto.CompactTextString(m) }
+func (*TransferResponse) ProtoMessage()    {}
+func (*TransferResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{10}
+}
+
+func (m *TransferResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferResponse.Unmarshal(m, b)
+}
+func (m *TransferResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferResponse.Marshal(b, m, deterministic)
+}
+func (m *TransferResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferResponse.Merge(m, src)
+}
+func (m *TransferResponse) XXX_Size() int {
+	return xxx_messageInfo_TransferResponse.Size(m)
+}
+func (m *TransferResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferResponse proto.InternalMessageInfo
+
+type ListServicesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListServicesRequest) Reset()         { *m = ListServicesRequest{} }
+func (m *ListServicesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListServicesRequest) ProtoMessage()    {}
+func (*ListServicesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{11}
+}
+
+func (m *ListServicesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListServicesRequest.Unmarshal(m, b)
+}
+func (m *ListServicesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListServicesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListServicesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListServicesRequest.Merge(m, src)
+}
+func (m *ListServicesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListServicesRequest.Size(m)
+}
+func (m *ListServicesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListServicesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListServicesRequest proto.InternalMessageInfo
+
+type ListServicesResponse struct {
+	Services             []*Service `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ListServicesResponse) Reset()         { *m = ListServicesResponse{} }
+func (m *ListServicesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListServicesResponse) ProtoMessage()    {}
+func (*ListServicesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{12}
+}
+
+func (m *ListServicesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListServicesResponse.Unmarshal(m, b)
+}
+func (m *ListServicesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListServicesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListServicesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListServicesResponse.Merge(m, src)
+}
+func (m *ListServicesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListServicesResponse.Size(m)
+}
+func (m *ListServicesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListServicesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListServicesResponse proto.InternalMessageInfo
+
+func (m *ListServicesResponse) GetServices() []*Service {
+	if m != nil {
+		return m.Services
+	}
+	return nil
+}
+
+// PayServiceRequest pays a service from a card, like core.ServicesPayMoreCard.
+type PayServiceRequest struct {
+	Service              string   `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	FromPan              int64    `protobuf:"varint,2,opt,name=from_pan,json=fromPan,proto3" json:"from_pan,omitempty"`
+	Amount               int64    `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Reference            string   `protobuf:"bytes,4,opt,name=reference,proto3" json:"reference,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PayServiceRequest) Reset()         { *m = PayServiceRequest{} }
+func (m *PayServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*PayServiceRequest) ProtoMessage()    {}
+func (*PayServiceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{13}
+}
+
+func (m *PayServiceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PayServiceRequest.Unmarshal(m, b)
+}
+func (m *PayServiceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PayServiceRequest.Marshal(b, m, deterministic)
+}
+func (m *PayServiceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PayServiceRequest.Merge(m, src)
+}
+func (m *PayServiceRequest) XXX_Size() int {
+	return xxx_messageInfo_PayServiceRequest.Size(m)
+}
+func (m *PayServiceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PayServiceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PayServiceRequest proto.InternalMessageInfo
+
+func (m *PayServiceRequest) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *PayServiceRequest) GetFromPan() int64 {
+	if m != nil {
+		return m.FromPan
+	}
+	return 0
+}
+
+func (m *PayServiceRequest) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+func (m *PayServiceRequest) GetReference() string {
+	if m != nil {
+		return m.Reference
+	}
+	return ""
+}
+
+// PayServiceResponse carries the receipt written with the payment.
+type PayServiceResponse struct {
+	ReceiptNumber        string   `protobuf:"bytes,1,opt,name=receipt_number,json=receiptNumber,proto3" json:"receipt_number,omitempty"`
+	TransactionId        int64    `protobuf:"varint,2,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	Time                 int64    `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Card                 string   `protobuf:"bytes,4,opt,name=card,proto3" json:"card,omitempty"`
+	Amount               int64    `protobuf:"varint,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Fee                  int64    `protobuf:"varint,6,opt,name=fee,proto3" json:"fee,omitempty"`
+	Total                int64    `protobuf:"varint,7,opt,name=total,proto3" json:"total,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PayServiceResponse) Reset()         { *m = PayServiceResponse{} }
+func (m *PayServiceResponse) String() string { return proto.CompactTextString(m) }
+func (*PayServiceResponse) ProtoMessage()    {}
+func (*PayServiceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{14}
+}
+
+func (m *PayServiceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PayServiceResponse.Unmarshal(m, b)
+}
+func (m *PayServiceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PayServiceResponse.Marshal(b, m, deterministic)
+}
+func (m *PayServiceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PayServiceResponse.Merge(m, src)
+}
+func (m *PayServiceResponse) XXX_Size() int {
+	return xxx_messageInfo_PayServiceResponse.Size(m)
+}
+func (m *PayServiceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PayServiceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PayServiceResponse proto.InternalMessageInfo
+
+func (m *PayServiceResponse) GetReceiptNumber() string {
+	if m != nil {
+		return m.ReceiptNumber
+	}
+	return ""
+}
+
+func (m *PayServiceResponse) GetTransactionId() int64 {
+	if m != nil {
+		return m.TransactionId
+	}
+	return 0
+}
+
+func (m *PayServiceResponse) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *PayServiceResponse) GetCard() string {
+	if m != nil {
+		return m.Card
+	}
+	return ""
+}
+
+func (m *PayServiceResponse) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+func (m *PayServiceResponse) GetFee() int64 {
+	if m != nil {
+		return m.Fee
+	}
+	return 0
+}
+
+func (m *PayServiceResponse) GetTotal() int64 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+type ListAtmsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListAtmsRequest) Reset()         { *m = ListAtmsRequest{} }
+func (m *ListAtmsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListAtmsRequest) ProtoMessage()    {}
+func (*ListAtmsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{15}
+}
+
+func (m *ListAtmsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAtmsRequest.Unmarshal(m, b)
+}
+func (m *ListAtmsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAtmsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListAtmsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAtmsRequest.Merge(m, src)
+}
+func (m *ListAtmsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListAtmsRequest.Size(m)
+}
+func (m *ListAtmsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAtmsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAtmsRequest proto.InternalMessageInfo
+
+type ListAtmsResponse struct {
+	Atms                 []*Atm   `protobuf:"bytes,1,rep,name=atms,proto3" json:"atms,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListAtmsResponse) Reset()         { *m = ListAtmsResponse{} }
+func (m *ListAtmsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListAtmsResponse) ProtoMessage()    {}
+func (*ListAtmsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6c7b36ecb5ad4a28, []int{16}
+}
+
+func (m *ListAtmsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAtmsResponse.Unmarshal(m, b)
+}
+func (m *ListAtmsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAtmsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListAtmsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAtmsResponse.Merge(m, src)
+}
+func (m *ListAtmsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListAtmsResponse.Size(m)
+}
+func (m *ListAtmsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAtmsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAtmsResponse proto.InternalMessageInfo
+
+func (m *ListAtmsResponse) GetAtms() []*Atm {
+	if m != nil {
+		return m.Atms
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Card)(nil), "clientscore.v1.Card")
+	proto.RegisterType((*Atm)(nil), "clientscore.v1.Atm")
+	proto.RegisterType((*Service)(nil), "clientscore.v1.Service")
+	proto.RegisterType((*SignInRequest)(nil), "clientscore.v1.SignInRequest")
+	proto.RegisterType((*SignInResponse)(nil), "clientscore.v1.SignInResponse")
+	proto.RegisterType((*ListCardsRequest)(nil), "clientscore.v1.ListCardsRequest")
+	proto.RegisterType((*ListCardsResponse)(nil), "clientscore.v1.ListCardsResponse")
+	proto.RegisterType((*GetBalanceRequest)(nil), "clientscore.v1.GetBalanceRequest")
+	proto.RegisterType((*GetBalanceResponse)(nil), "clientscore.v1.GetBalanceResponse")
+	proto.RegisterType((*TransferRequest)(nil), "clientscore.v1.TransferRequest")
+	proto.RegisterType((*TransferResponse)(nil), "clientscore.v1.TransferResponse")
+	proto.RegisterType((*ListServicesRequest)(nil), "clientscore.v1.ListServicesRequest")
+	proto.RegisterType((*ListServicesResponse)(nil), "clientscore.v1.ListServicesResponse")
+	proto.RegisterType((*PayServiceRequest)(nil), "clientscore.v1.PayServiceRequest")
+	proto.RegisterType((*PayServiceResponse)(nil), "clientscore.v1.PayServiceResponse")
+	proto.RegisterType((*ListAtmsRequest)(nil), "clientscore.v1.ListAtmsRequest")
+	proto.RegisterType((*ListAtmsResponse)(nil), "clientscore.v1.ListAtmsResponse")
+}
+
+func init() {
+	proto.RegisterFile("clients.proto", fileDescriptor_6c7b36ecb5ad4a28)
+}
+
+var fileDescriptor_6c7b36ecb5ad4a28 = []byte{
+	// 865 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x56, 0xdb, 0x8e, 0xdb, 0x44,
+	0x18, 0x96, 0x73, 0xf6, 0xbf, 0x6c, 0x36, 0x3b, 0xdd, 0x16, 0x13, 0x28, 0x0d, 0x03, 0x55, 0x03,
+	0x52, 0x13, 0xb5, 0xe5, 0xa6, 0xea, 0x45, 0x95, 0xae, 0x44, 0xb5, 0xa2, 0x54, 0x2b, 0x2f, 0x37,
+	0x80, 0x20, 0x9a, 0x38, 0x93, 0xec, 0x08, 0xdb, 0x13, 0xc6, 0xb3, 0x61, 0xf7, 0x06, 0x1e, 0x80,
+	0x37, 0x83, 0x97, 0x42, 0x73, 0xb2, 0x1d, 0xbb, 0xc9, 0xdd, 0xfc, 0xdf, 0x7c, 0xf3, 0x9f, 0x3f,
+	0xcb, 0x70, 0x1c, 0xc5, 0x8c, 0xa6, 0x32, 0x9b, 0x6c, 0x04, 0x97, 0x1c, 0xf5, 0xad, 0x19, 0x71,
+	0x41, 0x27, 0xdb, 0x67, 0xf8, 0x6f, 0x68, 0x9d, 0x13, 0xb1, 0x44, 0x7d, 0x68, 0xb0, 0x65, 0xe0,
+	0x8d, 0xbc, 0x71, 0x33, 0x6c, 0xb0, 0x25, 0x1a, 0x40, 0x73, 0x43, 0xd2, 0xa0, 0xa1, 0x01, 0x75,
+	0x44, 0x01, 0x74, 0x17, 0x24, 0x26, 0x69, 0x44, 0x83, 0xa6, 0x46, 0x9d, 0x89, 0x1e, 0xc1, 0xd1,
+	0x35, 0x8f, 0x97, 0x54, 0xcc, 0x53, 0x92, 0xd0, 0xa0, 0x35, 0xf2, 0xc6, 0x7e, 0x08, 0x06, 0x7a,
+	0x4f, 0x12, 0x8a, 0x86, 0xd0, 0xdb, 0x92, 0x98, 0x2d, 0x99, 0xbc, 0x0b, 0xda, 0x23, 0x6f, 0xdc,
+	0x0e, 0x73, 0x1b, 0xff, 0x0a, 0xcd, 0x99, 0x4c, 0x6a, 0xf1, 0x11, 0xb4, 0x22, 0x45, 0x6f, 0x68,
+	0x67, 0xfa, 0xac, 0xdc, 0x2c, 0x59, 0x26, 0x05, 0x8b, 0xa4, 0x4e, 0xc1, 0x0f, 0x73, 0x1b, 0x3d,
+	0x80, 0x4e, 0x26, 0x05, 0xa5, 0xd2, 0x86, 0xb7, 0x16, 0xfe, 0xa7, 0x01, 0xdd, 0x2b, 0x2a, 0xb6,
+	0x2c, 0xa2, 0xb5, 0x18, 0x01, 0x74, 0x33, 0x73, 0x65, 0xc3, 0x38, 0x53, 0x45, 0x8a, 0x88, 0xa4,
+	0x6b, 0x2e, 0xee, 0x5c, 0x24, 0x67, 0xa3, 0x27, 0x70, 0x22, 0xe8, 0x8a, 0x0a, 0x9a, 0x46, 0x74,
+	0x1e, 0x93, 0x05, 0x8d, 0x6d, 0xc8, 0x7e, 0x0e, 0xbf, 0x53, 0x28, 0xfa, 0x1a, 0x06, 0x05, 0x71,
+	0xc5, 0x45, 0x42, 0xa4, 0xae, 0xde, 0x0f, 0x0b, 0x07, 0xdf, 0x69, 0x18, 0x3d, 0x04, 0x48, 0x58,
+	0x3a, 0x27, 0x09, 0xbf, 0x49, 0x65, 0xd0, 0xd1, 0x19, 0xfa, 0x09, 0x4b, 0x67, 0x1a, 0xd0, 0xd7,
+	0xe4, 0xd6, 0x5d, 0x77, 0xed, 0x35, 0xb9, 0xb5, 0xd7, 0x0f, 0xa0, 0x43, 0x22, 0xc9, 0xb6, 0x34,
+	0xe8, 0x8d, 0xbc, 0x71, 0x2f, 0xb4, 0x96, 0x9a, 0xe1, 0x8a, 0xd2, 0xc0, 0x37, 0x33, 0x5c, 0x51,
+	0x8a, 0x67, 0x70, 0x7c, 0xc5, 0xd6, 0xe9, 0x45, 0x1a, 0xd2, 0x3f, 0x6e, 0x68, 0x26, 0xd1, 0x19,
+	0xb4, 0x63, 0xbe, 0x66, 0xa9, 0xee, 0x8a, 0x1f, 0x1a, 0x43, 0x95, 0xbf, 0x21, 0x59, 0xf6, 0x27,
+	0x17, 0x4b, 0xdb, 0x99, 0xdc, 0xc6, 0x4f, 0xa1, 0xef, 0x5c, 0x64, 0x1b, 0x9e, 0x66, 0x14, 0x7d,
+	0x0a, 0xbe, 0x59, 0xaa, 0x79, 0xde, 0xdd, 0x9e, 0x01, 0x2e, 0x96, 0x78, 0x0a, 0x83, 0x77, 0x2c,
+	0x93, 0x6a, 0xc7, 0x32, 0x17, 0xf4, 0xe0, 0x83, 0xd7, 0x70, 0x5a, 0x7a, 0x60, 0x43, 0x7c, 0x03,
+	0xed, 0x48, 0x01, 0x81, 0x37, 0x6a, 0x8e, 0x8f, 0x9e, 0x9f, 0x4d, 0x76, 0xb7, 0x78, 0xa2, 0xd8,
+	0xa1, 0xa1, 0xe0, 0xc7, 0x70, 0xfa, 0x96, 0xca, 0x37, 0x66, 0x37, 0x5d, 0x48, 0xbb, 0xce, 0x5e,
+	0xbe, 0xce, 0xf8, 0x37, 0x40, 0x65, 0x9a, 0x0d, 0x54, 0xe3, 0x95, 0xd7, 0xbe, 0xb1, 0xbb, 0xf6,
+	0x9f, 0x81, 0x4f, 0xb6, 0x84, 0xc5, 0x64, 0x11, 0x3b, 0x49, 0x14, 0x00, 0xfe, 0x05, 0x4e, 0x7e,
+	0x14, 0x24, 0xcd, 0x56, 0x54, 0xb8, 0x24, 0x3e, 0x81, 0xde, 0x4a, 0xf0, 0x64, 0x5e, 0x44, 0xe8,
+	0x2a, 0xfb, 0x92, 0xa4, 0xe8, 0x3e, 0x74, 0x24, 0x9f, 0x17, 0x8a, 0x6b, 0x4b, 0xae, 0x60, 0x35,
+	0x59, 0x33, 0x74, 0xe3, 0xdf, 0x5a, 0x18, 0xc1, 0xa0, 0x70, 0x6e, 0x52, 0xc7, 0xf7, 0xe1, 0x9e,
+	0x6a, 0x9c, 0x5d, 0x76, 0xd7, 0x6c, 0xfc, 0x3d, 0x9c, 0xed, 0xc2, 0xb6, 0xd2, 0x17, 0xd0, 0xb3,
+	0xdb, 0xee, 0xba, 0xfa, 0x71, 0xb5, 0xab, 0xf6, 0x4d, 0x98, 0x13, 0xf1, 0x5f, 0x70, 0x7a, 0x49,
+	0xee, 0x1c, 0x6e, 0xcb, 0x2a, 0xc9, 0xc8, 0xdb, 0x95, 0x51, 0xb9, 0xe0, 0xc6, 0x6e, 0xc1, 0x7b,
+	0x2a, 0x53, 0x4d, 0xcd, 0xc5, 0x61, 0x75, 0x55, 0x00, 0xf8, 0x5f, 0x0f, 0x50, 0x39, 0x01, 0x5b,
+	0xcb, 0x63, 0xe8, 0x0b, 0x1a, 0x51, 0xb6, 0x91, 0xf3, 0xf4, 0x26, 0x59, 0x50, 0x61, 0x13, 0x39,
+	0xb6, 0xe8, 0x7b, 0x0d, 0x2a, 0x9a, 0x54, 0x5d, 0x53, 0xf2, 0xe0, 0xa9, 0x5a, 0x3e, 0x93, 0xd4,
+	0x71, 0x09, 0xbd, 0xd0, 0x9f, 0x1e, 0xc9, 0x12, 0x37, 0x52, 0x7d, 0x56, 0x98, 0xda, 0x2e, 0x9b,
+	0x91, 0x3e, 0x97, 0x4a, 0x68, 0xef, 0x94, 0x60, 0x65, 0xd7, 0xc9, 0x65, 0xa7, 0x54, 0x26, 0xb9,
+	0x24, 0xb1, 0x95, 0xae, 0x31, 0xf0, 0x29, 0x9c, 0xa8, 0xc9, 0xcc, 0x64, 0x92, 0x0f, 0xeb, 0x15,
+	0x0c, 0x0a, 0xc8, 0x16, 0xf7, 0x04, 0x5a, 0x44, 0x26, 0x6e, 0x48, 0xf7, 0xaa, 0x43, 0x9a, 0xc9,
+	0x24, 0xd4, 0x84, 0xe7, 0xff, 0xb5, 0xe0, 0xe8, 0xdc, 0x5c, 0x9e, 0x73, 0x41, 0xd1, 0x5b, 0xe8,
+	0x18, 0xa5, 0xa2, 0x87, 0xb5, 0xc9, 0x96, 0x3f, 0x02, 0xc3, 0xcf, 0xf7, 0x5d, 0xdb, 0x0c, 0x2e,
+	0xc1, 0xcf, 0x25, 0x89, 0x46, 0x55, 0x72, 0x55, 0xde, 0xc3, 0x2f, 0x0e, 0x30, 0xac, 0xc7, 0x2b,
+	0x80, 0x42, 0x7c, 0xa8, 0xf6, 0xa0, 0xa6, 0xdf, 0x21, 0x3e, 0x44, 0xb1, 0x4e, 0x7f, 0x80, 0x9e,
+	0x13, 0x05, 0x7a, 0x54, 0xe5, 0x57, 0xb4, 0x38, 0x1c, 0xed, 0x27, 0x58, 0x77, 0x3f, 0xc1, 0x47,
+	0x65, 0xe1, 0xa0, 0x2f, 0x3f, 0x54, 0x56, 0x45, 0x6d, 0xc3, 0xaf, 0x0e, 0x93, 0x8a, 0xf2, 0x8b,
+	0x2d, 0xae, 0x97, 0x5f, 0x93, 0xd8, 0x10, 0x1f, 0xa2, 0x14, 0xe5, 0xbb, 0xdd, 0xa9, 0x97, 0x5f,
+	0x59, 0xb4, 0xe1, 0x68, 0x3f, 0xc1, 0xb8, 0x7b, 0x33, 0xfb, 0xf9, 0xf5, 0x9a, 0xc9, 0xeb, 0x9b,
+	0xc5, 0x24, 0xe2, 0xc9, 0x54, 0xf2, 0x6b, 0x26, 0xf8, 0xf6, 0xd9, 0xcb, 0x97, 0xdf, 0x4e, 0xed,
+	0xcb, 0xa7, 0xea, 0xe9, 0x74, 0xf3, 0xfb, 0x7a, 0x2a, 0x36, 0x91, 0x03, 0x37, 0x8b, 0x57, 0xf9,
+	0x69, 0xd1, 0xd1, 0xbf, 0x1c, 0x2f, 0xfe, 0x1f, 0x00, 0xef, 0x8b, 0x52, 0x3b, 0x83, 0x08, 0x00,
+	0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ClientsCoreClient is the client API for ClientsCore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ClientsCoreClient interface {
+	SignIn(ctx context.Context, in *SignInRequest, opts ...grpc.CallOption) (*SignInResponse, error)
+	ListCards(ctx context.Context, in *ListCardsRequest, opts ...grpc.CallOption) (*ListCardsResponse, error)
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (*ListServicesResponse, error)
+	PayService(ctx context.Context, in *PayServiceRequest, opts ...grpc.CallOption) (*PayServiceResponse, error)
+	ListAtms(ctx context.Context, in *ListAtmsRequest, opts ...grpc.CallOption) (*ListAtmsResponse, error)
+}
+
+type clientsCoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClientsCoreClient(cc grpc.ClientConnInterface) ClientsCoreClient {
+	return &clientsCoreClient{cc}
+}
+
+func (c *clientsCoreClient) SignIn(ctx context.Context, in *SignInRequest, opts ...grpc.CallOption) (*SignInResponse, error) {
+	out := new(SignInResponse)
+	err := c.cc.Invoke(ctx, "/clientscore.v1.ClientsCore/SignIn", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientsCoreClient) ListCards(ctx context.Context, in *ListCardsRequest, opts ...grpc.CallOption) (*ListCardsResponse, error) {
+	out := new(ListCardsResponse)
+	err := c.cc.Invoke(ctx, "/clientscore.v1.ClientsCore/ListCards", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientsCoreClient) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error) {
+	out := new(GetBalanceResponse)
+	err := c.cc.Invoke(ctx, "/clientscore.v1.ClientsCore/GetBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientsCoreClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error) {
+	out := new(TransferResponse)
+	err := c.cc.Invoke(ctx, "/clientscore.v1.ClientsCore/Transfer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientsCoreClient) ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (*ListServicesResponse, error) {
+	out := new(ListServicesResponse)
+	err := c.cc.Invoke(ctx, "/clientscore.v1.ClientsCore/ListServices", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientsCoreClient) PayService(ctx context.Context, in *PayServiceRequest, opts ...grpc.CallOption) (*PayServiceResponse, error) {
+	out := new(PayServiceResponse)
+	err := c.cc.Invoke(ctx, "/clientscore.v1.ClientsCore/PayService", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientsCoreClient) ListAtms(ctx context.Context, in *ListAtmsRequest, opts ...grpc.CallOption) (*ListAtmsResponse, error) {
+	out := new(ListAtmsResponse)
+	err := c.cc.Invoke(ctx, "/clientscore.v1.ClientsCore/ListAtms", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClientsCoreServer is the server API for ClientsCore service.
+type ClientsCoreServer interface {
+	SignIn(context.Context, *SignInRequest) (*SignInResponse, error)
+	ListCards(context.Context, *ListCardsRequest) (*ListCardsResponse, error)
+	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	Transfer(context.Context, *TransferRequest) (*TransferResponse, error)
+	ListServices(context.Context, *ListServicesRequest) (*ListServicesResponse, error)
+	PayService(context.Context, *PayServiceRequest) (*PayServiceResponse, error)
+	ListAtms(context.Context, *ListAtmsRequest) (*ListAtmsResponse, error)
+}
+
+// UnimplementedClientsCoreServer can be embedded to have forward compatible implementations.
+type UnimplementedClientsCoreServer struct {
+}
+
+func (*UnimplementedClientsCoreServer) SignIn(ctx context.Context, req *SignInRequest) (*SignInResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignIn not implemented")
+}
+func (*UnimplementedClientsCoreServer) ListCards(ctx context.Context, req *ListCardsRequest) (*ListCardsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCards not implemented")
+}
+func (*UnimplementedClientsCoreServer) GetBalance(ctx context.Context, req *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (*UnimplementedClientsCoreServer) Transfer(ctx context.Context, req *TransferRequest) (*TransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (*UnimplementedClientsCoreServer) ListServices(ctx context.Context, req *ListServicesRequest) (*ListServicesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListServices not implemented")
+}
+func (*UnimplementedClientsCoreServer) PayService(ctx context.Context, req *PayServiceRequest) (*PayServiceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PayService not implemented")
+}
+func (*UnimplementedClientsCoreServer) ListAtms(ctx context.Context, req *ListAtmsRequest) (*ListAtmsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAtms not implemented")
+}
+
+func RegisterClientsCoreServer(s *grpc.Server, srv ClientsCoreServer) {
+	s.RegisterService(&_ClientsCore_serviceDesc, srv)
+}
+
+func _ClientsCore_SignIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignInRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientsCoreServer).SignIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clientscore.v1.ClientsCore/SignIn",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientsCoreServer).SignIn(ctx, req.(*SignInRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientsCore_ListCards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCardsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientsCoreServer).ListCards(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clientscore.v1.ClientsCore/ListCards",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientsCoreServer).ListCards(ctx, req.(*ListCardsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientsCore_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientsCoreServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clientscore.v1.ClientsCore/GetBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientsCoreServer).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientsCore_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientsCoreServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clientscore.v1.ClientsCore/Transfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientsCoreServer).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientsCore_ListServices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListServicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientsCoreServer).ListServices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clientscore.v1.ClientsCore/ListServices",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientsCoreServer).ListServices(ctx, req.(*ListServicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientsCore_PayService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PayServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientsCoreServer).PayService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clientscore.v1.ClientsCore/PayService",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientsCoreServer).PayService(ctx, req.(*PayServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientsCore_ListAtms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAtmsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientsCoreServer).ListAtms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clientscore.v1.ClientsCore/ListAtms",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientsCoreServer).ListAtms(ctx, req.(*ListAtmsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ClientsCore_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "clientscore.v1.ClientsCore",
+	HandlerType: (*ClientsCoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SignIn",
+			Handler:    _ClientsCore_SignIn_Handler,
+		},
+		{
+			MethodName: "ListCards",
+			Handler:    _ClientsCore_ListCards_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _ClientsCore_GetBalance_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _ClientsCore_Transfer_Handler,
+		},
+		{
+			MethodName: "ListServices",
+			Handler:    _ClientsCore_ListServices_Handler,
+		},
+		{
+			MethodName: "PayService",
+			Handler:    _ClientsCore_PayService_Handler,
+		},
+		{
+			MethodName: "ListAtms",
+			Handler:    _ClientsCore_ListAtms_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "clients.proto",
+}
@@ -0,0 +1,3 @@
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. clients.proto
+
+package clientspb
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const defaultDeadline = 5 * time.Second
+const maxDeadline = 30 * time.Second
+
+// ParseServiceKeys splits a comma separated list of service keys, dropping
+// the empty ones a trailing comma or an unset variable leaves behind.
+func ParseServiceKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// authInterceptor accepts calls whose "authorization: Bearer <key>" metadata
+// matches one of the configured service keys. Empty keys match nothing.
+func authInterceptor(keys []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, header := range md.Get("authorization") {
+			if !strings.HasPrefix(header, "Bearer ") {
+				continue
+			}
+			presented := []byte(strings.TrimPrefix(header, "Bearer "))
+			if len(presented) == 0 {
+				continue
+			}
+			for _, key := range keys {
+				if key != "" && subtle.ConstantTimeCompare(presented, []byte(key)) == 1 {
+					return handler(ctx, request)
+				}
+			}
+		}
+		return nil, status.Error(codes.Unauthenticated, "service key is not valid")
+	}
+}
+
+// deadlineInterceptor gives calls without a deadline a default one and caps
+// the ones that ask for too long. Core calls are not interrupted once they
+// start, so handlers check the context before moving money.
+func deadlineInterceptor(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	timeout := defaultDeadline
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+		if timeout <= 0 {
+			return nil, status.Error(codes.DeadlineExceeded, "deadline already passed")
+		}
+		if timeout > maxDeadline {
+			timeout = maxDeadline
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return handler(ctx, request)
+}
+
+// chainUnary runs interceptors in order, the first one outermost.
+func chainUnary(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, request interface{}) (interface{}, error) {
+				return interceptor(ctx, request, info, inner)
+			}
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,182 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+	pb "github.com/tohirov1994/clients-core/pkg/rpc/clientspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Server struct {
+	db *sql.DB
+}
+
+func NewServer(db *sql.DB) *Server {
+	return &Server{db: db}
+}
+
+// NewGRPCServer registers the ClientsCore service behind the auth and
+// deadline interceptors. keys are the bearer keys of the calling services.
+func NewGRPCServer(db *sql.DB, keys []string, options ...grpc.ServerOption) *grpc.Server {
+	options = append(options, grpc.UnaryInterceptor(chainUnary(authInterceptor(keys), deadlineInterceptor)))
+	server := grpc.NewServer(options...)
+	pb.RegisterClientsCoreServer(server, NewServer(db))
+	return server
+}
+
+func (s *Server) SignIn(ctx context.Context, request *pb.SignInRequest) (*pb.SignInResponse, error) {
+	if request.Login == "" || request.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "login and password are required")
+	}
+	clientId, ok, err := core.SignIn(request.Login, request.Password, s.db)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, core.ErrorPassword.Error())
+	}
+	return &pb.SignInResponse{ClientId: int64(clientId)}, nil
+}
+
+func (s *Server) ListCards(ctx context.Context, request *pb.ListCardsRequest) (*pb.ListCardsResponse, error) {
+	if request.ClientId <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "client_id must be positive")
+	}
+	cards, err := core.CardsGet(int(request.ClientId), s.db)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	response := &pb.ListCardsResponse{}
+	for _, card := range cards {
+		response.Cards = append(response.Cards, &pb.Card{
+			Id:         int64(card.Id),
+			Pan:        int64(card.PAN),
+			Balance:    int64(card.Balance),
+			HolderName: card.HolderName,
+			Validity:   int32(card.Validity),
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) GetBalance(ctx context.Context, request *pb.GetBalanceRequest) (*pb.GetBalanceResponse, error) {
+	if request.Pan <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "pan must be positive")
+	}
+	balance, available, err := core.GetCurrentBalanceClientPAN(request.Pan, s.db)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return &pb.GetBalanceResponse{Pan: request.Pan, Balance: int64(balance), Available: int64(available)}, nil
+}
+
+func (s *Server) Transfer(ctx context.Context, request *pb.TransferRequest) (*pb.TransferResponse, error) {
+	if request.FromPan <= 0 || request.ToPan <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "from_pan and to_pan must be positive")
+	}
+	if request.Amount <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	_, err := core.MoreCard(request.FromPan, request.ToPan, int(request.Amount), s.db)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return &pb.TransferResponse{}, nil
+}
+
+func (s *Server) ListServices(ctx context.Context, request *pb.ListServicesRequest) (*pb.ListServicesResponse, error) {
+	providers, err := core.ProvidersGet(s.db)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	response := &pb.ListServicesResponse{}
+	for _, provider := range providers {
+		response.Services = append(response.Services, &pb.Service{
+			Id:              int64(provider.Id),
+			Service:         provider.Service,
+			Category:        provider.Category,
+			ReferenceLabel:  provider.ReferenceLabel,
+			ReferenceFormat: provider.ReferenceFormat,
+			MinAmount:       int64(provider.MinAmount),
+			MaxAmount:       int64(provider.MaxAmount),
+			Active:          provider.Active,
+			Fee:             int64(provider.Fee),
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) PayService(ctx context.Context, request *pb.PayServiceRequest) (*pb.PayServiceResponse, error) {
+	if request.Service == "" {
+		return nil, status.Error(codes.InvalidArgument, "service is required")
+	}
+	if request.Reference == "" {
+		return nil, status.Error(codes.InvalidArgument, "reference is required")
+	}
+	if request.FromPan <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "from_pan must be positive")
+	}
+	if request.Amount <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	receipt, err := core.ServicesPayMoreCard(request.Service, request.Reference, request.FromPan, int(request.Amount), s.db)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return &pb.PayServiceResponse{
+		ReceiptNumber: receipt.Number,
+		TransactionId: receipt.TransactionId,
+		Time:          receipt.Time.Unix(),
+		Card:          receipt.MaskedPAN,
+		Amount:        int64(receipt.Amount),
+		Fee:           int64(receipt.Fee),
+		Total:         int64(receipt.Total()),
+	}, nil
+}
+
+func (s *Server) ListAtms(ctx context.Context, request *pb.ListAtmsRequest) (*pb.ListAtmsResponse, error) {
+	atms, err := core.ATMsGet(s.db)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	response := &pb.ListAtmsResponse{}
+	for _, atm := range atms {
+		response.Atms = append(response.Atms, &pb.Atm{Id: atm.Id, City: atm.City, District: atm.District, Street: atm.Street})
+	}
+	return response, nil
+}
+
+func statusOf(err error) error {
+	switch {
+	case errors.Is(err, core.ErrorPassword):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, core.ErrCardBlocked), errors.Is(err, core.ErrTransferDenied),
+		errors.Is(err, core.ErrTransferHeld):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, core.ErrClientNotFound), errors.Is(err, core.ErrCardNotFound),
+		errors.Is(err, core.ErrServiceNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, core.ErrInsufficientFunds), errors.Is(err, core.ErrServiceInactive),
+		errors.Is(err, core.ErrPaymentRejected):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, core.ErrPaymentPending):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, core.ErrInvalidReference), errors.Is(err, core.ErrAmountOutOfRange):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		log.Printf("internal error: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
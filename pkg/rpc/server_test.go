@@ -0,0 +1,199 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tohirov1994/clients-core/pkg/core"
+	pb "github.com/tohirov1994/clients-core/pkg/rpc/clientspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const adminPAN = 2021600000000000
+const serviceKey = "fraud-engine-key"
+
+func startServer(t *testing.T) (pb.ClientsCoreClient, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	err = core.Init(db)
+	if err != nil {
+		t.Fatalf("can't init db: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients VALUES (2, 'Jack', 'Jackson', 'jack', 'secret');
+INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 500, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't insert client: %v", err)
+	}
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(db, []string{serviceKey})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	return pb.NewClientsCoreClient(conn), func() {
+		_ = conn.Close()
+		server.Stop()
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}
+}
+
+func authorized() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+serviceKey)
+}
+
+func TestServer_RequiresServiceKey(t *testing.T) {
+	client, stop := startServer(t)
+	defer stop()
+	_, err := client.ListAtms(context.Background(), &pb.ListAtmsRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call without key just be Unauthenticated: %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = client.ListAtms(ctx, &pb.ListAtmsRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call with wrong key just be Unauthenticated: %v", err)
+	}
+}
+
+func TestParseServiceKeys(t *testing.T) {
+	cases := map[string][]string{
+		"":                nil,
+		",":               nil,
+		"first":           {"first"},
+		"first, second, ": {"first", "second"},
+	}
+	for value, want := range cases {
+		if got := ParseServiceKeys(value); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q just be %v: %v", value, want, got)
+		}
+	}
+}
+
+func TestAuthInterceptor_EmptyKey(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/clientscore.v1.ClientsCore/ListAtms"}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "))
+	_, err := authInterceptor([]string{""})(ctx, nil, info, handler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("empty key just be Unauthenticated: %v", err)
+	}
+}
+
+func TestStatusOf_HidesInternalErrors(t *testing.T) {
+	err := statusOf(errors.New("no such table: clients_cards"))
+	if status.Code(err) != codes.Internal || status.Convert(err).Message() != "internal error" {
+		t.Errorf("internal error just be hidden: %v", err)
+	}
+}
+
+func TestServer_SignInAndCards(t *testing.T) {
+	client, stop := startServer(t)
+	defer stop()
+	_, err := client.SignIn(authorized(), &pb.SignInRequest{Login: "jack", Password: "wrong"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("wrong password just be Unauthenticated: %v", err)
+	}
+	signedIn, err := client.SignIn(authorized(), &pb.SignInRequest{Login: "jack", Password: "secret"})
+	if err != nil {
+		t.Fatalf("can't sign in: %v", err)
+	}
+	cards, err := client.ListCards(authorized(), &pb.ListCardsRequest{ClientId: signedIn.ClientId})
+	if err != nil {
+		t.Fatalf("can't list cards: %v", err)
+	}
+	if len(cards.Cards) != 1 || cards.Cards[0].HolderName != "JACK JACKSON" {
+		t.Errorf("just be Jack's card: %v", cards.Cards)
+	}
+}
+
+func TestServer_TransferAndPayments(t *testing.T) {
+	client, stop := startServer(t)
+	defer stop()
+	_, err := client.Transfer(authorized(), &pb.TransferRequest{FromPan: 2021600000000001, ToPan: adminPAN, Amount: 0})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("zero amount just be InvalidArgument: %v", err)
+	}
+	_, err = client.Transfer(authorized(), &pb.TransferRequest{FromPan: 2021600000000001, ToPan: 404, Amount: 10})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown receiver just be NotFound: %v", err)
+	}
+	_, err = client.Transfer(authorized(), &pb.TransferRequest{FromPan: 2021600000000001, ToPan: adminPAN, Amount: 501})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("overdraft just be FailedPrecondition: %v", err)
+	}
+	_, err = client.Transfer(authorized(), &pb.TransferRequest{FromPan: 2021600000000001, ToPan: adminPAN, Amount: 200})
+	if err != nil {
+		t.Errorf("can't transfer: %v", err)
+	}
+	_, err = client.PayService(authorized(), &pb.PayServiceRequest{Service: "internet", Reference: "12-34", FromPan: 2021600000000001, Amount: 100})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("bad reference just be InvalidArgument: %v", err)
+	}
+	paid, err := client.PayService(authorized(), &pb.PayServiceRequest{Service: "internet", Reference: "100200", FromPan: 2021600000000001, Amount: 100})
+	if err != nil {
+		t.Errorf("can't pay service: %v", err)
+	} else if paid.ReceiptNumber == "" || paid.Card != "202160******0001" || paid.Total != 100 {
+		t.Errorf("payment just return its receipt: %v", paid)
+	}
+	balance, err := client.GetBalance(authorized(), &pb.GetBalanceRequest{Pan: 2021600000000001})
+	if err != nil || balance.Balance != 200 {
+		t.Errorf("balance just be 200: %v %v", balance, err)
+	}
+	services, err := client.ListServices(authorized(), &pb.ListServicesRequest{})
+	if err != nil || len(services.Services) != 1 || services.Services[0].ReferenceLabel != "contract id" {
+		t.Errorf("just be the internet service: %v %v", services, err)
+	}
+	atms, err := client.ListAtms(authorized(), &pb.ListAtmsRequest{})
+	if err != nil || len(atms.Atms) != 1 || atms.Atms[0].City != "Dushanbe" {
+		t.Errorf("just be the Dushanbe atm: %v %v", atms, err)
+	}
+}
+
+func TestDeadlineInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/clientscore.v1.ClientsCore/ListAtms"}
+	var got time.Duration
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		deadline, _ := ctx.Deadline()
+		got = time.Until(deadline)
+		return nil, nil
+	}
+	_, _ = deadlineInterceptor(context.Background(), nil, info, handler)
+	if got <= 0 || got > defaultDeadline {
+		t.Errorf("missing deadline just become %v: %v", defaultDeadline, got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	_, _ = deadlineInterceptor(ctx, nil, info, handler)
+	if got > maxDeadline {
+		t.Errorf("long deadline just be capped at %v: %v", maxDeadline, got)
+	}
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	_, err := deadlineInterceptor(expired, nil, info, handler)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("passed deadline just be DeadlineExceeded: %v", err)
+	}
+}
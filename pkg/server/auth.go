@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrorToken = errors.New("token is not valid")
+
+const tokenTTL = time.Hour
+
+type contextKey int
+
+const clientIdKey contextKey = iota
+
+// issueToken signs "clientId:expiry" with the server secret, so any instance
+// that shares the secret can check the token without a session store.
+func (s *Server) issueToken(clientId int, now time.Time) (token string, expiresAt time.Time) {
+	expiresAt = now.Add(tokenTTL)
+	payload := fmt.Sprintf("%d:%d", clientId, expiresAt.Unix())
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + s.sign(encoded), expiresAt
+}
+
+func (s *Server) parseToken(token string, now time.Time) (clientId int, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return 0, ErrorToken
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(s.sign(parts[0]))) {
+		return 0, ErrorToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, ErrorToken
+	}
+	fields := strings.Split(string(payload), ":")
+	if len(fields) != 2 {
+		return 0, ErrorToken
+	}
+	clientId, err = strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, ErrorToken
+	}
+	expiry, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || now.Unix() >= expiry {
+		return 0, ErrorToken
+	}
+	return clientId, nil
+}
+
+func (s *Server) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			writeError(w, http.StatusUnauthorized, ErrorToken)
+			return
+		}
+		clientId, err := s.parseToken(strings.TrimPrefix(header, "Bearer "), s.now())
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), clientIdKey, clientId)))
+	}
+}
+
+func clientIdFrom(ctx context.Context) int {
+	clientId, _ := ctx.Value(clientIdKey).(int)
+	return clientId
+}
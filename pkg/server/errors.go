@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+var ErrorAmount = errors.New("amount must be positive")
+var ErrorPAN = errors.New("pan must be positive")
+var ErrorFromPAN = errors.New("fromPan is required when the client has several cards")
+var ErrorService = errors.New("service is required")
+var ErrorReference = errors.New("reference is required")
+var ErrorReceipt = errors.New("number is required")
+var ErrorLogin = errors.New("login and password are required")
+var ErrorLocation = errors.New("lat and lon are required")
+var ErrorNearest = errors.New("limit and radius must not be negative")
+var ErrorLimit = errors.New("limit must not be negative")
+var ErrorOpenNow = errors.New("openNow must be true or false")
+var ErrorBeneficiary = errors.New("beneficiary id must be positive")
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, core.ErrorPassword), errors.Is(err, ErrorToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, core.ErrInvalidLocation), errors.Is(err, core.ErrInvalidPageToken),
+		errors.Is(err, core.ErrInvalidSort), errors.Is(err, core.ErrInvalidBeneficiary),
+		errors.Is(err, core.ErrInvalidAmount):
+		return http.StatusBadRequest
+	case errors.Is(err, core.ErrCardBlocked), errors.Is(err, core.ErrTransferDenied),
+		errors.Is(err, core.ErrTransferHeld), errors.Is(err, core.ErrWrongPIN):
+		return http.StatusForbidden
+	case errors.Is(err, core.ErrClientNotFound), errors.Is(err, core.ErrCardNotFound),
+		errors.Is(err, core.ErrServiceNotFound), errors.Is(err, core.ErrReceiptNotFound),
+		errors.Is(err, core.ErrBeneficiaryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrInsufficientFunds), errors.Is(err, core.ErrInvalidReference),
+		errors.Is(err, core.ErrAmountOutOfRange), errors.Is(err, core.ErrServiceInactive),
+		errors.Is(err, core.ErrPaymentRejected), errors.Is(err, core.ErrHolderMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, core.ErrBeneficiaryExists), errors.Is(err, core.ErrATMUnavailable),
+		errors.Is(err, core.ErrScreeningClosed):
+		return http.StatusConflict
+	case errors.Is(err, core.ErrTooManyLookups):
+		return http.StatusTooManyRequests
+	case errors.Is(err, core.ErrPaymentPending):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeCoreError(w http.ResponseWriter, err error) {
+	status := statusOf(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("internal error: %v", err)
+		writeError(w, status, errors.New(http.StatusText(status)))
+		return
+	}
+	writeError(w, status, err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		log.Printf("can't write response: %v", err)
+	}
+}
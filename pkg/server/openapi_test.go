@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+	"github.com/tohirov1994/clients-core/pkg/openapi"
+)
+
+func TestServer_ResponsesMatchSpec(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	document, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("can't load spec: %v", err)
+	}
+	token := signIn(t, s, "jack", "secret")
+	err = core.SetATMLocation(1, core.Location{Latitude: 38.5763, Longitude: 68.7795}, s.db)
+	if err != nil {
+		t.Fatalf("can't place atm: %v", err)
+	}
+	calls := []struct {
+		method, path, target, token string
+		body                        interface{}
+	}{
+		{http.MethodPost, "/api/signin", "/api/signin", "", signInRequest{Login: "jack", Password: "secret"}},
+		{http.MethodPost, "/api/signin", "/api/signin", "", signInRequest{Login: "jack", Password: "wrong"}},
+		{http.MethodGet, "/api/cards", "/api/cards", token, nil},
+		{http.MethodGet, "/api/cards", "/api/cards", "", nil},
+		{http.MethodGet, "/api/balance", "/api/balance?pan=2021600000000001", token, nil},
+		{http.MethodGet, "/api/balance", "/api/balance?pan=1", token, nil},
+		{http.MethodPost, "/api/transfers", "/api/transfers", token, transferRequest{ToPAN: adminPAN, Amount: 10}},
+		{http.MethodPost, "/api/transfers", "/api/transfers", token, transferRequest{ToPAN: adminPAN, Amount: 100000}},
+		{http.MethodGet, "/api/receivers", "/api/receivers?pan=2021600000000000", token, nil},
+		{http.MethodGet, "/api/receivers", "/api/receivers?pan=404", token, nil},
+		{http.MethodPost, "/api/beneficiaries", "/api/beneficiaries", token, beneficiaryRequest{Nickname: "admin", PAN: adminPAN, HolderName: "ADMIN CLIENT"}},
+		{http.MethodPost, "/api/beneficiaries", "/api/beneficiaries", token, beneficiaryRequest{Nickname: "admin", PAN: adminPAN, HolderName: "ADMIN CLIENT"}},
+		{http.MethodGet, "/api/beneficiaries", "/api/beneficiaries", token, nil},
+		{http.MethodPost, "/api/beneficiaries/transfers", "/api/beneficiaries/transfers", token, beneficiaryTransferRequest{BeneficiaryId: 1, Amount: 10}},
+		{http.MethodPost, "/api/beneficiaries/transfers", "/api/beneficiaries/transfers", token, beneficiaryTransferRequest{BeneficiaryId: 404, Amount: 10}},
+		{http.MethodDelete, "/api/beneficiaries", "/api/beneficiaries?id=1", token, nil},
+		{http.MethodDelete, "/api/beneficiaries", "/api/beneficiaries?id=1", token, nil},
+		{http.MethodGet, "/api/services", "/api/services", "", nil},
+		{http.MethodPost, "/api/payments", "/api/payments", token, paymentRequest{Service: "internet", Reference: "100200", Amount: 10}},
+		{http.MethodPost, "/api/payments", "/api/payments", token, paymentRequest{Service: "water", Reference: "100200", Amount: 10}},
+		{http.MethodGet, "/api/receipts", "/api/receipts?number=20260305-00000001", token, nil},
+		{http.MethodGet, "/api/receipts", "/api/receipts", token, nil},
+		{http.MethodGet, "/api/atms", "/api/atms", "", nil},
+		{http.MethodGet, "/api/atms/search", "/api/atms/search?city=dushanbe&sort=street&limit=1", "", nil},
+		{http.MethodGet, "/api/atms/search", "/api/atms/search?pageToken=garbage", "", nil},
+		{http.MethodGet, "/api/atms/nearest", "/api/atms/nearest?lat=38.56&lon=68.78", "", nil},
+		{http.MethodGet, "/api/atms/nearest", "/api/atms/nearest?lat=95&lon=68.78", "", nil},
+	}
+	documented := map[string]bool{}
+	for _, call := range calls {
+		recorder := do(t, s, call.method, call.target, call.token, call.body)
+		err := document.ValidateResponse(call.method, call.path, recorder.Code, recorder.Body.Bytes())
+		if err != nil {
+			t.Errorf("%s %s (%d): %v", call.method, call.target, recorder.Code, err)
+		}
+		documented[call.method+" "+call.path] = true
+	}
+	for path, item := range document.Paths {
+		for method := range item {
+			if !documented[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is documented but not exercised", method, path)
+			}
+		}
+	}
+}
+
+func TestServer_ServesSpec(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	recorder := do(t, s, http.MethodGet, "/api/openapi.json", "", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("spec just be 200: %d", recorder.Code)
+	}
+	document, err := openapi.Parse(recorder.Body.Bytes())
+	if err != nil {
+		t.Fatalf("served spec just parse: %v", err)
+	}
+	if document.Info.Version != openapi.Version {
+		t.Errorf("served version just be %s: %s", openapi.Version, document.Info.Version)
+	}
+}
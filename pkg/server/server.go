@@ -0,0 +1,649 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+	"github.com/tohirov1994/clients-core/pkg/openapi"
+)
+
+type Server struct {
+	db     *sql.DB
+	secret []byte
+	now    func() time.Time
+	mux    *http.ServeMux
+}
+
+func NewServer(db *sql.DB, secret []byte) *Server {
+	s := &Server{db: db, secret: secret, now: time.Now, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/api/signin", method(http.MethodPost, s.handleSignIn))
+	s.mux.HandleFunc("/api/cards", method(http.MethodGet, s.authenticated(s.handleCards)))
+	s.mux.HandleFunc("/api/balance", method(http.MethodGet, s.authenticated(s.handleBalance)))
+	s.mux.HandleFunc("/api/transfers", method(http.MethodPost, s.authenticated(s.handleTransfer)))
+	s.mux.HandleFunc("/api/services", method(http.MethodGet, s.handleServices))
+	s.mux.HandleFunc("/api/payments", method(http.MethodPost, s.authenticated(s.handlePayment)))
+	s.mux.HandleFunc("/api/receipts", method(http.MethodGet, s.authenticated(s.handleReceipt)))
+	s.mux.HandleFunc("/api/atms", method(http.MethodGet, s.handleATMs))
+	s.mux.HandleFunc("/api/atms/nearest", method(http.MethodGet, s.handleNearestATMs))
+	s.mux.HandleFunc("/api/atms/search", method(http.MethodGet, s.handleSearchATMs))
+	s.mux.HandleFunc("/api/beneficiaries", methods(map[string]http.HandlerFunc{
+		http.MethodGet:    s.authenticated(s.handleBeneficiaries),
+		http.MethodPost:   s.authenticated(s.handleAddBeneficiary),
+		http.MethodDelete: s.authenticated(s.handleRemoveBeneficiary),
+	}))
+	s.mux.HandleFunc("/api/beneficiaries/transfers", method(http.MethodPost, s.authenticated(s.handleBeneficiaryTransfer)))
+	s.mux.HandleFunc("/api/receivers", method(http.MethodGet, s.authenticated(s.handleReceiver)))
+	s.mux.HandleFunc("/api/openapi.json", method(http.MethodGet, handleSpec))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func method(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != name {
+			w.Header().Set("Allow", name)
+			writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// methods routes a path that serves several methods.
+func methods(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	allowed := make([]string, 0, len(handlers))
+	for name := range handlers {
+		allowed = append(allowed, name)
+	}
+	sort.Strings(allowed)
+	return func(w http.ResponseWriter, r *http.Request) {
+		next, ok := handlers[r.Method]
+		if !ok {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+			return
+		}
+		next(w, r)
+	}
+}
+
+type signInRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type signInResponse struct {
+	Token     string    `json:"token"`
+	ClientId  int       `json:"clientId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type cardResponse struct {
+	Id         int    `json:"id"`
+	PAN        int    `json:"pan"`
+	Balance    int    `json:"balance"`
+	HolderName string `json:"holderName"`
+	Validity   int    `json:"validity"`
+}
+
+type balanceResponse struct {
+	PAN       int64 `json:"pan"`
+	Balance   int   `json:"balance"`
+	Available int   `json:"available"`
+}
+
+type transferRequest struct {
+	FromPAN int64 `json:"fromPan,omitempty"`
+	ToPAN   int64 `json:"toPan"`
+	Amount  int   `json:"amount"`
+}
+
+type paymentRequest struct {
+	Service   string `json:"service"`
+	Reference string `json:"reference"`
+	FromPAN   int64  `json:"fromPan,omitempty"`
+	Amount    int    `json:"amount"`
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+type serviceResponse struct {
+	Id              int    `json:"id"`
+	Service         string `json:"service"`
+	Category        string `json:"category"`
+	ReferenceLabel  string `json:"referenceLabel"`
+	ReferenceFormat string `json:"referenceFormat"`
+	MinAmount       int    `json:"minAmount"`
+	MaxAmount       int    `json:"maxAmount"`
+	Fee             int    `json:"fee"`
+	Active          bool   `json:"active"`
+}
+
+type receiptResponse struct {
+	Number        string    `json:"number"`
+	TransactionId int64     `json:"transactionId"`
+	Time          time.Time `json:"time"`
+	Card          string    `json:"card"`
+	Service       string    `json:"service"`
+	Reference     string    `json:"reference"`
+	Amount        int       `json:"amount"`
+	Fee           int       `json:"fee"`
+	Total         int       `json:"total"`
+}
+
+func newReceiptResponse(receipt core.Receipt) receiptResponse {
+	return receiptResponse{
+		Number:        receipt.Number,
+		TransactionId: receipt.TransactionId,
+		Time:          receipt.Time.UTC(),
+		Card:          receipt.MaskedPAN,
+		Service:       receipt.Service,
+		Reference:     receipt.Reference,
+		Amount:        receipt.Amount,
+		Fee:           receipt.Fee,
+		Total:         receipt.Total(),
+	}
+}
+
+type atmResponse struct {
+	Id           int64           `json:"id"`
+	City         string          `json:"city"`
+	District     string          `json:"district"`
+	Street       string          `json:"street"`
+	Latitude     *float64        `json:"latitude,omitempty"`
+	Longitude    *float64        `json:"longitude,omitempty"`
+	Status       string          `json:"status"`
+	Timezone     string          `json:"timezone"`
+	Hours        []hoursResponse `json:"hours,omitempty"`
+	Capabilities []string        `json:"capabilities,omitempty"`
+	Currencies   []string        `json:"currencies,omitempty"`
+}
+
+type hoursResponse struct {
+	Weekday int    `json:"weekday"`
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
+}
+
+func newAtmResponse(atm core.Atm) atmResponse {
+	response := atmResponse{Id: atm.Id, City: atm.City, District: atm.District, Street: atm.Street,
+		Status: atm.Status, Timezone: atm.Timezone, Capabilities: atm.Capabilities, Currencies: atm.Currencies}
+	if atm.Location != nil {
+		response.Latitude, response.Longitude = &atm.Location.Latitude, &atm.Location.Longitude
+	}
+	for _, hours := range atm.Hours {
+		response.Hours = append(response.Hours, hoursResponse{
+			Weekday: int(hours.Weekday),
+			Opens:   clock(hours.Opens),
+			Closes:  clock(hours.Closes),
+		})
+	}
+	return response
+}
+
+// clock renders minutes after midnight as HH:MM.
+func clock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// atmFilterOf reads the openNow, capability and currency parameters.
+func atmFilterOf(query url.Values) (filter core.ATMFilter, ok bool) {
+	if value := query.Get("openNow"); value != "" {
+		var err error
+		filter.OpenNow, err = strconv.ParseBool(value)
+		if err != nil {
+			return core.ATMFilter{}, false
+		}
+	}
+	filter.Capability, filter.Currency = query.Get("capability"), query.Get("currency")
+	return filter, true
+}
+
+type atmPageResponse struct {
+	ATMs          []atmResponse `json:"atms"`
+	NextPageToken string        `json:"nextPageToken,omitempty"`
+}
+
+type receiverResponse struct {
+	Card       string `json:"card"`
+	MaskedName string `json:"maskedName"`
+	Scheme     string `json:"scheme"`
+}
+
+type beneficiaryRequest struct {
+	Nickname   string `json:"nickname"`
+	PAN        int64  `json:"pan"`
+	HolderName string `json:"holderName"`
+}
+
+type beneficiaryResponse struct {
+	Id         int64     `json:"id"`
+	Nickname   string    `json:"nickname"`
+	PAN        int64     `json:"pan"`
+	HolderName string    `json:"holderName"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func newBeneficiaryResponse(beneficiary core.Beneficiary) beneficiaryResponse {
+	return beneficiaryResponse{
+		Id:         beneficiary.Id,
+		Nickname:   beneficiary.Nickname,
+		PAN:        beneficiary.PAN,
+		HolderName: beneficiary.HolderName,
+		CreatedAt:  beneficiary.CreatedAt.UTC(),
+	}
+}
+
+type beneficiaryTransferRequest struct {
+	BeneficiaryId int64 `json:"beneficiaryId"`
+	FromPAN       int64 `json:"fromPan,omitempty"`
+	Amount        int   `json:"amount"`
+}
+
+type nearbyAtmResponse struct {
+	atmResponse
+	Distance float64 `json:"distance"`
+}
+
+func (s *Server) handleSignIn(w http.ResponseWriter, r *http.Request) {
+	var request signInRequest
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Login == "" || request.Password == "" {
+		writeError(w, http.StatusBadRequest, ErrorLogin)
+		return
+	}
+	clientId, ok, err := core.SignIn(request.Login, request.Password, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusUnauthorized, core.ErrorPassword)
+		return
+	}
+	token, expiresAt := s.issueToken(clientId, s.now())
+	writeJSON(w, http.StatusOK, signInResponse{Token: token, ClientId: clientId, ExpiresAt: expiresAt.UTC()})
+}
+
+func (s *Server) handleCards(w http.ResponseWriter, r *http.Request) {
+	cards, err := core.CardsGet(clientIdFrom(r.Context()), s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	response := make([]cardResponse, 0, len(cards))
+	for _, card := range cards {
+		response = append(response, cardResponse{
+			Id:         card.Id,
+			PAN:        card.PAN,
+			Balance:    card.Balance,
+			HolderName: card.HolderName,
+			Validity:   card.Validity,
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
+	pan, err := strconv.ParseInt(r.URL.Query().Get("pan"), 10, 64)
+	if err != nil || pan <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorPAN)
+		return
+	}
+	_, err = core.SelectCards(clientIdFrom(r.Context()), pan, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	balance, available, err := core.GetCurrentBalanceClientPAN(pan, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, balanceResponse{PAN: pan, Balance: balance, Available: available})
+}
+
+func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
+	var request transferRequest
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Amount <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorAmount)
+		return
+	}
+	if request.ToPAN <= 0 || request.FromPAN < 0 {
+		writeError(w, http.StatusBadRequest, ErrorPAN)
+		return
+	}
+	clientId := clientIdFrom(r.Context())
+	if !s.sourceCardChosen(w, clientId, request.FromPAN) {
+		return
+	}
+	var err error
+	if request.FromPAN == 0 {
+		_, err = core.OneCard(request.ToPAN, clientId, request.Amount, s.db)
+	} else if _, err = core.SelectCards(clientId, request.FromPAN, s.db); err == nil {
+		_, err = core.MoreCard(request.FromPAN, request.ToPAN, request.Amount, s.db)
+	}
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, statusResponse{Status: "completed"})
+}
+
+func (s *Server) handleServices(w http.ResponseWriter, r *http.Request) {
+	providers, err := core.ProvidersGet(s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	response := make([]serviceResponse, 0, len(providers))
+	for _, provider := range providers {
+		response = append(response, serviceResponse{
+			Id:              provider.Id,
+			Service:         provider.Service,
+			Category:        provider.Category,
+			ReferenceLabel:  provider.ReferenceLabel,
+			ReferenceFormat: provider.ReferenceFormat,
+			MinAmount:       provider.MinAmount,
+			MaxAmount:       provider.MaxAmount,
+			Fee:             provider.Fee,
+			Active:          provider.Active,
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handlePayment(w http.ResponseWriter, r *http.Request) {
+	var request paymentRequest
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Service == "" {
+		writeError(w, http.StatusBadRequest, ErrorService)
+		return
+	}
+	if request.Reference == "" {
+		writeError(w, http.StatusBadRequest, ErrorReference)
+		return
+	}
+	if request.Amount <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorAmount)
+		return
+	}
+	if request.FromPAN < 0 {
+		writeError(w, http.StatusBadRequest, ErrorPAN)
+		return
+	}
+	clientId := clientIdFrom(r.Context())
+	if !s.sourceCardChosen(w, clientId, request.FromPAN) {
+		return
+	}
+	var receipt core.Receipt
+	var err error
+	if request.FromPAN == 0 {
+		receipt, err = core.ServicesPayOneCard(request.Service, request.Reference, clientId, request.Amount, s.db)
+	} else if _, err = core.SelectCards(clientId, request.FromPAN, s.db); err == nil {
+		receipt, err = core.ServicesPayMoreCard(request.Service, request.Reference, request.FromPAN, request.Amount, s.db)
+	}
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newReceiptResponse(receipt))
+}
+
+func (s *Server) handleReceipt(w http.ResponseWriter, r *http.Request) {
+	number := r.URL.Query().Get("number")
+	if number == "" {
+		writeError(w, http.StatusBadRequest, ErrorReceipt)
+		return
+	}
+	receipt, err := core.ReceiptGet(number, clientIdFrom(r.Context()), s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newReceiptResponse(receipt))
+}
+
+func (s *Server) handleATMs(w http.ResponseWriter, r *http.Request) {
+	filter, ok := atmFilterOf(r.URL.Query())
+	if !ok {
+		writeError(w, http.StatusBadRequest, ErrorOpenNow)
+		return
+	}
+	atms, err := core.ATMsFiltered(filter, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	response := make([]atmResponse, 0, len(atms))
+	for _, atm := range atms {
+		response = append(response, newAtmResponse(atm))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleNearestATMs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(query.Get("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(query.Get("lon"), 64)
+	if latErr != nil || lonErr != nil {
+		writeError(w, http.StatusBadRequest, ErrorLocation)
+		return
+	}
+	var limit int
+	var radius float64
+	var err error
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+	}
+	if value := query.Get("radius"); value != "" && err == nil {
+		radius, err = strconv.ParseFloat(value, 64)
+	}
+	if err != nil || limit < 0 || radius < 0 {
+		writeError(w, http.StatusBadRequest, ErrorNearest)
+		return
+	}
+	filter, ok := atmFilterOf(query)
+	if !ok {
+		writeError(w, http.StatusBadRequest, ErrorOpenNow)
+		return
+	}
+	atms, err := core.NearestATMs(lat, lon, limit, radius, filter, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	response := make([]nearbyAtmResponse, 0, len(atms))
+	for _, atm := range atms {
+		response = append(response, nearbyAtmResponse{atmResponse: newAtmResponse(atm.Atm), Distance: atm.Distance})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleSearchATMs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var limit int
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, ErrorLimit)
+			return
+		}
+	}
+	filter, ok := atmFilterOf(query)
+	if !ok {
+		writeError(w, http.StatusBadRequest, ErrorOpenNow)
+		return
+	}
+	page, err := core.ATMsSearch(core.ATMQuery{
+		ATMFilter: filter,
+		City:      query.Get("city"),
+		District:  query.Get("district"),
+		Street:    query.Get("street"),
+		Sort:      query.Get("sort"),
+		Limit:     limit,
+		PageToken: query.Get("pageToken"),
+	}, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	response := atmPageResponse{ATMs: make([]atmResponse, 0, len(page.ATMs)), NextPageToken: page.NextPageToken}
+	for _, atm := range page.ATMs {
+		response.ATMs = append(response.ATMs, newAtmResponse(atm))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleReceiver(w http.ResponseWriter, r *http.Request) {
+	pan, err := strconv.ParseInt(r.URL.Query().Get("pan"), 10, 64)
+	if err != nil || pan <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorPAN)
+		return
+	}
+	receiver, err := core.LookupReceiver(clientIdFrom(r.Context()), pan, s.db)
+	var limitErr *core.LookupLimitError
+	if errors.As(err, &limitErr) {
+		retryAfter := int(math.Ceil(limitErr.RetryAt.Sub(s.now()).Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, receiverResponse{Card: receiver.MaskedPAN, MaskedName: receiver.MaskedName, Scheme: receiver.Scheme})
+}
+
+func (s *Server) handleBeneficiaries(w http.ResponseWriter, r *http.Request) {
+	beneficiaries, err := core.BeneficiariesGet(clientIdFrom(r.Context()), s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	response := make([]beneficiaryResponse, 0, len(beneficiaries))
+	for _, beneficiary := range beneficiaries {
+		response = append(response, newBeneficiaryResponse(beneficiary))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleAddBeneficiary(w http.ResponseWriter, r *http.Request) {
+	var request beneficiaryRequest
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.PAN <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorPAN)
+		return
+	}
+	beneficiary, err := core.AddBeneficiary(clientIdFrom(r.Context()), request.Nickname, request.PAN, request.HolderName, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newBeneficiaryResponse(beneficiary))
+}
+
+func (s *Server) handleRemoveBeneficiary(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorBeneficiary)
+		return
+	}
+	err = core.RemoveBeneficiary(clientIdFrom(r.Context()), id, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, statusResponse{Status: "removed"})
+}
+
+func (s *Server) handleBeneficiaryTransfer(w http.ResponseWriter, r *http.Request) {
+	var request beneficiaryTransferRequest
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Amount <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorAmount)
+		return
+	}
+	if request.BeneficiaryId <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorBeneficiary)
+		return
+	}
+	if request.FromPAN < 0 {
+		writeError(w, http.StatusBadRequest, ErrorPAN)
+		return
+	}
+	clientId := clientIdFrom(r.Context())
+	if !s.sourceCardChosen(w, clientId, request.FromPAN) {
+		return
+	}
+	err := core.TransferToBeneficiary(clientId, request.BeneficiaryId, request.FromPAN, request.Amount, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, statusResponse{Status: "completed"})
+}
+
+func handleSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, openapi.Spec)
+}
+
+// sourceCardChosen requires fromPan from clients who hold several cards, the
+// way GetTransferCard and SelectCards are meant to be used.
+func (s *Server) sourceCardChosen(w http.ResponseWriter, clientId int, fromPAN int64) bool {
+	if fromPAN != 0 {
+		return true
+	}
+	count, err := core.GetTransferCard(clientId, s.db)
+	if err != nil {
+		writeCoreError(w, err)
+		return false
+	}
+	if count > 1 {
+		writeError(w, http.StatusBadRequest, ErrorFromPAN)
+		return false
+	}
+	return true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, request interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,450 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+const adminPAN = 2021600000000000
+
+func newTestServer(t *testing.T) (*Server, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	err = core.Init(db)
+	if err != nil {
+		t.Fatalf("can't init db: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO clients VALUES (2, 'Jack', 'Jackson', 'jack', 'secret');
+INSERT INTO clients_cards VALUES (2, 2021600000000001, 1111, 500, 'JACK JACKSON', 111, 0525, 2);`)
+	if err != nil {
+		t.Fatalf("can't insert client: %v", err)
+	}
+	return NewServer(db, []byte("test-secret")), func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("can't close db: %v", err)
+		}
+	}
+}
+
+func do(t *testing.T, s *Server, method, target, token string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("can't encode body: %v", err)
+		}
+	}
+	request := httptest.NewRequest(method, target, &payload)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func signIn(t *testing.T, s *Server, login, password string) string {
+	recorder := do(t, s, http.MethodPost, "/api/signin", "", signInRequest{Login: login, Password: password})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("sign in just be 200: %d %s", recorder.Code, recorder.Body)
+	}
+	var response signInResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("can't decode sign in: %v", err)
+	}
+	return response.Token
+}
+
+func TestStatusOf(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{core.ErrInvalidAmount, http.StatusBadRequest},
+		{core.ErrWrongPIN, http.StatusForbidden},
+		{core.ErrATMUnavailable, http.StatusConflict},
+		{core.ErrScreeningClosed, http.StatusConflict},
+		{core.ErrBeneficiaryExists, http.StatusConflict},
+		{core.ErrTooManyLookups, http.StatusTooManyRequests},
+		{core.ErrCardNotFound, http.StatusNotFound},
+		{errors.New("no such table: clients_cards"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		wrapped := fmt.Errorf("can't do it: %w", c.err)
+		if status := statusOf(wrapped); status != c.status {
+			t.Errorf("%v just be %d, got %d", c.err, c.status, status)
+		}
+	}
+}
+
+func TestServer_SignIn(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	recorder := do(t, s, http.MethodPost, "/api/signin", "", signInRequest{Login: "jack", Password: "wrong"})
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password just be 401: %d", recorder.Code)
+	}
+	recorder = do(t, s, http.MethodPost, "/api/signin", "", signInRequest{Login: "nobody", Password: "secret"})
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("unknown login just be 401: %d", recorder.Code)
+	}
+	recorder = do(t, s, http.MethodPost, "/api/signin", "", map[string]string{"login": "jack"})
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("missing password just be 400: %d", recorder.Code)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/signin", "", nil)
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET sign in just be 405: %d", recorder.Code)
+	}
+}
+
+func TestServer_Authentication(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	recorder := do(t, s, http.MethodGet, "/api/cards", "", nil)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("missing token just be 401: %d", recorder.Code)
+	}
+	token := signIn(t, s, "jack", "secret")
+	recorder = do(t, s, http.MethodGet, "/api/cards", token+"x", nil)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("forged token just be 401: %d", recorder.Code)
+	}
+	s.now = func() time.Time { return time.Now().Add(2 * tokenTTL) }
+	recorder = do(t, s, http.MethodGet, "/api/cards", token, nil)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expired token just be 401: %d", recorder.Code)
+	}
+}
+
+func TestServer_CardsAndBalance(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	token := signIn(t, s, "jack", "secret")
+	recorder := do(t, s, http.MethodGet, "/api/cards", token, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("cards just be 200: %d", recorder.Code)
+	}
+	var cards []map[string]interface{}
+	if err := json.NewDecoder(recorder.Body).Decode(&cards); err != nil {
+		t.Fatalf("can't decode cards: %v", err)
+	}
+	if len(cards) != 1 || cards[0]["holderName"] != "JACK JACKSON" {
+		t.Errorf("just be Jack's card: %v", cards)
+	}
+	if _, ok := cards[0]["pin"]; ok {
+		t.Error("pin must not leave the server")
+	}
+
+	recorder = do(t, s, http.MethodGet, "/api/balance?pan=2021600000000001", token, nil)
+	var balance balanceResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&balance); err != nil || balance.Balance != 500 {
+		t.Errorf("balance just be 500: %v %v", balance, err)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/balance?pan=2021600000000000", token, nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("someone else's card just be 404: %d", recorder.Code)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/balance?pan=abc", token, nil)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("bad pan just be 400: %d", recorder.Code)
+	}
+}
+
+func TestServer_Transfer(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	token := signIn(t, s, "jack", "secret")
+	cases := []struct {
+		request transferRequest
+		status  int
+	}{
+		{transferRequest{ToPAN: adminPAN, Amount: 0}, http.StatusBadRequest},
+		{transferRequest{ToPAN: 0, Amount: 10}, http.StatusBadRequest},
+		{transferRequest{ToPAN: 404, Amount: 10}, http.StatusNotFound},
+		{transferRequest{FromPAN: adminPAN, ToPAN: 2021600000000001, Amount: 10}, http.StatusNotFound},
+		{transferRequest{ToPAN: adminPAN, Amount: 501}, http.StatusUnprocessableEntity},
+		{transferRequest{ToPAN: adminPAN, Amount: 200}, http.StatusOK},
+		{transferRequest{FromPAN: 2021600000000001, ToPAN: adminPAN, Amount: 100}, http.StatusOK},
+	}
+	for _, c := range cases {
+		recorder := do(t, s, http.MethodPost, "/api/transfers", token, c.request)
+		if recorder.Code != c.status {
+			t.Errorf("%+v just be %d: %d %s", c.request, c.status, recorder.Code, recorder.Body)
+		}
+	}
+	balance, _, err := core.GetCurrentBalanceClientPAN(2021600000000001, s.db)
+	if err != nil || balance != 200 {
+		t.Errorf("balance just be 200: %d %v", balance, err)
+	}
+}
+
+func TestServer_TransferScreened(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	defer func(rules []core.FraudRule) { core.FraudRules = rules }(core.FraudRules)
+	core.FraudRules = []core.FraudRule{core.NewReceiverRule{MinAmount: 100, Decision: core.FraudDeny}}
+	token := signIn(t, s, "jack", "secret")
+	recorder := do(t, s, http.MethodPost, "/api/transfers", token, transferRequest{ToPAN: adminPAN, Amount: 100})
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("denied transfer just be 403: %d %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestServer_Receivers(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	token := signIn(t, s, "jack", "secret")
+	recorder := do(t, s, http.MethodGet, "/api/receivers?pan=2021600000000000", token, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("receiver just be 200: %d %s", recorder.Code, recorder.Body)
+	}
+	var receiver receiverResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&receiver); err != nil {
+		t.Fatalf("can't decode receiver: %v", err)
+	}
+	if receiver != (receiverResponse{Card: "202160******0000", MaskedName: "A*** C***", Scheme: core.SchemeLocal}) {
+		t.Errorf("receiver just be the masked admin card: %+v", receiver)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/receivers?pan=x", token, nil)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("bad pan just be 400: %d", recorder.Code)
+	}
+	for pan := 1; pan < core.ReceiverLookupLimit; pan++ {
+		recorder = do(t, s, http.MethodGet, "/api/receivers?pan="+strconv.Itoa(pan), token, nil)
+		if recorder.Code != http.StatusNotFound {
+			t.Fatalf("unknown card just be 404: %d %s", recorder.Code, recorder.Body)
+		}
+	}
+	recorder = do(t, s, http.MethodGet, "/api/receivers?pan=404", token, nil)
+	if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") == "" {
+		t.Errorf("lookup over the limit just be 429 with Retry-After: %d %v", recorder.Code, recorder.Header())
+	}
+}
+
+func TestServer_Beneficiaries(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	token := signIn(t, s, "jack", "secret")
+	cases := []struct {
+		request beneficiaryRequest
+		status  int
+	}{
+		{beneficiaryRequest{Nickname: "admin", PAN: 0, HolderName: "ADMIN CLIENT"}, http.StatusBadRequest},
+		{beneficiaryRequest{Nickname: " ", PAN: adminPAN, HolderName: "ADMIN CLIENT"}, http.StatusBadRequest},
+		{beneficiaryRequest{Nickname: "admin", PAN: 404, HolderName: "ADMIN CLIENT"}, http.StatusUnprocessableEntity},
+		{beneficiaryRequest{Nickname: "admin", PAN: adminPAN, HolderName: "JACK JACKSON"}, http.StatusUnprocessableEntity},
+		{beneficiaryRequest{Nickname: "admin", PAN: adminPAN, HolderName: "admin client"}, http.StatusOK},
+		{beneficiaryRequest{Nickname: "Admin", PAN: adminPAN, HolderName: "ADMIN CLIENT"}, http.StatusConflict},
+	}
+	for _, c := range cases {
+		recorder := do(t, s, http.MethodPost, "/api/beneficiaries", token, c.request)
+		if recorder.Code != c.status {
+			t.Errorf("%+v just be %d: %d %s", c.request, c.status, recorder.Code, recorder.Body)
+		}
+	}
+	recorder := do(t, s, http.MethodGet, "/api/beneficiaries", token, nil)
+	var beneficiaries []beneficiaryResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&beneficiaries); err != nil {
+		t.Fatalf("can't decode beneficiaries: %v", err)
+	}
+	if len(beneficiaries) != 1 || beneficiaries[0].PAN != adminPAN || beneficiaries[0].HolderName != "A*** C***" {
+		t.Fatalf("jack just have the admin card saved: %+v", beneficiaries)
+	}
+	id := beneficiaries[0].Id
+
+	recorder = do(t, s, http.MethodPost, "/api/beneficiaries/transfers", token, beneficiaryTransferRequest{BeneficiaryId: id, Amount: 200})
+	if recorder.Code != http.StatusOK {
+		t.Errorf("transfer to beneficiary just be 200: %d %s", recorder.Code, recorder.Body)
+	}
+	balance, _, err := core.GetCurrentBalanceClientPAN(2021600000000001, s.db)
+	if err != nil || balance != 300 {
+		t.Errorf("balance just be 300: %d %v", balance, err)
+	}
+	adminToken := signIn(t, s, "adminC", "adminC")
+	recorder = do(t, s, http.MethodPost, "/api/beneficiaries/transfers", adminToken, beneficiaryTransferRequest{BeneficiaryId: id, Amount: 200})
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("beneficiary of another client just be 404: %d %s", recorder.Code, recorder.Body)
+	}
+	recorder = do(t, s, http.MethodDelete, "/api/beneficiaries?id="+strconv.FormatInt(id, 10), adminToken, nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("another client just not remove the beneficiary: %d %s", recorder.Code, recorder.Body)
+	}
+	recorder = do(t, s, http.MethodDelete, "/api/beneficiaries?id="+strconv.FormatInt(id, 10), token, nil)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("remove just be 200: %d %s", recorder.Code, recorder.Body)
+	}
+	recorder = do(t, s, http.MethodPut, "/api/beneficiaries", token, nil)
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != "DELETE, GET, POST" {
+		t.Errorf("put just be 405: %d %s", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
+
+func TestServer_Payments(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	_, err := s.db.Exec(`INSERT INTO clients_cards VALUES (3, 2021600000000002, 2222, 900, 'JACK JACKSON', 222, 0626, 2);`)
+	if err != nil {
+		t.Fatalf("can't insert card: %v", err)
+	}
+	token := signIn(t, s, "jack", "secret")
+	recorder := do(t, s, http.MethodGet, "/api/services", "", nil)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("services just be 200: %d", recorder.Code)
+	}
+	cases := []struct {
+		request paymentRequest
+		status  int
+	}{
+		{paymentRequest{Service: "", Reference: "100200", Amount: 10}, http.StatusBadRequest},
+		{paymentRequest{Service: "internet", Amount: 10}, http.StatusBadRequest},
+		{paymentRequest{Service: "internet", Reference: "100200", Amount: 10}, http.StatusBadRequest},
+		{paymentRequest{Service: "water", Reference: "100200", FromPAN: 2021600000000002, Amount: 10}, http.StatusNotFound},
+		{paymentRequest{Service: "internet", Reference: "12-34", FromPAN: 2021600000000002, Amount: 100}, http.StatusUnprocessableEntity},
+		{paymentRequest{Service: "internet", Reference: "100200", FromPAN: 2021600000000002, Amount: 100}, http.StatusOK},
+	}
+	for _, c := range cases {
+		recorder := do(t, s, http.MethodPost, "/api/payments", token, c.request)
+		if recorder.Code != c.status {
+			t.Errorf("%+v just be %d: %d %s", c.request, c.status, recorder.Code, recorder.Body)
+		}
+	}
+	recorder = do(t, s, http.MethodPost, "/api/payments", token,
+		paymentRequest{Service: "internet", Reference: "100200", FromPAN: 2021600000000002, Amount: 50})
+	var paid receiptResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&paid); err != nil {
+		t.Fatalf("can't decode receipt: %v", err)
+	}
+	if paid.Number == "" || paid.Card != "202160******0002" || paid.Amount != 50 || paid.Total != 50 {
+		t.Errorf("payment just return its receipt: %+v", paid)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/receipts?number="+paid.Number, token, nil)
+	var stored receiptResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&stored); err != nil {
+		t.Fatalf("can't decode receipt: %v", err)
+	}
+	if stored != paid {
+		t.Errorf("stored receipt just be %+v: %+v", paid, stored)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/receipts?number="+paid.Number, signIn(t, s, "adminC", "adminC"), nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("someone else's receipt just be 404: %d", recorder.Code)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/receipts", token, nil)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("receipt without number just be 400: %d", recorder.Code)
+	}
+}
+
+func TestServer_ATMs(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	recorder := do(t, s, http.MethodGet, "/api/atms", "", nil)
+	var atms []atmResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&atms); err != nil {
+		t.Fatalf("can't decode atms: %v", err)
+	}
+	if len(atms) != 1 || atms[0].City != "Dushanbe" {
+		t.Errorf("just be the Dushanbe atm: %v", atms)
+	}
+}
+
+func TestServer_SearchATMs(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	_, err := s.db.Exec(`INSERT INTO atms VALUES (2, 'Dushanbe', 'Sino', 'Aini 1'), (3, 'Khujand', 'Center', 'Lenin 5')`)
+	if err != nil {
+		t.Fatalf("can't insert atms: %v", err)
+	}
+	recorder := do(t, s, http.MethodGet, "/api/atms/search?city=DUSHANBE&sort=street&limit=1", "", nil)
+	var page atmPageResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&page); err != nil {
+		t.Fatalf("can't decode atms: %v", err)
+	}
+	if len(page.ATMs) != 1 || page.ATMs[0].Id != 2 || page.NextPageToken == "" {
+		t.Fatalf("first page just be atm 2 and a token: %+v", page)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/atms/search?city=DUSHANBE&sort=street&limit=1&pageToken="+page.NextPageToken, "", nil)
+	page = atmPageResponse{}
+	if err := json.NewDecoder(recorder.Body).Decode(&page); err != nil {
+		t.Fatalf("can't decode atms: %v", err)
+	}
+	if len(page.ATMs) != 1 || page.ATMs[0].Id != 1 || page.NextPageToken != "" {
+		t.Errorf("last page just be atm 1 without a token: %+v", page)
+	}
+	for _, target := range []string{"/api/atms/search?sort=pin", "/api/atms/search?limit=x", "/api/atms/search?pageToken=garbage"} {
+		recorder = do(t, s, http.MethodGet, target, "", nil)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s just be 400: %d", target, recorder.Code)
+		}
+	}
+}
+
+func TestServer_NearestATMs(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	err := core.SetATMLocation(1, core.Location{Latitude: 38.5763, Longitude: 68.7795}, s.db)
+	if err != nil {
+		t.Fatalf("can't place atm: %v", err)
+	}
+	recorder := do(t, s, http.MethodGet, "/api/atms/nearest?lat=38.5598&lon=68.7870&limit=5&radius=3000", "", nil)
+	var atms []nearbyAtmResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&atms); err != nil {
+		t.Fatalf("can't decode atms: %v", err)
+	}
+	if len(atms) != 1 || atms[0].Id != 1 || atms[0].Distance < 1900 || atms[0].Distance > 2000 {
+		t.Errorf("just be the Dushanbe atm about 1.9 km away: %+v", atms)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/atms/nearest?lat=38.5598&lon=68.7870&radius=1000", "", nil)
+	if err := json.NewDecoder(recorder.Body).Decode(&atms); err != nil || len(atms) != 0 {
+		t.Errorf("atm out of the radius just not be found: %+v %v", atms, err)
+	}
+	for _, target := range []string{"/api/atms/nearest?lat=38.5", "/api/atms/nearest?lat=38.5&lon=68.7&limit=-1", "/api/atms/nearest?lat=91&lon=0"} {
+		recorder = do(t, s, http.MethodGet, target, "", nil)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s just be 400: %d", target, recorder.Code)
+		}
+	}
+}
+
+func TestServer_ATMsFilter(t *testing.T) {
+	s, closeDb := newTestServer(t)
+	defer closeDb()
+	_, err := s.db.Exec(`INSERT INTO atms VALUES (2, 'Dushanbe', 'Sino', 'Aini 1')`)
+	if err != nil {
+		t.Fatalf("can't insert atm: %v", err)
+	}
+	err = core.SetATMCapabilities(2, []string{core.CapabilityCashIn}, []string{"TJS"}, 1, s.db)
+	if err != nil {
+		t.Fatalf("can't set capabilities: %v", err)
+	}
+	err = core.SetATMStatus(1, core.ATMOffline, 1, s.db)
+	if err != nil {
+		t.Fatalf("can't set status: %v", err)
+	}
+	recorder := do(t, s, http.MethodGet, "/api/atms?openNow=true&capability=cash-in&currency=TJS", "", nil)
+	var atms []atmResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&atms); err != nil {
+		t.Fatalf("can't decode atms: %v", err)
+	}
+	if len(atms) != 1 || atms[0].Id != 2 || atms[0].Status != core.ATMOnline || len(atms[0].Capabilities) != 1 {
+		t.Errorf("just be the cash-in atm 2: %+v", atms)
+	}
+	recorder = do(t, s, http.MethodGet, "/api/atms?openNow=maybe", "", nil)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("openNow=maybe just be 400: %d", recorder.Code)
+	}
+}
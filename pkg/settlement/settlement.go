@@ -0,0 +1,119 @@
+package settlement
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// WriteFiles writes a CSV and a fixed-width file for every provider in the
+// batch into dir and returns their paths. Writing a batch again overwrites
+// its files with the same content.
+func WriteFiles(dir string, batch core.Settlement) (paths []string, err error) {
+	if batch.Status == core.SettlementVoided {
+		return nil, fmt.Errorf("settlement %d: %w", batch.Id, core.ErrSettlementVoided)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("can't create %s: %w", dir, err)
+	}
+	for _, provider := range batch.Providers {
+		name := fmt.Sprintf("settlement-%06d-%s", batch.Id, unsafeName.ReplaceAllString(provider.Service, "_"))
+		for _, format := range []struct {
+			extension string
+			write     func(io.Writer, core.Settlement, core.ProviderSettlement) error
+		}{
+			{".csv", WriteCSV},
+			{".txt", WriteFixedWidth},
+		} {
+			var out bytes.Buffer
+			err = format.write(&out, batch, provider)
+			if err != nil {
+				return nil, err
+			}
+			path := filepath.Join(dir, name+format.extension)
+			err = ioutil.WriteFile(path, out.Bytes(), 0644)
+			if err != nil {
+				return nil, fmt.Errorf("can't write %s: %w", path, err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func WriteCSV(w io.Writer, batch core.Settlement, provider core.ProviderSettlement) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{
+		{"batch", "service", "transaction", "date", "reference", "amount"},
+	}
+	batchId := strconv.FormatInt(batch.Id, 10)
+	for _, entry := range provider.Entries {
+		records = append(records, []string{
+			batchId,
+			provider.Service,
+			strconv.FormatInt(entry.TransactionId, 10),
+			formatTime(entry.Time),
+			entry.Reference,
+			strconv.Itoa(entry.Amount),
+		})
+	}
+	records = append(records, []string{batchId, provider.Service, "", formatTime(batch.Cutoff), "total", strconv.Itoa(provider.Total)})
+	err := writer.WriteAll(records)
+	if err != nil {
+		return fmt.Errorf("can't write csv settlement: %w", err)
+	}
+	return nil
+}
+
+// WriteFixedWidth writes the layout providers load into their billing
+// systems. Every record is 60 characters:
+//
+//	H batch(10) service(20) cutoff(14) entries(6) filler(9)
+//	D transaction(12) date(14) reference(20) amount(13)
+//	T entries(6) total(13) filler(40)
+//
+// Numbers are right-aligned and zero-padded, amounts carry a leading sign,
+// text is left-aligned and space-padded and dates read YYYYMMDDhhmmss in UTC.
+func WriteFixedWidth(w io.Writer, batch core.Settlement, provider core.ProviderSettlement) error {
+	writer := bufio.NewWriter(w)
+	records := []string{
+		fmt.Sprintf("H%010d%-20.20s%s%06d%9s", batch.Id, provider.Service, fixedTime(batch.Cutoff), len(provider.Entries), ""),
+	}
+	for _, entry := range provider.Entries {
+		records = append(records, fmt.Sprintf("D%012d%s%-20.20s%+013d",
+			entry.TransactionId, fixedTime(entry.Time), entry.Reference, entry.Amount))
+	}
+	records = append(records, fmt.Sprintf("T%06d%+013d%40s", len(provider.Entries), provider.Total, ""))
+	for _, record := range records {
+		_, err := writer.WriteString(record + "\r\n")
+		if err != nil {
+			return fmt.Errorf("can't write fixed-width settlement: %w", err)
+		}
+	}
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("can't write fixed-width settlement: %w", err)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+func fixedTime(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
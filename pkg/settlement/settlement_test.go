@@ -0,0 +1,112 @@
+package settlement
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func sample() core.Settlement {
+	return core.Settlement{
+		Id:     7,
+		Cutoff: time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC),
+		Status: core.SettlementSettled,
+		Time:   time.Date(2026, 3, 7, 6, 0, 0, 0, time.UTC),
+		Providers: []core.ProviderSettlement{{
+			Service: "internet",
+			Entries: []core.SettlementEntry{
+				{TransactionId: 1, Time: time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC), Reference: "100200", Amount: 200},
+				{TransactionId: 2, Time: time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC), Reference: "100201", Amount: 300},
+				{TransactionId: 3, Time: time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC), Reference: "100200", Amount: -50},
+			},
+			Total: 450,
+		}},
+	}
+}
+
+func checkGolden(t *testing.T, name string, write func(io.Writer, core.Settlement, core.ProviderSettlement) error) {
+	batch := sample()
+	var out bytes.Buffer
+	err := write(&out, batch, batch.Providers[0])
+	if err != nil {
+		t.Fatalf("can't write %s: %v", name, err)
+	}
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, out.Bytes(), 0644); err != nil {
+			t.Fatalf("can't update %s: %v", path, err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("can't read %s: %v", path, err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("%s differs from the golden file, run go test -update if the change is intended", name)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	checkGolden(t, "settlement.csv", WriteCSV)
+}
+
+func TestWriteFixedWidth(t *testing.T) {
+	checkGolden(t, "settlement.txt", WriteFixedWidth)
+	var out bytes.Buffer
+	batch := sample()
+	if err := WriteFixedWidth(&out, batch, batch.Providers[0]); err != nil {
+		t.Fatalf("can't write fixed-width settlement: %v", err)
+	}
+	for _, record := range strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n") {
+		if len(record) != 60 {
+			t.Errorf("record just be 60 characters: %d %q", len(record), record)
+		}
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settlement")
+	if err != nil {
+		t.Fatalf("can't create dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Errorf("can't remove dir: %v", err)
+		}
+	}()
+	batch := sample()
+	batch.Providers[0].Service = "tv/cable"
+	paths, err := WriteFiles(dir, batch)
+	if err != nil {
+		t.Fatalf("can't write files: %v", err)
+	}
+	want := []string{filepath.Join(dir, "settlement-000007-tv_cable.csv"), filepath.Join(dir, "settlement-000007-tv_cable.txt")}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("files just be %v: %v", want, paths)
+	}
+	first, _ := ioutil.ReadFile(paths[1])
+	_, err = WriteFiles(dir, batch)
+	if err != nil {
+		t.Fatalf("can't write files again: %v", err)
+	}
+	second, _ := ioutil.ReadFile(paths[1])
+	if !bytes.Equal(first, second) {
+		t.Errorf("regenerated file just be the same")
+	}
+	batch.Status = core.SettlementVoided
+	_, err = WriteFiles(dir, batch)
+	if !errors.Is(err, core.ErrSettlementVoided) {
+		t.Errorf("voided batch just be ErrSettlementVoided: %v", err)
+	}
+}
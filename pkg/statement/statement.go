@@ -0,0 +1,161 @@
+package statement
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+type jsonEntry struct {
+	TransactionId int64     `json:"transactionId"`
+	Time          time.Time `json:"time"`
+	Description   string    `json:"description"`
+	Amount        int       `json:"amount"`
+	Balance       int       `json:"balance"`
+}
+
+type jsonStatement struct {
+	Card           string      `json:"card"`
+	HolderName     string      `json:"holderName"`
+	From           time.Time   `json:"from"`
+	To             time.Time   `json:"to"`
+	OpeningBalance int         `json:"openingBalance"`
+	ClosingBalance int         `json:"closingBalance"`
+	Entries        []jsonEntry `json:"entries"`
+}
+
+// Describe renders an entry the way it reads on every statement format.
+func Describe(entry core.StatementEntry) string {
+	switch entry.Kind {
+	case core.KindTransfer:
+		if entry.Amount < 0 {
+			return "transfer to " + core.MaskPAN(entry.CounterPAN)
+		}
+		return "transfer from " + core.MaskPAN(entry.CounterPAN)
+	case core.KindService:
+		return "payment for " + entry.Service
+	case core.KindFee:
+		return "fee for " + entry.Service
+	case core.KindCapture:
+		return "card payment"
+	case core.KindWithdrawal:
+		return "cash withdrawal"
+	case core.KindDeposit:
+		return "cash deposit"
+	case core.KindReversal:
+		if entry.Service != "" {
+			return "refund from " + entry.Service
+		}
+		if entry.Amount < 0 {
+			return "reversal to " + core.MaskPAN(entry.CounterPAN)
+		}
+		return "reversal from " + core.MaskPAN(entry.CounterPAN)
+	}
+	return entry.Kind
+}
+
+func WriteCSV(w io.Writer, statement core.Statement) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{
+		{"date", "transaction", "description", "amount", "balance"},
+		{formatTime(statement.From), "", "opening balance", "", strconv.Itoa(statement.OpeningBalance)},
+	}
+	for _, entry := range statement.Entries {
+		records = append(records, []string{
+			formatTime(entry.Time),
+			strconv.FormatInt(entry.TransactionId, 10),
+			Describe(entry),
+			strconv.Itoa(entry.Amount),
+			strconv.Itoa(entry.Balance),
+		})
+	}
+	records = append(records, []string{formatTime(statement.To), "", "closing balance", "", strconv.Itoa(statement.ClosingBalance)})
+	err := writer.WriteAll(records)
+	if err != nil {
+		return fmt.Errorf("can't write csv statement: %w", err)
+	}
+	return nil
+}
+
+func WriteJSON(w io.Writer, statement core.Statement) error {
+	out := jsonStatement{
+		Card:           core.MaskPAN(statement.PAN),
+		HolderName:     statement.HolderName,
+		From:           statement.From.UTC(),
+		To:             statement.To.UTC(),
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		Entries:        []jsonEntry{},
+	}
+	for _, entry := range statement.Entries {
+		out.Entries = append(out.Entries, jsonEntry{
+			TransactionId: entry.TransactionId,
+			Time:          entry.Time.UTC(),
+			Description:   Describe(entry),
+			Amount:        entry.Amount,
+			Balance:       entry.Balance,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(out)
+	if err != nil {
+		return fmt.Errorf("can't write json statement: %w", err)
+	}
+	return nil
+}
+
+// WritePDF renders an A4 statement. The document dates are taken from the
+// statement period, so the same statement always renders to the same bytes.
+func WritePDF(w io.Writer, statement core.Statement) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCatalogSort(true)
+	pdf.SetCreationDate(statement.To.UTC())
+	pdf.SetModificationDate(statement.To.UTC())
+	pdf.SetTitle("Statement "+core.MaskPAN(statement.PAN), false)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Account statement", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, "Card: "+core.MaskPAN(statement.PAN), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Holder: "+statement.HolderName, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Period: "+formatTime(statement.From)+" - "+formatTime(statement.To), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{42, 22, 66, 30, 30}
+	row := func(style string, cells ...string) {
+		pdf.SetFont("Helvetica", style, 9)
+		for i, cell := range cells {
+			align := "L"
+			if i >= 3 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 7, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	row("B", "Date", "Transaction", "Description", "Amount", "Balance")
+	row("", formatTime(statement.From), "", "Opening balance", "", strconv.Itoa(statement.OpeningBalance))
+	for _, entry := range statement.Entries {
+		row("", formatTime(entry.Time), strconv.FormatInt(entry.TransactionId, 10), Describe(entry),
+			strconv.Itoa(entry.Amount), strconv.Itoa(entry.Balance))
+	}
+	row("B", formatTime(statement.To), "", "Closing balance", "", strconv.Itoa(statement.ClosingBalance))
+
+	err := pdf.Output(w)
+	if err != nil {
+		return fmt.Errorf("can't write pdf statement: %w", err)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
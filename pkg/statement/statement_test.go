@@ -0,0 +1,65 @@
+package statement
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tohirov1994/clients-core/pkg/core"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func sample() core.Statement {
+	day := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, time.UTC) }
+	return core.Statement{
+		PAN:            2021600000000001,
+		HolderName:     "JACK JACKSON",
+		From:           day(1, 0),
+		To:             day(31, 0),
+		OpeningBalance: 1000,
+		ClosingBalance: 1350,
+		Entries: []core.StatementEntry{
+			{TransactionId: 7, Time: day(2, 10), Kind: core.KindTransfer, CounterPAN: 2021600000000000, Amount: 500, Balance: 1500},
+			{TransactionId: 9, Time: day(5, 12), Kind: core.KindService, Service: "internet", Amount: -100, Balance: 1400},
+			{TransactionId: 12, Time: day(20, 18), Kind: core.KindTransfer, CounterPAN: 2021600000000002, Amount: -50, Balance: 1350},
+		},
+	}
+}
+
+func checkGolden(t *testing.T, name string, write func(io.Writer, core.Statement) error) {
+	var out bytes.Buffer
+	err := write(&out, sample())
+	if err != nil {
+		t.Fatalf("can't write %s: %v", name, err)
+	}
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, out.Bytes(), 0644); err != nil {
+			t.Fatalf("can't update %s: %v", path, err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("can't read %s: %v", path, err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("%s differs from the golden file, run go test -update if the change is intended", name)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	checkGolden(t, "statement.csv", WriteCSV)
+}
+
+func TestWriteJSON(t *testing.T) {
+	checkGolden(t, "statement.json", WriteJSON)
+}
+
+func TestWritePDF(t *testing.T) {
+	checkGolden(t, "statement.pdf", WritePDF)
+}